@@ -0,0 +1,51 @@
+#!/usr/bin/env bash
+
+# Renders the config with ytt and checks what the data values switch on and off
+
+set -eu
+
+SCRIPT_DIR="$( cd "$( dirname "${BASH_SOURCE[0]}" )" && pwd )"
+CONFIG_DIR="${SCRIPT_DIR}/../config"
+
+failures=0
+
+render() {
+  ytt -f "${CONFIG_DIR}" "$@"
+}
+
+expect_rendered() {
+  local description="$1" pattern="$2"
+  shift 2
+  if render "$@" | grep -q -- "${pattern}"; then
+    echo "ok: ${description}"
+  else
+    echo "FAIL: ${description}"
+    failures=$((failures + 1))
+  fi
+}
+
+expect_not_rendered() {
+  local description="$1" pattern="$2"
+  shift 2
+  if render "$@" | grep -q -- "${pattern}"; then
+    echo "FAIL: ${description}"
+    failures=$((failures + 1))
+  else
+    echo "ok: ${description}"
+  fi
+}
+
+expect_not_rendered "the controllers get no STACK_REBASE_ROLLOUT when stack rebase rollouts are disabled" \
+  "name: STACK_REBASE_ROLLOUT" \
+  --data-value-yaml cf_api_controllers.stack_rebase_rollout.enabled=false
+expect_rendered "the controllers get STACK_REBASE_ROLLOUT when stack rebase rollouts are enabled" \
+  "name: STACK_REBASE_ROLLOUT" \
+  --data-value-yaml cf_api_controllers.stack_rebase_rollout.enabled=true
+expect_rendered "the StackRebaseRollout is created when stack rebase rollouts are enabled" \
+  "^kind: StackRebaseRollout$" \
+  --data-value-yaml cf_api_controllers.stack_rebase_rollout.enabled=true
+
+if [ "${failures}" -gt 0 ]; then
+  echo "${failures} template test(s) failed"
+  exit 1
+fi
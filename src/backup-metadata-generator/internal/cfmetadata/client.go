@@ -1,6 +1,12 @@
 package cfmetadata
 
 import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
 	"github.com/cloudfoundry-community/go-cfclient"
 )
 
@@ -30,12 +36,30 @@ type Client struct {
 	CfClient cfclient.CloudFoundryClient
 }
 
-func NewClient(apiAddress string, clientID string, clientSecret string) (*Client, error) {
+// TLSConfig configures the connections to the CF API and UAA. Their
+// certificates are verified unless SkipSSLValidation is set.
+type TLSConfig struct {
+	// CACertFile is a PEM bundle of CAs trusted on top of the system's
+	CACertFile string
+	// ClientCertFile and ClientKeyFile are presented for mutual TLS, if set
+	ClientCertFile    string
+	ClientKeyFile     string
+	SkipSSLValidation bool
+}
+
+func NewClient(apiAddress string, clientID string, clientSecret string, tlsConfig TLSConfig) (*Client, error) {
+	httpClient, err := newHTTPClient(tlsConfig)
+	if err != nil {
+		return &Client{}, err
+	}
+
 	conf := &cfclient.Config{
-		ApiAddress:        apiAddress,
-		ClientID:          clientID,
-		ClientSecret:      clientSecret,
-		SkipSslValidation: true,
+		ApiAddress:   apiAddress,
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		HttpClient:   httpClient,
+		// cfclient sets the transport's InsecureSkipVerify from this
+		SkipSslValidation: tlsConfig.SkipSSLValidation,
 	}
 
 	cfClient, err := cfclient.NewClient(conf)
@@ -45,6 +69,38 @@ func NewClient(apiAddress string, clientID string, clientSecret string) (*Client
 	}, err
 }
 
+func newHTTPClient(config TLSConfig) (*http.Client, error) {
+	tlsClientConfig := &tls.Config{InsecureSkipVerify: config.SkipSSLValidation}
+
+	if config.CACertFile != "" {
+		caCert, err := ioutil.ReadFile(config.CACertFile)
+		if err != nil {
+			return nil, fmt.Errorf("could not read CA certificate: %s", err)
+		}
+
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(caCert) {
+			return nil, fmt.Errorf("CA certificate file %s contains no PEM encoded certificate", config.CACertFile)
+		}
+		tlsClientConfig.RootCAs = pool
+	}
+
+	if config.ClientCertFile != "" || config.ClientKeyFile != "" {
+		clientCert, err := tls.LoadX509KeyPair(config.ClientCertFile, config.ClientKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("could not load client certificate: %s", err)
+		}
+		tlsClientConfig.Certificates = []tls.Certificate{clientCert}
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsClientConfig
+	return &http.Client{Transport: transport}, nil
+}
+
 func (c *Client) Orgs() ([]Org, error) {
 	cfOrgs, err := c.CfClient.ListOrgs()
 	if err != nil {
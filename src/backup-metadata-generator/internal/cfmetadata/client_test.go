@@ -1,7 +1,10 @@
 package cfmetadata_test
 
 import (
+	"encoding/pem"
+	"io/ioutil"
 	"net/http"
+	"os"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -32,7 +35,7 @@ var _ = Describe("CF Client", func() {
 			),
 		)
 
-		cfClient, err = cfmetadata.NewClient(fakeCF.URL(), "test-cf-user", "test-cf-password")
+		cfClient, err = cfmetadata.NewClient(fakeCF.URL(), "test-cf-user", "test-cf-password", cfmetadata.TLSConfig{})
 		Expect(err).To(BeNil())
 	})
 
@@ -151,4 +154,62 @@ var _ = Describe("CF Client", func() {
 			})
 		})
 	})
+
+	Describe("TLS", func() {
+		var (
+			fakeTLSCF *ghttp.Server
+			caFile    *os.File
+		)
+
+		BeforeEach(func() {
+			fakeTLSCF = ghttp.NewTLSServer()
+			fakeTLSCF.RouteToHandler("GET", "/v2/info",
+				ghttp.RespondWith(http.StatusOK, `{"token_endpoint": "`+fakeTLSCF.URL()+`"}`),
+			)
+			fakeTLSCF.RouteToHandler("POST", "/oauth/token",
+				ghttp.RespondWith(http.StatusOK, `{"access_token": "foo"}`, http.Header{
+					"Content-Type": []string{"application/json"},
+				}),
+			)
+			fakeTLSCF.RouteToHandler("GET", "/v2/users",
+				ghttp.RespondWith(http.StatusOK, `{"resources": []}`),
+			)
+
+			caFile, err = ioutil.TempFile("", "cf-ca-cert")
+			Expect(err).NotTo(HaveOccurred())
+			err = pem.Encode(caFile, &pem.Block{Type: "CERTIFICATE", Bytes: fakeTLSCF.HTTPTestServer.Certificate().Raw})
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			fakeTLSCF.Close()
+			Expect(os.Remove(caFile.Name())).To(Succeed())
+		})
+
+		listUsers := func(tlsConfig cfmetadata.TLSConfig) error {
+			tlsCFClient, err := cfmetadata.NewClient(fakeTLSCF.URL(), "test-cf-user", "test-cf-password", tlsConfig)
+			if err != nil {
+				return err
+			}
+			_, err = tlsCFClient.Users()
+			return err
+		}
+
+		It("refuses a CF API whose certificate it can't verify", func() {
+			Expect(listUsers(cfmetadata.TLSConfig{})).NotTo(Succeed())
+		})
+
+		It("trusts the CAs of the given bundle", func() {
+			Expect(listUsers(cfmetadata.TLSConfig{CACertFile: caFile.Name()})).To(Succeed())
+		})
+
+		It("skips verification when opted in", func() {
+			Expect(listUsers(cfmetadata.TLSConfig{SkipSSLValidation: true})).To(Succeed())
+		})
+
+		It("returns an error when the CA bundle can't be read", func() {
+			_, err := cfmetadata.NewClient(fakeTLSCF.URL(), "test-cf-user", "test-cf-password", cfmetadata.TLSConfig{CACertFile: "does-not-exist.pem"})
+			Expect(err).To(MatchError(ContainSubstring("could not read CA certificate")))
+		})
+	})
 })
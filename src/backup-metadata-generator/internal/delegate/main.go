@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
+	"strconv"
 
 	"code.cloudfoundry.org/capi-k8s-release/src/backup-metadata-generator/internal/cfmetadata"
 )
@@ -34,9 +35,14 @@ func Main(args []string, input Reader, env map[string]string) error {
 		return fmt.Errorf("'CF_CLIENT_SECRET' Environment Variable is not set")
 	}
 
+	tlsConfig, err := loadTLSConfig(env)
+	if err != nil {
+		return err
+	}
+
 	switch len(args) {
 	case reportMetadata:
-		err := printMetadata(cfAPI, cfClient, cfClientSecret)
+		err := printMetadata(cfAPI, cfClient, cfClientSecret, tlsConfig)
 		if err != nil {
 			return err
 		}
@@ -45,7 +51,7 @@ func Main(args []string, input Reader, env map[string]string) error {
 			return fmt.Errorf("unknown option: %s, Did you mean compare ?", args[1])
 		}
 
-		if err := printComparison(cfAPI, cfClient, cfClientSecret, input); err != nil {
+		if err := printComparison(cfAPI, cfClient, cfClientSecret, tlsConfig, input); err != nil {
 			return err
 		}
 	default:
@@ -56,8 +62,29 @@ func Main(args []string, input Reader, env map[string]string) error {
 	return nil
 }
 
-func printMetadata(cfAPI, cfUsername, cfPassword string) error {
-	backupMetadata, err := getCurrentCFMetadata(cfAPI, cfUsername, cfPassword)
+func loadTLSConfig(env map[string]string) (cfmetadata.TLSConfig, error) {
+	tlsConfig := cfmetadata.TLSConfig{
+		CACertFile:     env["CF_CA_CERT_FILE"],
+		ClientCertFile: env["CF_CLIENT_CERT_FILE"],
+		ClientKeyFile:  env["CF_CLIENT_KEY_FILE"],
+	}
+
+	if (tlsConfig.ClientCertFile == "") != (tlsConfig.ClientKeyFile == "") {
+		return cfmetadata.TLSConfig{}, fmt.Errorf("'CF_CLIENT_CERT_FILE' and 'CF_CLIENT_KEY_FILE' Environment Variables must be set together")
+	}
+
+	if skip := env["CF_SKIP_SSL_VALIDATION"]; skip != "" {
+		var err error
+		if tlsConfig.SkipSSLValidation, err = strconv.ParseBool(skip); err != nil {
+			return cfmetadata.TLSConfig{}, fmt.Errorf("'CF_SKIP_SSL_VALIDATION' Environment Variable must be a boolean, got %q", skip)
+		}
+	}
+
+	return tlsConfig, nil
+}
+
+func printMetadata(cfAPI, cfUsername, cfPassword string, tlsConfig cfmetadata.TLSConfig) error {
+	backupMetadata, err := getCurrentCFMetadata(cfAPI, cfUsername, cfPassword, tlsConfig)
 	if err != nil {
 		return err
 	}
@@ -67,7 +94,7 @@ func printMetadata(cfAPI, cfUsername, cfPassword string) error {
 	return nil
 }
 
-func printComparison(cfAPI, cfUsername, cfPassword string, input Reader) error {
+func printComparison(cfAPI, cfUsername, cfPassword string, tlsConfig cfmetadata.TLSConfig, input Reader) error {
 	var (
 		diff        string
 		err         error
@@ -79,7 +106,7 @@ func printComparison(cfAPI, cfUsername, cfPassword string, input Reader) error {
 		return err
 	}
 
-	backupMetadata, err := getCurrentCFMetadata(cfAPI, cfUsername, cfPassword)
+	backupMetadata, err := getCurrentCFMetadata(cfAPI, cfUsername, cfPassword, tlsConfig)
 	if err != nil {
 		return err
 	}
@@ -103,11 +130,12 @@ func toStr(metadata cfmetadata.Metadata) string {
 	return string(ret)
 }
 
-func getCurrentCFMetadata(cfAPI string, cfUsername string, cfPassword string) (*cfmetadata.Metadata, error) {
+func getCurrentCFMetadata(cfAPI string, cfUsername string, cfPassword string, tlsConfig cfmetadata.TLSConfig) (*cfmetadata.Metadata, error) {
 	client, err := cfmetadata.NewClient(
 		cfAPI,
 		cfUsername,
-		cfPassword)
+		cfPassword,
+		tlsConfig)
 	if err != nil {
 		return &cfmetadata.Metadata{}, err
 	}
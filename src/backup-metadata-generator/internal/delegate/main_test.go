@@ -41,4 +41,23 @@ var _ = Describe("Main", func() {
 		Entry("CF_CLIENT is missing", map[string]string{"CF_API_HOST": "api.cf.example.com", "CF_CLIENT_SECRET": "fake-uaa-client-secret"}),
 		Entry("CF_CLIENT_SECRET is missing", map[string]string{"CF_API_HOST": "api.cf.example.com", "CF_CLIENT": "fake-uaa-client"}),
 	)
+
+	DescribeTable("when TLS environment variables are invalid",
+		func(env map[string]string, errorMessage string) {
+			env["CF_API_HOST"] = "api.cf.example.com"
+			env["CF_CLIENT"] = "fake-uaa-client"
+			env["CF_CLIENT_SECRET"] = "fake-uaa-client-secret"
+			stdIn := new(delegatefakes.FakeReader)
+
+			err := delegate.Main([]string{"", "compare"}, stdIn, env)
+			Expect(err).To(MatchError(errorMessage))
+			Expect(stdIn.ReadCallCount()).To(Equal(0))
+		},
+		Entry("CF_SKIP_SSL_VALIDATION is not a boolean",
+			map[string]string{"CF_SKIP_SSL_VALIDATION": "sure"},
+			`'CF_SKIP_SSL_VALIDATION' Environment Variable must be a boolean, got "sure"`),
+		Entry("CF_CLIENT_CERT_FILE is set without CF_CLIENT_KEY_FILE",
+			map[string]string{"CF_CLIENT_CERT_FILE": "/config/tls.crt"},
+			"'CF_CLIENT_CERT_FILE' and 'CF_CLIENT_KEY_FILE' Environment Variables must be set together"),
+	)
 })
@@ -0,0 +1,161 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	ValidConditionType = "Valid"
+
+	AppliedConditionReason     = "Applied"
+	InvalidConditionReason     = "Invalid"
+	ApplyFailedConditionReason = "ApplyFailed"
+)
+
+// CFAPIControllersConfigSpec configures how the cf-api-controllers reach the
+// CF API and which of them run
+type CFAPIControllersConfigSpec struct {
+	// CFAPIHost is the URL of the CF API, e.g. http://capi.cf-system.svc.cluster.local
+	CFAPIHost string `json:"cfAPIHost"`
+
+	// UAA is the UAA client the controllers authenticate to the CF API as
+	UAA UAAClientConfig `json:"uaa"`
+
+	// WorkloadsNamespace is the namespace of the apps' workloads and routes.
+	// Changing it restarts the controllers.
+	WorkloadsNamespace string `json:"workloadsNamespace"`
+
+	// TLS configures the connections to the CF API and UAA
+	// +optional
+	TLS TLSConfig `json:"tls,omitempty"`
+
+	// Timeouts bound the requests the controllers make
+	// +optional
+	Timeouts TimeoutsConfig `json:"timeouts,omitempty"`
+
+	// Controllers turns individual controllers off
+	// +optional
+	Controllers ControllersEnabled `json:"controllers,omitempty"`
+}
+
+// UAAClientConfig is a UAA client and where its secret is kept
+type UAAClientConfig struct {
+	// Endpoint is the URL of UAA
+	Endpoint string `json:"endpoint"`
+
+	// ClientName is the name of the UAA client
+	ClientName string `json:"clientName"`
+
+	// ClientSecretRef references the UAA client's secret
+	ClientSecretRef SecretKeyReference `json:"clientSecretRef"`
+}
+
+// SecretKeyReference references a key of a Secret
+type SecretKeyReference struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	Key       string `json:"key"`
+}
+
+// SecretReference references a Secret
+type SecretReference struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+}
+
+// TLSConfig configures the TLS connections to the CF API and UAA. The
+// servers' certificates are verified unless InsecureSkipVerify is set.
+type TLSConfig struct {
+	// CACertificate is a PEM bundle of CAs trusted on top of the system's
+	// +optional
+	CACertificate string `json:"caCertificate,omitempty"`
+
+	// ClientCertificateSecretRef references a kubernetes.io/tls Secret whose
+	// certificate is presented to the CF API and UAA for mutual TLS
+	// +optional
+	ClientCertificateSecretRef *SecretReference `json:"clientCertificateSecretRef,omitempty"`
+
+	// InsecureSkipVerify turns off verification of the servers' certificates
+	// +optional
+	InsecureSkipVerify bool `json:"insecureSkipVerify,omitempty"`
+}
+
+// TimeoutsConfig bounds the requests to the CF API, UAA and image registries
+type TimeoutsConfig struct {
+	// CFAPI bounds each request to the CF API and UAA, 30s by default
+	// +optional
+	CFAPI *metav1.Duration `json:"cfAPI,omitempty"`
+
+	// ImageRegistry bounds fetching the config of a built image from its
+	// registry, 1m by default
+	// +optional
+	ImageRegistry *metav1.Duration `json:"imageRegistry,omitempty"`
+}
+
+// ControllersEnabled turns individual controllers on or off. A controller
+// that isn't listed is on.
+type ControllersEnabled struct {
+	// +optional
+	Build *bool `json:"build,omitempty"`
+	// +optional
+	Image *bool `json:"image,omitempty"`
+	// +optional
+	PeriodicSync *bool `json:"periodicSync,omitempty"`
+	// +optional
+	Route *bool `json:"route,omitempty"`
+}
+
+// CFAPIControllersConfigStatus shows whether the config was applied
+type CFAPIControllersConfigStatus struct {
+	Conditions []Condition `json:"conditions"`
+
+	// ObservedGeneration is the generation of the spec last validated
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope=Cluster
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="CF API",type="string",JSONPath=`.spec.cfAPIHost`
+// +kubebuilder:printcolumn:name="Valid",type="string",JSONPath=`.status.conditions[?(@.type=="Valid")].status`
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=`.metadata.creationTimestamp`
+
+// CFAPIControllersConfig is the Schema for the cfapicontrollersconfigs API.
+// The cf-api-controllers apply changes to it without restarting.
+type CFAPIControllersConfig struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   CFAPIControllersConfigSpec   `json:"spec,omitempty"`
+	Status CFAPIControllersConfigStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// CFAPIControllersConfigList contains a list of CFAPIControllersConfig
+type CFAPIControllersConfigList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []CFAPIControllersConfig `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&CFAPIControllersConfig{}, &CFAPIControllersConfigList{})
+}
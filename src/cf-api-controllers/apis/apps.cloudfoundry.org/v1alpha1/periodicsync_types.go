@@ -42,10 +42,47 @@ type Condition struct {
 	Message            string          `json:"message"`
 }
 
+// SetCondition replaces the condition of the same type in conditions, or adds
+// it. Its LastTransitionTime is now, unless the status stays the same.
+func SetCondition(conditions *[]Condition, condition Condition) {
+	condition.LastTransitionTime = metav1.Now()
+	for i, existing := range *conditions {
+		if existing.Type == condition.Type {
+			if existing.Status == condition.Status {
+				condition.LastTransitionTime = existing.LastTransitionTime
+			}
+			(*conditions)[i] = condition
+			return
+		}
+	}
+	*conditions = append(*conditions, condition)
+}
+
 const (
-	SyncedConditionType      = "Synced"
-	CompletedConditionReason = "Completed"
-	FailedConditionReason    = "Failed"
+	SyncedConditionType            = "Synced"
+	DeletionThrottledConditionType = "DeletionThrottled"
+
+	CompletedConditionReason              = "Completed"
+	FailedConditionReason                 = "Failed"
+	DeletionBudgetExceededConditionReason = "DeletionBudgetExceeded"
+	WithinDeletionBudgetConditionReason   = "WithinDeletionBudget"
+	DryRunConditionReason                 = "DryRun"
+
+	// UnsupportedResourcesEventReason marks the events reporting resources a
+	// sync couldn't fully express in k8s
+	UnsupportedResourcesEventReason = "UnsupportedResources"
+)
+
+// ResourceType selects what a PeriodicSync converges
+// +kubebuilder:validation:Enum=Route;DropletImage
+type ResourceType string
+
+const (
+	// RouteResourceType syncs CF routes to Route resources
+	RouteResourceType ResourceType = "Route"
+	// DropletImageResourceType syncs the images of kpack Images to their CF
+	// droplets and, after stack updates, to the app's StatefulSets
+	DropletImageResourceType ResourceType = "DropletImage"
 )
 
 // PeriodicSyncSpec defines the desired state of PeriodicSync
@@ -54,6 +91,37 @@ type PeriodicSyncSpec struct {
 	// Important: Run "make" to regenerate code after modifying this file
 
 	PeriodSeconds int32 `json:"period_seconds"`
+
+	// ResourceType is the type of resource to sync, Route if unset
+	// +kubebuilder:default=Route
+	// +optional
+	ResourceType ResourceType `json:"resourceType,omitempty"`
+
+	// DeletionBudget caps how many resources a single sync may delete. When a
+	// sync would exceed it, no resources are deleted in that sync.
+	// +optional
+	DeletionBudget *DeletionBudget `json:"deletionBudget,omitempty"`
+
+	// DryRun makes the sync report the changes it would make in
+	// status.plan instead of applying them
+	// +optional
+	DryRun bool `json:"dryRun,omitempty"`
+}
+
+// DeletionBudget limits deletions per sync. A zero value for either field
+// means that limit is not enforced.
+type DeletionBudget struct {
+	// MaxCount is the largest number of resources deleted in one sync
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MaxCount int32 `json:"maxCount,omitempty"`
+
+	// MaxPercentage is the largest share of the existing resources, from 0 to
+	// 100, deleted in one sync
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=100
+	// +optional
+	MaxPercentage int32 `json:"maxPercentage,omitempty"`
 }
 
 // PeriodicSyncStatus defines the observed state of PeriodicSync
@@ -61,10 +129,82 @@ type PeriodicSyncStatus struct {
 	// INSERT ADDITIONAL STATUS FIELD - define observed state of cluster
 	// Important: Run "make" to regenerate code after modifying this file
 	Conditions []Condition `json:"conditions"`
+
+	// ObservedGeneration is the generation of the spec used by the last sync
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// LastSyncTime is when the last sync started
+	// +optional
+	LastSyncTime *metav1.Time `json:"lastSyncTime,omitempty"`
+
+	// LastSuccessfulSyncTime is when the last sync that completed without
+	// errors started
+	// +optional
+	LastSuccessfulSyncTime *metav1.Time `json:"lastSuccessfulSyncTime,omitempty"`
+
+	// LastSyncDuration is how long the last sync took
+	// +optional
+	LastSyncDuration *metav1.Duration `json:"lastSyncDuration,omitempty"`
+
+	// LastSyncResult counts the changes applied by the last sync
+	// +optional
+	LastSyncResult SyncResult `json:"lastSyncResult,omitempty"`
+
+	// FailedGUIDs lists the first few resources the last sync failed to change
+	// +optional
+	FailedGUIDs []string `json:"failedGUIDs,omitempty"`
+
+	// UnsupportedGUIDs lists the first few resources the last sync could only
+	// sync in part, or had to leave as they are, because k8s can't express
+	// all of them
+	// +optional
+	UnsupportedGUIDs []string `json:"unsupportedGUIDs,omitempty"`
+
+	// Plan holds the changes found by the last dry-run sync
+	// +optional
+	Plan *PlannedChanges `json:"plan,omitempty"`
+}
+
+// SyncResult counts the resources a sync changed, and those it failed to
+type SyncResult struct {
+	Created int32 `json:"created"`
+	Updated int32 `json:"updated"`
+	Deleted int32 `json:"deleted"`
+	Failed  int32 `json:"failed"`
+	// Unsupported counts the resources that k8s can't fully express, which
+	// don't fail the sync
+	// +optional
+	Unsupported int32 `json:"unsupported,omitempty"`
+}
+
+// PlannedChanges describes the changes a dry-run sync would have made.
+// Delete includes resources the deletion budget would have protected.
+type PlannedChanges struct {
+	Create PlannedAction `json:"create"`
+	Update PlannedAction `json:"update"`
+	Delete PlannedAction `json:"delete"`
+}
+
+// PlannedAction counts the resources affected by one kind of change and
+// lists the GUIDs of the first few of them
+type PlannedAction struct {
+	Count int32    `json:"count"`
+	GUIDs []string `json:"guids,omitempty"`
 }
 
 // +kubebuilder:object:root=true
 // +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Type",type="string",JSONPath=`.spec.resourceType`
+// +kubebuilder:printcolumn:name="Synced",type="string",JSONPath=`.status.conditions[?(@.type=="Synced")].status`
+// +kubebuilder:printcolumn:name="Last Sync",type="date",JSONPath=`.status.lastSyncTime`
+// +kubebuilder:printcolumn:name="Last Success",type="date",JSONPath=`.status.lastSuccessfulSyncTime`
+// +kubebuilder:printcolumn:name="Duration",type="string",JSONPath=`.status.lastSyncDuration`
+// +kubebuilder:printcolumn:name="Created",type="integer",JSONPath=`.status.lastSyncResult.created`,priority=1
+// +kubebuilder:printcolumn:name="Updated",type="integer",JSONPath=`.status.lastSyncResult.updated`,priority=1
+// +kubebuilder:printcolumn:name="Deleted",type="integer",JSONPath=`.status.lastSyncResult.deleted`,priority=1
+// +kubebuilder:printcolumn:name="Failed",type="integer",JSONPath=`.status.lastSyncResult.failed`
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=`.metadata.creationTimestamp`
 
 // PeriodicSync is the Schema for the periodicsyncs API
 type PeriodicSync struct {
@@ -87,3 +227,12 @@ type PeriodicSyncList struct {
 func init() {
 	SchemeBuilder.Register(&PeriodicSync{}, &PeriodicSyncList{})
 }
+
+// SyncedResourceType returns the resource type to sync, defaulting to Route
+// for objects created before the field existed
+func (p *PeriodicSync) SyncedResourceType() ResourceType {
+	if p.Spec.ResourceType == "" {
+		return RouteResourceType
+	}
+	return p.Spec.ResourceType
+}
@@ -0,0 +1,124 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// StackRebaseOptOutLabel is the CF metadata label that, set to "true" on an
+// org or space, keeps stack rebases from restarting its apps
+const StackRebaseOptOutLabel = "stack-rebase.cloudfoundry.org/opt-out"
+
+const (
+	RollingOutConditionType = "RollingOut"
+
+	PausedConditionReason         = "Paused"
+	BatchFullConditionReason      = "BatchFull"
+	BetweenBatchesConditionReason = "BetweenBatches"
+	ProgressingConditionReason    = "Progressing"
+)
+
+// StackRebaseRolloutSpec defines how rebased app images are rolled out after
+// a stack update
+type StackRebaseRolloutSpec struct {
+	// MaxConcurrentRestarts is the number of apps restarted in a single batch.
+	// The next batch only starts once every app in the current one is ready.
+	// +kubebuilder:validation:Minimum=1
+	MaxConcurrentRestarts int32 `json:"maxConcurrentRestarts"`
+
+	// PauseBetweenBatchesSeconds is how long to wait after a batch has finished
+	// restarting before starting the next one
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	PauseBetweenBatchesSeconds int32 `json:"pauseBetweenBatchesSeconds,omitempty"`
+
+	// Paused stops any more apps from being restarted until it is unset
+	// +optional
+	Paused bool `json:"paused,omitempty"`
+}
+
+// StackRebaseRolloutStatus shows the progress of the rollout
+type StackRebaseRolloutStatus struct {
+	Conditions []Condition `json:"conditions"`
+
+	// ObservedGeneration is the generation of the spec last acted on
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// BatchStartTime is when the current batch started
+	// +optional
+	BatchStartTime *metav1.Time `json:"batchStartTime,omitempty"`
+
+	// BatchRestarts is the number of apps restarted in the current batch
+	// +optional
+	BatchRestarts int32 `json:"batchRestarts,omitempty"`
+
+	// RestartingAppGUIDs are the apps of the current batch whose workloads
+	// are not yet all ready on their rebased image
+	// +optional
+	RestartingAppGUIDs []string `json:"restartingAppGUIDs,omitempty"`
+
+	// LastBatchFinishTime is when every app of the previous batch was ready
+	// +optional
+	LastBatchFinishTime *metav1.Time `json:"lastBatchFinishTime,omitempty"`
+
+	// Rebased is the number of apps rebased under this rollout
+	// +optional
+	Rebased int32 `json:"rebased,omitempty"`
+
+	// OptedOut is the number of rebased images not rolled out because the
+	// app's org or space opted out
+	// +optional
+	OptedOut int32 `json:"optedOut,omitempty"`
+
+	// LastRebasedAppGUID is the app most recently restarted
+	// +optional
+	LastRebasedAppGUID string `json:"lastRebasedAppGUID,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Paused",type="boolean",JSONPath=`.spec.paused`
+// +kubebuilder:printcolumn:name="Rolling Out",type="string",JSONPath=`.status.conditions[?(@.type=="RollingOut")].reason`
+// +kubebuilder:printcolumn:name="Batch",type="integer",JSONPath=`.status.batchRestarts`
+// +kubebuilder:printcolumn:name="Rebased",type="integer",JSONPath=`.status.rebased`
+// +kubebuilder:printcolumn:name="Opted Out",type="integer",JSONPath=`.status.optedOut`
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=`.metadata.creationTimestamp`
+
+// StackRebaseRollout is the Schema for the stackrebaserollouts API. The Image
+// controller restarts apps onto rebased images according to its spec.
+type StackRebaseRollout struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   StackRebaseRolloutSpec   `json:"spec,omitempty"`
+	Status StackRebaseRolloutStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// StackRebaseRolloutList contains a list of StackRebaseRollout
+type StackRebaseRolloutList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []StackRebaseRollout `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&StackRebaseRollout{}, &StackRebaseRolloutList{})
+}
@@ -21,9 +21,110 @@ limitations under the License.
 package v1alpha1
 
 import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CFAPIControllersConfig) DeepCopyInto(out *CFAPIControllersConfig) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CFAPIControllersConfig.
+func (in *CFAPIControllersConfig) DeepCopy() *CFAPIControllersConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(CFAPIControllersConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *CFAPIControllersConfig) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CFAPIControllersConfigList) DeepCopyInto(out *CFAPIControllersConfigList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]CFAPIControllersConfig, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CFAPIControllersConfigList.
+func (in *CFAPIControllersConfigList) DeepCopy() *CFAPIControllersConfigList {
+	if in == nil {
+		return nil
+	}
+	out := new(CFAPIControllersConfigList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *CFAPIControllersConfigList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CFAPIControllersConfigSpec) DeepCopyInto(out *CFAPIControllersConfigSpec) {
+	*out = *in
+	out.UAA = in.UAA
+	in.TLS.DeepCopyInto(&out.TLS)
+	in.Timeouts.DeepCopyInto(&out.Timeouts)
+	in.Controllers.DeepCopyInto(&out.Controllers)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CFAPIControllersConfigSpec.
+func (in *CFAPIControllersConfigSpec) DeepCopy() *CFAPIControllersConfigSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(CFAPIControllersConfigSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CFAPIControllersConfigStatus) DeepCopyInto(out *CFAPIControllersConfigStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CFAPIControllersConfigStatus.
+func (in *CFAPIControllersConfigStatus) DeepCopy() *CFAPIControllersConfigStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(CFAPIControllersConfigStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Condition) DeepCopyInto(out *Condition) {
 	*out = *in
@@ -40,12 +141,62 @@ func (in *Condition) DeepCopy() *Condition {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ControllersEnabled) DeepCopyInto(out *ControllersEnabled) {
+	*out = *in
+	if in.Build != nil {
+		in, out := &in.Build, &out.Build
+		*out = new(bool)
+		**out = **in
+	}
+	if in.Image != nil {
+		in, out := &in.Image, &out.Image
+		*out = new(bool)
+		**out = **in
+	}
+	if in.PeriodicSync != nil {
+		in, out := &in.PeriodicSync, &out.PeriodicSync
+		*out = new(bool)
+		**out = **in
+	}
+	if in.Route != nil {
+		in, out := &in.Route, &out.Route
+		*out = new(bool)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ControllersEnabled.
+func (in *ControllersEnabled) DeepCopy() *ControllersEnabled {
+	if in == nil {
+		return nil
+	}
+	out := new(ControllersEnabled)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DeletionBudget) DeepCopyInto(out *DeletionBudget) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DeletionBudget.
+func (in *DeletionBudget) DeepCopy() *DeletionBudget {
+	if in == nil {
+		return nil
+	}
+	out := new(DeletionBudget)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PeriodicSync) DeepCopyInto(out *PeriodicSync) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	out.Spec = in.Spec
+	in.Spec.DeepCopyInto(&out.Spec)
 	in.Status.DeepCopyInto(&out.Status)
 }
 
@@ -102,6 +253,11 @@ func (in *PeriodicSyncList) DeepCopyObject() runtime.Object {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PeriodicSyncSpec) DeepCopyInto(out *PeriodicSyncSpec) {
 	*out = *in
+	if in.DeletionBudget != nil {
+		in, out := &in.DeletionBudget, &out.DeletionBudget
+		*out = new(DeletionBudget)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PeriodicSyncSpec.
@@ -124,6 +280,34 @@ func (in *PeriodicSyncStatus) DeepCopyInto(out *PeriodicSyncStatus) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.LastSyncTime != nil {
+		in, out := &in.LastSyncTime, &out.LastSyncTime
+		*out = (*in).DeepCopy()
+	}
+	if in.LastSuccessfulSyncTime != nil {
+		in, out := &in.LastSuccessfulSyncTime, &out.LastSuccessfulSyncTime
+		*out = (*in).DeepCopy()
+	}
+	if in.LastSyncDuration != nil {
+		in, out := &in.LastSyncDuration, &out.LastSyncDuration
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.FailedGUIDs != nil {
+		in, out := &in.FailedGUIDs, &out.FailedGUIDs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.UnsupportedGUIDs != nil {
+		in, out := &in.UnsupportedGUIDs, &out.UnsupportedGUIDs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Plan != nil {
+		in, out := &in.Plan, &out.Plan
+		*out = new(PlannedChanges)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PeriodicSyncStatus.
@@ -135,3 +319,256 @@ func (in *PeriodicSyncStatus) DeepCopy() *PeriodicSyncStatus {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PlannedAction) DeepCopyInto(out *PlannedAction) {
+	*out = *in
+	if in.GUIDs != nil {
+		in, out := &in.GUIDs, &out.GUIDs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlannedAction.
+func (in *PlannedAction) DeepCopy() *PlannedAction {
+	if in == nil {
+		return nil
+	}
+	out := new(PlannedAction)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PlannedChanges) DeepCopyInto(out *PlannedChanges) {
+	*out = *in
+	in.Create.DeepCopyInto(&out.Create)
+	in.Update.DeepCopyInto(&out.Update)
+	in.Delete.DeepCopyInto(&out.Delete)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlannedChanges.
+func (in *PlannedChanges) DeepCopy() *PlannedChanges {
+	if in == nil {
+		return nil
+	}
+	out := new(PlannedChanges)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SecretKeyReference) DeepCopyInto(out *SecretKeyReference) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SecretKeyReference.
+func (in *SecretKeyReference) DeepCopy() *SecretKeyReference {
+	if in == nil {
+		return nil
+	}
+	out := new(SecretKeyReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SecretReference) DeepCopyInto(out *SecretReference) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SecretReference.
+func (in *SecretReference) DeepCopy() *SecretReference {
+	if in == nil {
+		return nil
+	}
+	out := new(SecretReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StackRebaseRollout) DeepCopyInto(out *StackRebaseRollout) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Spec = in.Spec
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StackRebaseRollout.
+func (in *StackRebaseRollout) DeepCopy() *StackRebaseRollout {
+	if in == nil {
+		return nil
+	}
+	out := new(StackRebaseRollout)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *StackRebaseRollout) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StackRebaseRolloutList) DeepCopyInto(out *StackRebaseRolloutList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]StackRebaseRollout, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StackRebaseRolloutList.
+func (in *StackRebaseRolloutList) DeepCopy() *StackRebaseRolloutList {
+	if in == nil {
+		return nil
+	}
+	out := new(StackRebaseRolloutList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *StackRebaseRolloutList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StackRebaseRolloutSpec) DeepCopyInto(out *StackRebaseRolloutSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StackRebaseRolloutSpec.
+func (in *StackRebaseRolloutSpec) DeepCopy() *StackRebaseRolloutSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(StackRebaseRolloutSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StackRebaseRolloutStatus) DeepCopyInto(out *StackRebaseRolloutStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.BatchStartTime != nil {
+		in, out := &in.BatchStartTime, &out.BatchStartTime
+		*out = (*in).DeepCopy()
+	}
+	if in.RestartingAppGUIDs != nil {
+		in, out := &in.RestartingAppGUIDs, &out.RestartingAppGUIDs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.LastBatchFinishTime != nil {
+		in, out := &in.LastBatchFinishTime, &out.LastBatchFinishTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StackRebaseRolloutStatus.
+func (in *StackRebaseRolloutStatus) DeepCopy() *StackRebaseRolloutStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(StackRebaseRolloutStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SyncResult) DeepCopyInto(out *SyncResult) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SyncResult.
+func (in *SyncResult) DeepCopy() *SyncResult {
+	if in == nil {
+		return nil
+	}
+	out := new(SyncResult)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TLSConfig) DeepCopyInto(out *TLSConfig) {
+	*out = *in
+	if in.ClientCertificateSecretRef != nil {
+		in, out := &in.ClientCertificateSecretRef, &out.ClientCertificateSecretRef
+		*out = new(SecretReference)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TLSConfig.
+func (in *TLSConfig) DeepCopy() *TLSConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(TLSConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TimeoutsConfig) DeepCopyInto(out *TimeoutsConfig) {
+	*out = *in
+	if in.CFAPI != nil {
+		in, out := &in.CFAPI, &out.CFAPI
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.ImageRegistry != nil {
+		in, out := &in.ImageRegistry, &out.ImageRegistry
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TimeoutsConfig.
+func (in *TimeoutsConfig) DeepCopy() *TimeoutsConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(TimeoutsConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UAAClientConfig) DeepCopyInto(out *UAAClientConfig) {
+	*out = *in
+	out.ClientSecretRef = in.ClientSecretRef
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UAAClientConfig.
+func (in *UAAClientConfig) DeepCopy() *UAAClientConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(UAAClientConfig)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -0,0 +1,15 @@
+package auth_test
+
+import (
+	"testing"
+
+	"github.com/matt-royal/biloba"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestAuth(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecsWithDefaultAndCustomReporters(t, "Auth Suite", biloba.GoLandReporter())
+}
@@ -0,0 +1,155 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/capi-k8s-release/src/cf-api-controllers/metrics"
+	"golang.org/x/oauth2"
+)
+
+// refreshBefore is how long before its expiry a token is refreshed in the
+// background, while it is still handed out
+const refreshBefore = 5 * time.Minute
+
+// expiryDelta is how long before its expiry a token is no longer handed out,
+// leaving time for the request it authenticates
+const expiryDelta = 10 * time.Second
+
+// tokenRequestTimeout bounds each request for a new token
+const tokenRequestTimeout = 30 * time.Second
+
+// a failed background refresh isn't retried for minRefreshBackoff, doubling
+// with each further failure up to maxRefreshBackoff, so that a degraded UAA
+// isn't asked for a token on every request while the cached one is valid
+const (
+	minRefreshBackoff = 10 * time.Second
+	maxRefreshBackoff = time.Minute
+)
+
+//go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 . TokenRequester
+
+// TokenRequester requests a new token from UAA
+type TokenRequester interface {
+	Token(ctx context.Context) (*oauth2.Token, error)
+}
+
+// TokenSource caches the token of a TokenRequester until shortly before it
+// expires and refreshes it in the background ahead of that, so that callers
+// rarely wait for UAA. Concurrent callers share a single request for a new
+// token.
+type TokenSource struct {
+	requester TokenRequester
+
+	mu      sync.Mutex
+	token   *oauth2.Token
+	request *tokenRequest
+	// refreshBackoff is how long the last failed refresh holds off the next
+	// background one, which isn't started before nextRefresh
+	refreshBackoff time.Duration
+	nextRefresh    time.Time
+}
+
+// tokenRequest is a request for a new token that is in flight
+type tokenRequest struct {
+	done  chan struct{}
+	token *oauth2.Token
+	err   error
+}
+
+func NewTokenSource(requester TokenRequester) *TokenSource {
+	return &TokenSource{requester: requester}
+}
+
+// Fetch implements the cf.TokenFetcher interface, returning the cached access
+// token or waiting for a new one when there is none that is still valid. A
+// caller that stops waiting as ctx is done leaves the request to the others.
+func (s *TokenSource) Fetch(ctx context.Context) (string, error) {
+	s.mu.Lock()
+	if s.token != nil {
+		if s.token.Expiry.IsZero() {
+			defer s.mu.Unlock()
+			return s.token.AccessToken, nil
+		}
+
+		remaining := time.Until(s.token.Expiry)
+		if remaining > expiryDelta {
+			if remaining <= refreshBefore && !time.Now().Before(s.nextRefresh) {
+				s.startRequest()
+			}
+			defer s.mu.Unlock()
+			return s.token.AccessToken, nil
+		}
+	}
+	request := s.startRequest()
+	s.mu.Unlock()
+
+	select {
+	case <-request.done:
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+	if request.err != nil {
+		return "", request.err
+	}
+	return request.token.AccessToken, nil
+}
+
+// Invalidate drops the cached token, e.g. once CC has rejected it because the
+// client's secret was rotated, so that the next Fetch waits for a new one
+// rather than handing it out until it expires
+func (s *TokenSource) Invalidate() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.token = nil
+}
+
+// startRequest requests a new token unless a request is in flight already.
+// s.mu must be held.
+func (s *TokenSource) startRequest() *tokenRequest {
+	if s.request != nil {
+		return s.request
+	}
+
+	request := &tokenRequest{done: make(chan struct{})}
+	s.request = request
+	go func() {
+		defer close(request.done)
+
+		// not bound to any caller, as callers share the request
+		ctx, cancel := context.WithTimeout(context.Background(), tokenRequestTimeout)
+		defer cancel()
+		start := time.Now()
+		request.token, request.err = s.requester.Token(ctx)
+		metrics.UAATokenFetchDuration.Observe(time.Since(start).Seconds())
+		if request.err == nil && (request.token == nil || request.token.AccessToken == "") {
+			request.err = errors.New("UAA returned an empty access token")
+		}
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.request = nil
+		if request.err != nil {
+			// a token that is still valid keeps being handed out
+			metrics.UAATokenFetchFailures.Inc()
+			s.refreshBackoff *= 2
+			if s.refreshBackoff < minRefreshBackoff {
+				s.refreshBackoff = minRefreshBackoff
+			}
+			if s.refreshBackoff > maxRefreshBackoff {
+				s.refreshBackoff = maxRefreshBackoff
+			}
+			s.nextRefresh = time.Now().Add(s.refreshBackoff)
+			return
+		}
+		s.refreshBackoff = 0
+		s.nextRefresh = time.Time{}
+		s.token = request.token
+		if !s.token.Expiry.IsZero() {
+			metrics.UAATokenExpiry.Set(float64(s.token.Expiry.Unix()))
+		}
+	}()
+	return request
+}
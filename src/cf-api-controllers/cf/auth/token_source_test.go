@@ -0,0 +1,219 @@
+package auth_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"golang.org/x/oauth2"
+
+	. "code.cloudfoundry.org/capi-k8s-release/src/cf-api-controllers/cf/auth"
+	"code.cloudfoundry.org/capi-k8s-release/src/cf-api-controllers/cf/auth/authfakes"
+	"code.cloudfoundry.org/capi-k8s-release/src/cf-api-controllers/metrics"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("TokenSource", func() {
+	var (
+		requester   *authfakes.FakeTokenRequester
+		tokenSource *TokenSource
+		expiresIn   time.Duration
+	)
+
+	BeforeEach(func() {
+		requester = new(authfakes.FakeTokenRequester)
+		expiresIn = time.Hour
+		// only the first token lives expiresIn, so that refreshing it doesn't
+		// start another refresh outliving the test
+		fakeRequester := requester
+		requester.TokenCalls(func(context.Context) (*oauth2.Token, error) {
+			call := fakeRequester.TokenCallCount()
+			expiry := time.Now().Add(time.Hour)
+			if call == 1 {
+				expiry = time.Now().Add(expiresIn)
+			}
+			return &oauth2.Token{AccessToken: fmt.Sprintf("token-%d", call), Expiry: expiry}, nil
+		})
+
+		tokenSource = NewTokenSource(requester)
+	})
+
+	It("requests a token with a deadline", func() {
+		token, err := tokenSource.Fetch(context.Background())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(token).To(Equal("token-1"))
+
+		_, hasDeadline := requester.TokenArgsForCall(0).Deadline()
+		Expect(hasDeadline).To(BeTrue())
+	})
+
+	It("caches the token until shortly before it expires", func() {
+		for i := 0; i < 3; i++ {
+			token, err := tokenSource.Fetch(context.Background())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(token).To(Equal("token-1"))
+		}
+		Expect(requester.TokenCallCount()).To(Equal(1))
+	})
+
+	It("requests a new token once the cached one is invalidated", func() {
+		token, err := tokenSource.Fetch(context.Background())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(token).To(Equal("token-1"))
+
+		tokenSource.Invalidate()
+
+		token, err = tokenSource.Fetch(context.Background())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(token).To(Equal("token-2"))
+		Expect(requester.TokenCallCount()).To(Equal(2))
+	})
+
+	It("exposes when the cached token expires", func() {
+		_, err := tokenSource.Fetch(context.Background())
+		Expect(err).NotTo(HaveOccurred())
+
+		Eventually(func() float64 { return testutil.ToFloat64(metrics.UAATokenExpiry) }).
+			Should(BeNumerically("~", time.Now().Add(time.Hour).Unix(), 5))
+	})
+
+	When("the token expires within minutes", func() {
+		BeforeEach(func() {
+			expiresIn = 2 * time.Minute
+			_, err := tokenSource.Fetch(context.Background())
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("hands it out while refreshing it in the background", func() {
+			token, err := tokenSource.Fetch(context.Background())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(token).To(Equal("token-1"))
+
+			Eventually(requester.TokenCallCount).Should(Equal(2))
+			Eventually(func() (string, error) { return tokenSource.Fetch(context.Background()) }).Should(Equal("token-2"))
+		})
+
+		When("refreshing it fails", func() {
+			BeforeEach(func() {
+				requester.TokenCalls(nil)
+				requester.TokenReturns(nil, errors.New("uaa is down"))
+			})
+
+			It("keeps handing it out and counts the failure", func() {
+				failuresBefore := testutil.ToFloat64(metrics.UAATokenFetchFailures)
+
+				token, err := tokenSource.Fetch(context.Background())
+				Expect(err).NotTo(HaveOccurred())
+				Expect(token).To(Equal("token-1"))
+
+				Eventually(func() float64 { return testutil.ToFloat64(metrics.UAATokenFetchFailures) }).
+					Should(Equal(failuresBefore + 1))
+				Expect(tokenSource.Fetch(context.Background())).To(Equal("token-1"))
+			})
+
+			It("backs off before refreshing it again", func() {
+				failuresBefore := testutil.ToFloat64(metrics.UAATokenFetchFailures)
+				_, err := tokenSource.Fetch(context.Background())
+				Expect(err).NotTo(HaveOccurred())
+				Eventually(func() float64 { return testutil.ToFloat64(metrics.UAATokenFetchFailures) }).
+					Should(Equal(failuresBefore + 1))
+
+				for i := 0; i < 100; i++ {
+					token, err := tokenSource.Fetch(context.Background())
+					Expect(err).NotTo(HaveOccurred())
+					Expect(token).To(Equal("token-1"))
+				}
+				Consistently(requester.TokenCallCount, 100*time.Millisecond).Should(Equal(2))
+			})
+		})
+	})
+
+	When("the token is about to expire", func() {
+		BeforeEach(func() {
+			expiresIn = 5 * time.Second
+			_, err := tokenSource.Fetch(context.Background())
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("waits for a new one", func() {
+			token, err := tokenSource.Fetch(context.Background())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(token).To(Equal("token-2"))
+		})
+	})
+
+	It("shares a single request between concurrent callers", func() {
+		release := make(chan struct{})
+		requester.TokenCalls(func(context.Context) (*oauth2.Token, error) {
+			<-release
+			return &oauth2.Token{AccessToken: "shared-token", Expiry: time.Now().Add(time.Hour)}, nil
+		})
+
+		var wg sync.WaitGroup
+		tokens := make([]string, 3)
+		for i := range tokens {
+			wg.Add(1)
+			go func(i int) {
+				defer GinkgoRecover()
+				defer wg.Done()
+				var err error
+				tokens[i], err = tokenSource.Fetch(context.Background())
+				Expect(err).NotTo(HaveOccurred())
+			}(i)
+		}
+
+		Consistently(requester.TokenCallCount, 100*time.Millisecond).Should(BeNumerically("<=", 1))
+		close(release)
+		wg.Wait()
+
+		Expect(requester.TokenCallCount()).To(Equal(1))
+		Expect(tokens).To(Equal([]string{"shared-token", "shared-token", "shared-token"}))
+	})
+
+	It("stops waiting for a new token when the caller's context is done", func() {
+		release := make(chan struct{})
+		defer close(release)
+		requester.TokenCalls(func(context.Context) (*oauth2.Token, error) {
+			<-release
+			return &oauth2.Token{AccessToken: "late-token", Expiry: time.Now().Add(time.Hour)}, nil
+		})
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, err := tokenSource.Fetch(ctx)
+		Expect(err).To(MatchError(context.Canceled))
+	})
+
+	When("there is no token and requesting one fails", func() {
+		BeforeEach(func() {
+			requester.TokenCalls(nil)
+			requester.TokenReturns(nil, errors.New("uaa is down"))
+		})
+
+		It("returns the error and tries again on the next fetch", func() {
+			_, err := tokenSource.Fetch(context.Background())
+			Expect(err).To(MatchError("uaa is down"))
+
+			_, err = tokenSource.Fetch(context.Background())
+			Expect(err).To(MatchError("uaa is down"))
+			Expect(requester.TokenCallCount()).To(Equal(2))
+		})
+	})
+
+	When("UAA returns an empty token", func() {
+		BeforeEach(func() {
+			requester.TokenCalls(nil)
+			requester.TokenReturns(&oauth2.Token{}, nil)
+		})
+
+		It("returns an error", func() {
+			_, err := tokenSource.Fetch(context.Background())
+			Expect(err).To(MatchError("UAA returned an empty access token"))
+		})
+	})
+})
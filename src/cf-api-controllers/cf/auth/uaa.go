@@ -2,6 +2,7 @@ package auth
 
 import (
 	"context"
+	"net/http"
 
 	uaaClient "github.com/cloudfoundry-community/go-uaa"
 )
@@ -9,29 +10,37 @@ import (
 // UAAClient wraps over the official UAA client implementation.
 type UAAClient struct {
 	*uaaClient.API
+	tokens *TokenSource
 }
 
-// NewUAAClient creates a new UAA client.
-func NewUAAClient(endpoint, clientName, clientSecret string) *UAAClient {
+// NewUAAClient creates a new UAA client, connecting to UAA with httpClient.
+func NewUAAClient(endpoint, clientName, clientSecret string, httpClient *http.Client) (*UAAClient, error) {
+	// go-uaa overwrites the transport's InsecureSkipVerify with this option
+	skipSSLValidation := false
+	if transport, ok := httpClient.Transport.(*http.Transport); ok && transport.TLSClientConfig != nil {
+		skipSSLValidation = transport.TLSClientConfig.InsecureSkipVerify
+	}
+
 	client, err := uaaClient.New(
 		endpoint,
 		uaaClient.WithClientCredentials(clientName, clientSecret, 1),
-		uaaClient.WithSkipSSLValidation(true),
+		uaaClient.WithClient(httpClient),
+		uaaClient.WithSkipSSLValidation(skipSSLValidation),
 	)
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
 
-	return &UAAClient{client}
+	return &UAAClient{API: client, tokens: NewTokenSource(client)}, nil
 }
 
 // Fetch implements the TokenFetcher interface, fetching tokens from UAA. This stands as an anti-corruption layer over
-// the actual FetchToken call.
-func (u *UAAClient) Fetch() (string, error) {
-	token, err := u.Token(context.Background())
-	if err != nil {
-		return "", err
-	}
+// the actual FetchToken call. Tokens are cached and refreshed ahead of their expiry.
+func (u *UAAClient) Fetch(ctx context.Context) (string, error) {
+	return u.tokens.Fetch(ctx)
+}
 
-	return token.AccessToken, nil
+// Invalidate implements the TokenFetcher interface, dropping the cached token
+func (u *UAAClient) Invalidate() {
+	u.tokens.Invalidate()
 }
@@ -0,0 +1,60 @@
+package auth_test
+
+import (
+	"context"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+
+	"code.cloudfoundry.org/capi-k8s-release/src/cf-api-controllers/cf"
+	. "code.cloudfoundry.org/capi-k8s-release/src/cf-api-controllers/cf/auth"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("UAAClient", func() {
+	var (
+		uaaServer *httptest.Server
+		serverCA  []byte
+	)
+
+	BeforeEach(func() {
+		uaaServer = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			Expect(r.URL.Path).To(Equal("/oauth/token"))
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"access_token": "some-token", "token_type": "bearer", "expires_in": 3600}`))
+		}))
+		serverCA = pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: uaaServer.Certificate().Raw})
+	})
+
+	AfterEach(func() {
+		uaaServer.Close()
+	})
+
+	fetch := func(config cf.TLSConfig) (string, error) {
+		httpClient, err := cf.NewHTTPClient(config)
+		Expect(err).NotTo(HaveOccurred())
+
+		client, err := NewUAAClient(uaaServer.URL, "some-client", "some-secret", httpClient)
+		Expect(err).NotTo(HaveOccurred())
+		return client.Fetch(context.Background())
+	}
+
+	It("fetches a token from a UAA whose certificate it trusts", func() {
+		token, err := fetch(cf.TLSConfig{CACertificate: serverCA})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(token).To(Equal("some-token"))
+	})
+
+	It("refuses a UAA whose certificate it can't verify", func() {
+		_, err := fetch(cf.TLSConfig{})
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("skips verification when opted in", func() {
+		token, err := fetch(cf.TLSConfig{InsecureSkipVerify: true})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(token).To(Equal("some-token"))
+	})
+})
@@ -2,6 +2,7 @@
 package cffakes
 
 import (
+	"context"
 	"sync"
 
 	"code.cloudfoundry.org/capi-k8s-release/src/cf-api-controllers/cf"
@@ -9,9 +10,52 @@ import (
 )
 
 type FakeClientInterface struct {
-	ListRoutesStub        func() (model.RouteList, error)
+	GetDomainStub        func(context.Context, string) (model.Domain, error)
+	getDomainMutex       sync.RWMutex
+	getDomainArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+	}
+	getDomainReturns struct {
+		result1 model.Domain
+		result2 error
+	}
+	getDomainReturnsOnCall map[int]struct {
+		result1 model.Domain
+		result2 error
+	}
+	GetRouteStub        func(context.Context, string) (model.RouteResponse, error)
+	getRouteMutex       sync.RWMutex
+	getRouteArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+	}
+	getRouteReturns struct {
+		result1 model.RouteResponse
+		result2 error
+	}
+	getRouteReturnsOnCall map[int]struct {
+		result1 model.RouteResponse
+		result2 error
+	}
+	GetSpaceStub        func(context.Context, string) (model.SpaceResponse, error)
+	getSpaceMutex       sync.RWMutex
+	getSpaceArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+	}
+	getSpaceReturns struct {
+		result1 model.SpaceResponse
+		result2 error
+	}
+	getSpaceReturnsOnCall map[int]struct {
+		result1 model.SpaceResponse
+		result2 error
+	}
+	ListRoutesStub        func(context.Context) (model.RouteList, error)
 	listRoutesMutex       sync.RWMutex
 	listRoutesArgsForCall []struct {
+		arg1 context.Context
 	}
 	listRoutesReturns struct {
 		result1 model.RouteList
@@ -25,15 +69,208 @@ type FakeClientInterface struct {
 	invocationsMutex sync.RWMutex
 }
 
-func (fake *FakeClientInterface) ListRoutes() (model.RouteList, error) {
+func (fake *FakeClientInterface) GetDomain(arg1 context.Context, arg2 string) (model.Domain, error) {
+	fake.getDomainMutex.Lock()
+	ret, specificReturn := fake.getDomainReturnsOnCall[len(fake.getDomainArgsForCall)]
+	fake.getDomainArgsForCall = append(fake.getDomainArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+	}{arg1, arg2})
+	fake.recordInvocation("GetDomain", []interface{}{arg1, arg2})
+	fake.getDomainMutex.Unlock()
+	if fake.GetDomainStub != nil {
+		return fake.GetDomainStub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.getDomainReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeClientInterface) GetDomainCallCount() int {
+	fake.getDomainMutex.RLock()
+	defer fake.getDomainMutex.RUnlock()
+	return len(fake.getDomainArgsForCall)
+}
+
+func (fake *FakeClientInterface) GetDomainCalls(stub func(context.Context, string) (model.Domain, error)) {
+	fake.getDomainMutex.Lock()
+	defer fake.getDomainMutex.Unlock()
+	fake.GetDomainStub = stub
+}
+
+func (fake *FakeClientInterface) GetDomainArgsForCall(i int) (context.Context, string) {
+	fake.getDomainMutex.RLock()
+	defer fake.getDomainMutex.RUnlock()
+	argsForCall := fake.getDomainArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeClientInterface) GetDomainReturns(result1 model.Domain, result2 error) {
+	fake.getDomainMutex.Lock()
+	defer fake.getDomainMutex.Unlock()
+	fake.GetDomainStub = nil
+	fake.getDomainReturns = struct {
+		result1 model.Domain
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeClientInterface) GetDomainReturnsOnCall(i int, result1 model.Domain, result2 error) {
+	fake.getDomainMutex.Lock()
+	defer fake.getDomainMutex.Unlock()
+	fake.GetDomainStub = nil
+	if fake.getDomainReturnsOnCall == nil {
+		fake.getDomainReturnsOnCall = make(map[int]struct {
+			result1 model.Domain
+			result2 error
+		})
+	}
+	fake.getDomainReturnsOnCall[i] = struct {
+		result1 model.Domain
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeClientInterface) GetRoute(arg1 context.Context, arg2 string) (model.RouteResponse, error) {
+	fake.getRouteMutex.Lock()
+	ret, specificReturn := fake.getRouteReturnsOnCall[len(fake.getRouteArgsForCall)]
+	fake.getRouteArgsForCall = append(fake.getRouteArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+	}{arg1, arg2})
+	fake.recordInvocation("GetRoute", []interface{}{arg1, arg2})
+	fake.getRouteMutex.Unlock()
+	if fake.GetRouteStub != nil {
+		return fake.GetRouteStub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.getRouteReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeClientInterface) GetRouteCallCount() int {
+	fake.getRouteMutex.RLock()
+	defer fake.getRouteMutex.RUnlock()
+	return len(fake.getRouteArgsForCall)
+}
+
+func (fake *FakeClientInterface) GetRouteCalls(stub func(context.Context, string) (model.RouteResponse, error)) {
+	fake.getRouteMutex.Lock()
+	defer fake.getRouteMutex.Unlock()
+	fake.GetRouteStub = stub
+}
+
+func (fake *FakeClientInterface) GetRouteArgsForCall(i int) (context.Context, string) {
+	fake.getRouteMutex.RLock()
+	defer fake.getRouteMutex.RUnlock()
+	argsForCall := fake.getRouteArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeClientInterface) GetRouteReturns(result1 model.RouteResponse, result2 error) {
+	fake.getRouteMutex.Lock()
+	defer fake.getRouteMutex.Unlock()
+	fake.GetRouteStub = nil
+	fake.getRouteReturns = struct {
+		result1 model.RouteResponse
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeClientInterface) GetRouteReturnsOnCall(i int, result1 model.RouteResponse, result2 error) {
+	fake.getRouteMutex.Lock()
+	defer fake.getRouteMutex.Unlock()
+	fake.GetRouteStub = nil
+	if fake.getRouteReturnsOnCall == nil {
+		fake.getRouteReturnsOnCall = make(map[int]struct {
+			result1 model.RouteResponse
+			result2 error
+		})
+	}
+	fake.getRouteReturnsOnCall[i] = struct {
+		result1 model.RouteResponse
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeClientInterface) GetSpace(arg1 context.Context, arg2 string) (model.SpaceResponse, error) {
+	fake.getSpaceMutex.Lock()
+	ret, specificReturn := fake.getSpaceReturnsOnCall[len(fake.getSpaceArgsForCall)]
+	fake.getSpaceArgsForCall = append(fake.getSpaceArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+	}{arg1, arg2})
+	fake.recordInvocation("GetSpace", []interface{}{arg1, arg2})
+	fake.getSpaceMutex.Unlock()
+	if fake.GetSpaceStub != nil {
+		return fake.GetSpaceStub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.getSpaceReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeClientInterface) GetSpaceCallCount() int {
+	fake.getSpaceMutex.RLock()
+	defer fake.getSpaceMutex.RUnlock()
+	return len(fake.getSpaceArgsForCall)
+}
+
+func (fake *FakeClientInterface) GetSpaceCalls(stub func(context.Context, string) (model.SpaceResponse, error)) {
+	fake.getSpaceMutex.Lock()
+	defer fake.getSpaceMutex.Unlock()
+	fake.GetSpaceStub = stub
+}
+
+func (fake *FakeClientInterface) GetSpaceArgsForCall(i int) (context.Context, string) {
+	fake.getSpaceMutex.RLock()
+	defer fake.getSpaceMutex.RUnlock()
+	argsForCall := fake.getSpaceArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeClientInterface) GetSpaceReturns(result1 model.SpaceResponse, result2 error) {
+	fake.getSpaceMutex.Lock()
+	defer fake.getSpaceMutex.Unlock()
+	fake.GetSpaceStub = nil
+	fake.getSpaceReturns = struct {
+		result1 model.SpaceResponse
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeClientInterface) GetSpaceReturnsOnCall(i int, result1 model.SpaceResponse, result2 error) {
+	fake.getSpaceMutex.Lock()
+	defer fake.getSpaceMutex.Unlock()
+	fake.GetSpaceStub = nil
+	if fake.getSpaceReturnsOnCall == nil {
+		fake.getSpaceReturnsOnCall = make(map[int]struct {
+			result1 model.SpaceResponse
+			result2 error
+		})
+	}
+	fake.getSpaceReturnsOnCall[i] = struct {
+		result1 model.SpaceResponse
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeClientInterface) ListRoutes(arg1 context.Context) (model.RouteList, error) {
 	fake.listRoutesMutex.Lock()
 	ret, specificReturn := fake.listRoutesReturnsOnCall[len(fake.listRoutesArgsForCall)]
 	fake.listRoutesArgsForCall = append(fake.listRoutesArgsForCall, struct {
-	}{})
-	fake.recordInvocation("ListRoutes", []interface{}{})
+		arg1 context.Context
+	}{arg1})
+	fake.recordInvocation("ListRoutes", []interface{}{arg1})
 	fake.listRoutesMutex.Unlock()
 	if fake.ListRoutesStub != nil {
-		return fake.ListRoutesStub()
+		return fake.ListRoutesStub(arg1)
 	}
 	if specificReturn {
 		return ret.result1, ret.result2
@@ -48,12 +285,19 @@ func (fake *FakeClientInterface) ListRoutesCallCount() int {
 	return len(fake.listRoutesArgsForCall)
 }
 
-func (fake *FakeClientInterface) ListRoutesCalls(stub func() (model.RouteList, error)) {
+func (fake *FakeClientInterface) ListRoutesCalls(stub func(context.Context) (model.RouteList, error)) {
 	fake.listRoutesMutex.Lock()
 	defer fake.listRoutesMutex.Unlock()
 	fake.ListRoutesStub = stub
 }
 
+func (fake *FakeClientInterface) ListRoutesArgsForCall(i int) context.Context {
+	fake.listRoutesMutex.RLock()
+	defer fake.listRoutesMutex.RUnlock()
+	argsForCall := fake.listRoutesArgsForCall[i]
+	return argsForCall.arg1
+}
+
 func (fake *FakeClientInterface) ListRoutesReturns(result1 model.RouteList, result2 error) {
 	fake.listRoutesMutex.Lock()
 	defer fake.listRoutesMutex.Unlock()
@@ -83,6 +327,12 @@ func (fake *FakeClientInterface) ListRoutesReturnsOnCall(i int, result1 model.Ro
 func (fake *FakeClientInterface) Invocations() map[string][][]interface{} {
 	fake.invocationsMutex.RLock()
 	defer fake.invocationsMutex.RUnlock()
+	fake.getDomainMutex.RLock()
+	defer fake.getDomainMutex.RUnlock()
+	fake.getRouteMutex.RLock()
+	defer fake.getRouteMutex.RUnlock()
+	fake.getSpaceMutex.RLock()
+	defer fake.getSpaceMutex.RUnlock()
 	fake.listRoutesMutex.RLock()
 	defer fake.listRoutesMutex.RUnlock()
 	copiedInvocations := map[string][][]interface{}{}
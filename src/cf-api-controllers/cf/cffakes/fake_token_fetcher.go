@@ -2,15 +2,17 @@
 package cffakes
 
 import (
+	"context"
 	"sync"
 
 	"code.cloudfoundry.org/capi-k8s-release/src/cf-api-controllers/cf"
 )
 
 type FakeTokenFetcher struct {
-	FetchStub        func() (string, error)
+	FetchStub        func(context.Context) (string, error)
 	fetchMutex       sync.RWMutex
 	fetchArgsForCall []struct {
+		arg1 context.Context
 	}
 	fetchReturns struct {
 		result1 string
@@ -20,19 +22,24 @@ type FakeTokenFetcher struct {
 		result1 string
 		result2 error
 	}
+	InvalidateStub        func()
+	invalidateMutex       sync.RWMutex
+	invalidateArgsForCall []struct {
+	}
 	invocations      map[string][][]interface{}
 	invocationsMutex sync.RWMutex
 }
 
-func (fake *FakeTokenFetcher) Fetch() (string, error) {
+func (fake *FakeTokenFetcher) Fetch(arg1 context.Context) (string, error) {
 	fake.fetchMutex.Lock()
 	ret, specificReturn := fake.fetchReturnsOnCall[len(fake.fetchArgsForCall)]
 	fake.fetchArgsForCall = append(fake.fetchArgsForCall, struct {
-	}{})
-	fake.recordInvocation("Fetch", []interface{}{})
+		arg1 context.Context
+	}{arg1})
+	fake.recordInvocation("Fetch", []interface{}{arg1})
 	fake.fetchMutex.Unlock()
 	if fake.FetchStub != nil {
-		return fake.FetchStub()
+		return fake.FetchStub(arg1)
 	}
 	if specificReturn {
 		return ret.result1, ret.result2
@@ -47,12 +54,19 @@ func (fake *FakeTokenFetcher) FetchCallCount() int {
 	return len(fake.fetchArgsForCall)
 }
 
-func (fake *FakeTokenFetcher) FetchCalls(stub func() (string, error)) {
+func (fake *FakeTokenFetcher) FetchCalls(stub func(context.Context) (string, error)) {
 	fake.fetchMutex.Lock()
 	defer fake.fetchMutex.Unlock()
 	fake.FetchStub = stub
 }
 
+func (fake *FakeTokenFetcher) FetchArgsForCall(i int) context.Context {
+	fake.fetchMutex.RLock()
+	defer fake.fetchMutex.RUnlock()
+	argsForCall := fake.fetchArgsForCall[i]
+	return argsForCall.arg1
+}
+
 func (fake *FakeTokenFetcher) FetchReturns(result1 string, result2 error) {
 	fake.fetchMutex.Lock()
 	defer fake.fetchMutex.Unlock()
@@ -79,11 +93,36 @@ func (fake *FakeTokenFetcher) FetchReturnsOnCall(i int, result1 string, result2
 	}{result1, result2}
 }
 
+func (fake *FakeTokenFetcher) Invalidate() {
+	fake.invalidateMutex.Lock()
+	fake.invalidateArgsForCall = append(fake.invalidateArgsForCall, struct {
+	}{})
+	fake.recordInvocation("Invalidate", []interface{}{})
+	fake.invalidateMutex.Unlock()
+	if fake.InvalidateStub != nil {
+		fake.InvalidateStub()
+	}
+}
+
+func (fake *FakeTokenFetcher) InvalidateCallCount() int {
+	fake.invalidateMutex.RLock()
+	defer fake.invalidateMutex.RUnlock()
+	return len(fake.invalidateArgsForCall)
+}
+
+func (fake *FakeTokenFetcher) InvalidateCalls(stub func()) {
+	fake.invalidateMutex.Lock()
+	defer fake.invalidateMutex.Unlock()
+	fake.InvalidateStub = stub
+}
+
 func (fake *FakeTokenFetcher) Invocations() map[string][][]interface{} {
 	fake.invocationsMutex.RLock()
 	defer fake.invocationsMutex.RUnlock()
 	fake.fetchMutex.RLock()
 	defer fake.fetchMutex.RUnlock()
+	fake.invalidateMutex.RLock()
+	defer fake.invalidateMutex.RUnlock()
 	copiedInvocations := map[string][][]interface{}{}
 	for key, value := range fake.invocations {
 		copiedInvocations[key] = value
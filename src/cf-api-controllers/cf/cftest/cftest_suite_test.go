@@ -0,0 +1,15 @@
+package cftest_test
+
+import (
+	"testing"
+
+	"github.com/matt-royal/biloba"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestCftest(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecsWithDefaultAndCustomReporters(t, "Cftest Suite", biloba.GoLandReporter())
+}
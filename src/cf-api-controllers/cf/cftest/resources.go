@@ -0,0 +1,296 @@
+package cftest
+
+import (
+	"code.cloudfoundry.org/capi-k8s-release/src/cf-api-controllers/cf/model"
+)
+
+// The types below spell out the JSON CC v3 sends and accepts. They are kept
+// apart from the client's model on purpose: were the server to encode the
+// model, a field the model tags wrongly would round-trip unnoticed.
+
+type ccLink struct {
+	Href string `json:"href"`
+}
+
+type ccLinks map[string]ccLink
+
+type ccMetadata struct {
+	Labels      map[string]string `json:"labels"`
+	Annotations map[string]string `json:"annotations"`
+}
+
+type ccToOne struct {
+	Data *ccGUID `json:"data"`
+}
+
+type ccGUID struct {
+	GUID string `json:"guid"`
+}
+
+type ccPagination struct {
+	TotalResults int     `json:"total_results"`
+	TotalPages   int     `json:"total_pages"`
+	First        ccLink  `json:"first"`
+	Last         ccLink  `json:"last"`
+	Next         *ccLink `json:"next"`
+	Previous     *ccLink `json:"previous"`
+}
+
+type ccRouteList struct {
+	Pagination ccPagination `json:"pagination"`
+	Resources  []ccRoute    `json:"resources"`
+	// Included only has the keys of the resources asked for
+	Included map[string]interface{} `json:"included,omitempty"`
+}
+
+type ccRoute struct {
+	GUID          string             `json:"guid"`
+	Protocol      string             `json:"protocol"`
+	Host          string             `json:"host"`
+	Path          string             `json:"path"`
+	Port          *int               `json:"port"`
+	URL           string             `json:"url"`
+	Destinations  []ccDestination    `json:"destinations"`
+	Metadata      ccMetadata         `json:"metadata"`
+	Relationships map[string]ccToOne `json:"relationships"`
+	Links         ccLinks            `json:"links"`
+	Included      interface{}        `json:"included,omitempty"`
+}
+
+type ccDestination struct {
+	GUID string `json:"guid"`
+	App  struct {
+		GUID    string `json:"guid"`
+		Process struct {
+			Type string `json:"type"`
+		} `json:"process"`
+	} `json:"app"`
+	Weight   *int    `json:"weight"`
+	Port     int     `json:"port"`
+	Protocol *string `json:"protocol"`
+}
+
+type ccSpace struct {
+	GUID          string             `json:"guid"`
+	Name          string             `json:"name"`
+	Relationships map[string]ccToOne `json:"relationships"`
+	Metadata      ccMetadata         `json:"metadata"`
+	Links         ccLinks            `json:"links"`
+	Included      interface{}        `json:"included,omitempty"`
+}
+
+type ccOrganization struct {
+	GUID      string     `json:"guid"`
+	Name      string     `json:"name"`
+	Suspended bool       `json:"suspended"`
+	Metadata  ccMetadata `json:"metadata"`
+	Links     ccLinks    `json:"links"`
+}
+
+type ccDomain struct {
+	GUID        string     `json:"guid"`
+	Name        string     `json:"name"`
+	Internal    bool       `json:"internal"`
+	RouterGroup *ccGUID    `json:"router_group"`
+	Metadata    ccMetadata `json:"metadata"`
+	Links       ccLinks    `json:"links"`
+}
+
+type ccApp struct {
+	GUID          string             `json:"guid"`
+	Name          string             `json:"name"`
+	Relationships map[string]ccToOne `json:"relationships"`
+	Metadata      ccMetadata         `json:"metadata"`
+	Links         ccLinks            `json:"links"`
+	Included      interface{}        `json:"included,omitempty"`
+}
+
+type ccDroplet struct {
+	GUID     string     `json:"guid"`
+	State    string     `json:"state"`
+	Image    string     `json:"image"`
+	Metadata ccMetadata `json:"metadata"`
+	Links    ccLinks    `json:"links"`
+}
+
+// ccDropletUpdate is the body of a PATCH /v3/droplets/:guid
+type ccDropletUpdate struct {
+	Image    *string `json:"image"`
+	Metadata *struct {
+		Labels      map[string]string `json:"labels"`
+		Annotations map[string]string `json:"annotations"`
+	} `json:"metadata"`
+}
+
+// ccBuild is both the body of a PATCH /v3/builds/:guid by the kpack lifecycle
+// and, with its GUID and links, what CC answers with
+type ccBuild struct {
+	GUID      string           `json:"guid,omitempty"`
+	State     string           `json:"state"`
+	Error     *string          `json:"error"`
+	Lifecycle ccBuildLifecycle `json:"lifecycle"`
+	Links     ccLinks          `json:"links,omitempty"`
+}
+
+type ccBuildLifecycle struct {
+	Type string `json:"type"`
+	Data struct {
+		Image        string            `json:"image"`
+		ProcessTypes map[string]string `json:"processTypes"`
+		Buildpacks   []struct {
+			ID       string `json:"id"`
+			Version  string `json:"version"`
+			Homepage string `json:"homepage"`
+		} `json:"buildpacks"`
+		Stack    string `json:"stack"`
+		RunImage string `json:"runImage"`
+	} `json:"data"`
+}
+
+type ccErrorList struct {
+	Errors []ccError `json:"errors"`
+}
+
+type ccError struct {
+	Code   int    `json:"code"`
+	Title  string `json:"title"`
+	Detail string `json:"detail"`
+}
+
+func selfLinks(path string) ccLinks {
+	return ccLinks{"self": {Href: ExternalURL + path}}
+}
+
+func renderMetadata(metadata model.Metadata) ccMetadata {
+	// CC renders no labels or annotations as empty objects
+	return ccMetadata{
+		Labels:      mergeMap(map[string]string{}, metadata.Labels),
+		Annotations: mergeMap(map[string]string{}, metadata.Annotations),
+	}
+}
+
+func renderRelationships(relationships map[string]model.Relationship) map[string]ccToOne {
+	rendered := make(map[string]ccToOne, len(relationships))
+	for name, relationship := range relationships {
+		rendered[name] = ccToOne{Data: &ccGUID{GUID: relationship.Data.GUID}}
+	}
+	return rendered
+}
+
+func renderRoute(route model.Route) ccRoute {
+	protocol := route.Protocol
+	if protocol == "" {
+		protocol = model.RouteProtocolHTTP
+	}
+	rendered := ccRoute{
+		GUID:          route.GUID,
+		Protocol:      protocol,
+		Host:          route.Host,
+		Path:          route.Path,
+		Port:          route.Port,
+		URL:           route.URL,
+		Destinations:  []ccDestination{},
+		Metadata:      renderMetadata(route.Metadata),
+		Relationships: renderRelationships(route.Relationships),
+		Links:         selfLinks("/v3/routes/" + route.GUID),
+	}
+	for _, destination := range route.Destinations {
+		renderedDestination := ccDestination{GUID: destination.GUID, Weight: destination.Weight, Port: destination.Port}
+		renderedDestination.App.GUID = destination.App.GUID
+		renderedDestination.App.Process.Type = destination.App.Process.Type
+		if destination.Protocol != "" {
+			protocol := destination.Protocol
+			renderedDestination.Protocol = &protocol
+		}
+		rendered.Destinations = append(rendered.Destinations, renderedDestination)
+	}
+	return rendered
+}
+
+func renderSpace(space model.Space) ccSpace {
+	return ccSpace{
+		GUID:          space.GUID,
+		Name:          space.Name,
+		Relationships: renderRelationships(space.Relationships),
+		Metadata:      renderMetadata(space.Metadata),
+		Links:         selfLinks("/v3/spaces/" + space.GUID),
+	}
+}
+
+func renderOrganization(organization model.Organization) ccOrganization {
+	return ccOrganization{
+		GUID:     organization.GUID,
+		Name:     organization.Name,
+		Metadata: renderMetadata(organization.Metadata),
+		Links:    selfLinks("/v3/organizations/" + organization.GUID),
+	}
+}
+
+func renderDomain(domain model.Domain) ccDomain {
+	return ccDomain{
+		GUID:     domain.GUID,
+		Name:     domain.Name,
+		Internal: domain.Internal,
+		Metadata: renderMetadata(model.Metadata{}),
+		Links:    selfLinks("/v3/domains/" + domain.GUID),
+	}
+}
+
+func renderApp(app model.App) ccApp {
+	return ccApp{
+		GUID:          app.GUID,
+		Name:          app.Name,
+		Relationships: renderRelationships(app.Relationships),
+		Metadata:      renderMetadata(app.Metadata),
+		Links:         selfLinks("/v3/apps/" + app.GUID),
+	}
+}
+
+func renderDroplet(guid string, droplet model.Droplet) ccDroplet {
+	var metadata model.Metadata
+	if droplet.Metadata != nil {
+		metadata = *droplet.Metadata
+	}
+	return ccDroplet{
+		GUID:     guid,
+		State:    "STAGED",
+		Image:    droplet.Image,
+		Metadata: renderMetadata(metadata),
+		Links:    selfLinks("/v3/droplets/" + guid),
+	}
+}
+
+func renderErrors(errors []model.Error) ccErrorList {
+	rendered := ccErrorList{Errors: []ccError{}}
+	for _, err := range errors {
+		rendered.Errors = append(rendered.Errors, ccError{Code: err.Code, Title: err.Title, Detail: err.Detail})
+	}
+	return rendered
+}
+
+// buildFromUpdate reads back a build update the way CC understood it
+func buildFromUpdate(update ccBuild) model.Build {
+	build := model.Build{
+		State: update.State,
+		Lifecycle: model.Lifecycle{
+			Type: update.Lifecycle.Type,
+			Data: model.LifecycleData{
+				Image:        update.Lifecycle.Data.Image,
+				ProcessTypes: update.Lifecycle.Data.ProcessTypes,
+				Stack:        update.Lifecycle.Data.Stack,
+				RunImage:     update.Lifecycle.Data.RunImage,
+			},
+		},
+	}
+	if update.Error != nil {
+		build.Error = *update.Error
+	}
+	for _, buildpack := range update.Lifecycle.Data.Buildpacks {
+		build.Lifecycle.Data.Buildpacks = append(build.Lifecycle.Data.Buildpacks, model.Buildpack{
+			ID:       buildpack.ID,
+			Version:  buildpack.Version,
+			Homepage: buildpack.Homepage,
+		})
+	}
+	return build
+}
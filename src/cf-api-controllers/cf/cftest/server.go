@@ -0,0 +1,637 @@
+// Package cftest provides a fake CF API v3 and UAA for tests. It serves the
+// endpoints the controllers use from state a test programs, and can be made to
+// fail or stall any of them.
+package cftest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/capi-k8s-release/src/cf-api-controllers/cf"
+	"code.cloudfoundry.org/capi-k8s-release/src/cf-api-controllers/cf/model"
+)
+
+// ExternalURL is the host the server renders its links with, the way CC
+// renders them with its external hostname rather than the one it was reached at
+const ExternalURL = "https://api.example.com"
+
+const (
+	defaultPerPage = 50
+	tokenLifetime  = time.Hour
+)
+
+// Fault makes the server misbehave on the requests it is injected for
+type Fault struct {
+	// Delay holds the response back, e.g. past a client's timeout. The request
+	// is served normally afterwards unless StatusCode is set.
+	Delay time.Duration
+	// StatusCode answers with this status and an error envelope of Errors
+	StatusCode int
+	Errors     []model.Error
+	// Times limits the fault to the next n matching requests. Zero keeps it
+	// until the faults are cleared.
+	Times int
+}
+
+// Request is a request the server received
+type Request struct {
+	Method string
+	// Endpoint is the path the request was routed by, e.g. /v3/builds/:guid
+	Endpoint  string
+	Path      string
+	RequestID string
+	Body      []byte
+}
+
+// Server is a fake CF API and UAA backed by an httptest.Server. The UAA token
+// endpoint hands out a token to any client, which the CF API endpoints then
+// require as a bearer token.
+type Server struct {
+	*httptest.Server
+
+	mu            sync.Mutex
+	routes        map[string]model.Route
+	spaces        map[string]model.Space
+	domains       map[string]model.Domain
+	apps          map[string]model.App
+	organizations map[string]model.Organization
+	builds        map[string]*model.Build
+	droplets      map[string]model.Droplet
+	tokens        map[string]bool
+	faults        map[string][]*Fault
+	requests      []Request
+	pageSizeLimit int
+}
+
+type handler func(w http.ResponseWriter, r *http.Request, guid string)
+
+// NewServer starts a Server. Callers should Close it when finished.
+func NewServer() *Server {
+	s := &Server{
+		routes:        make(map[string]model.Route),
+		spaces:        make(map[string]model.Space),
+		domains:       make(map[string]model.Domain),
+		apps:          make(map[string]model.App),
+		organizations: make(map[string]model.Organization),
+		builds:        make(map[string]*model.Build),
+		droplets:      make(map[string]model.Droplet),
+		tokens:        make(map[string]bool),
+		faults:        make(map[string][]*Fault),
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+// AddRoute adds or replaces a route. Its space and domain are taken from its
+// relationships and have to be added separately to be included.
+func (s *Server) AddRoute(route model.Route) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.routes[route.GUID] = route
+}
+
+// DeleteRoute removes a route, as if it was deleted in CC
+func (s *Server) DeleteRoute(guid string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.routes, guid)
+}
+
+func (s *Server) AddSpace(space model.Space) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.spaces[space.GUID] = space
+}
+
+func (s *Server) AddDomain(domain model.Domain) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.domains[domain.GUID] = domain
+}
+
+func (s *Server) AddOrganization(organization model.Organization) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.organizations[organization.GUID] = organization
+}
+
+// AddApp adds or replaces an app. Its space is taken from its relationships
+// and the space's organization from the space's.
+func (s *Server) AddApp(app model.App) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.apps[app.GUID] = app
+}
+
+// AddBuild adds a build that is staging, which can then be updated. Updating
+// any other build results in a 404.
+func (s *Server) AddBuild(guid string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.builds[guid] = nil
+}
+
+// Build returns the build as last updated, and whether it has been updated
+func (s *Server) Build(guid string) (model.Build, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	build := s.builds[guid]
+	if build == nil {
+		return model.Build{}, false
+	}
+	return *build, true
+}
+
+func (s *Server) AddDroplet(guid string, droplet model.Droplet) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.droplets[guid] = copyDroplet(droplet)
+}
+
+// Droplet returns the droplet with any updates applied
+func (s *Server) Droplet(guid string) (model.Droplet, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	droplet, ok := s.droplets[guid]
+	return copyDroplet(droplet), ok
+}
+
+// LimitPageSize makes the server answer list requests with at most limit
+// resources a page, whatever per_page asks for, so that tests can make
+// clients page through lists without adding thousands of resources. Zero
+// lifts the limit.
+func (s *Server) LimitPageSize(limit int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pageSizeLimit = limit
+}
+
+// InjectFault makes the requests for method and endpoint fail as described by
+// fault. The endpoint is a path with its GUIDs replaced with :guid, e.g.
+// /v3/routes/:guid, or /oauth/token for UAA. Faults injected for the same
+// endpoint apply in turn.
+func (s *Server) InjectFault(method, endpoint string, fault Fault) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := method + " " + endpoint
+	s.faults[key] = append(s.faults[key], &fault)
+}
+
+func (s *Server) ClearFaults() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults = make(map[string][]*Fault)
+}
+
+// Requests returns the requests received for method and endpoint, in the order
+// they were received
+func (s *Server) Requests(method, endpoint string) []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var requests []Request
+	for _, request := range s.requests {
+		if request.Method == method && request.Endpoint == endpoint {
+			requests = append(requests, request)
+		}
+	}
+	return requests
+}
+
+// Reset forgets all state, faults and requests. The tokens issued so far stay
+// valid, since clients cache them.
+func (s *Server) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.routes = make(map[string]model.Route)
+	s.spaces = make(map[string]model.Space)
+	s.domains = make(map[string]model.Domain)
+	s.apps = make(map[string]model.App)
+	s.organizations = make(map[string]model.Organization)
+	s.builds = make(map[string]*model.Build)
+	s.droplets = make(map[string]model.Droplet)
+	s.faults = make(map[string][]*Fault)
+	s.requests = nil
+	s.pageSizeLimit = 0
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	endpoint, guid, handle := s.route(r.Method, r.URL.Path)
+
+	body, _ := ioutil.ReadAll(r.Body)
+	s.mu.Lock()
+	s.requests = append(s.requests, Request{
+		Method:    r.Method,
+		Endpoint:  endpoint,
+		Path:      r.URL.RequestURI(),
+		RequestID: r.Header.Get(cf.RequestIDHeader),
+		Body:      body,
+	})
+	fault := s.nextFault(r.Method + " " + endpoint)
+	s.mu.Unlock()
+
+	// CC echoes the request ID with a suffix of its own
+	if requestID := r.Header.Get(cf.RequestIDHeader); requestID != "" {
+		w.Header().Set(cf.RequestIDHeader, requestID+"::cftest")
+	}
+
+	if fault != nil {
+		select {
+		case <-time.After(fault.Delay):
+		case <-r.Context().Done():
+			return
+		}
+		if fault.StatusCode != 0 {
+			writeJSON(w, fault.StatusCode, renderErrors(fault.Errors))
+			return
+		}
+	}
+
+	if handle == nil {
+		writeError(w, http.StatusNotFound, 10000, "CF-NotFound", "Unknown request")
+		return
+	}
+	if endpoint != "/oauth/token" && !s.authorized(r) {
+		writeError(w, http.StatusUnauthorized, 1000, "CF-InvalidAuthToken", "Invalid Auth Token")
+		return
+	}
+	handle(w, withBody(r, body), guid)
+}
+
+// route finds the handler for a request, returning the endpoint it is known by
+// and the GUID in its path if any
+func (s *Server) route(method, path string) (string, string, handler) {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	endpoint := "/" + strings.Join(segments, "/")
+	guid := ""
+	if len(segments) == 3 && segments[0] == "v3" {
+		guid = segments[2]
+		endpoint = fmt.Sprintf("/%s/%s/:guid", segments[0], segments[1])
+	}
+
+	handlers := map[string]handler{
+		"POST /oauth/token":        s.issueToken,
+		"GET /v3/routes":           s.listRoutes,
+		"GET /v3/routes/:guid":     s.getRoute,
+		"GET /v3/spaces/:guid":     s.getSpace,
+		"GET /v3/domains/:guid":    s.getDomain,
+		"PATCH /v3/builds/:guid":   s.updateBuild,
+		"GET /v3/droplets/:guid":   s.getDroplet,
+		"PATCH /v3/droplets/:guid": s.updateDroplet,
+		"GET /v3/apps/:guid":       s.getApp,
+	}
+	return endpoint, guid, handlers[method+" "+endpoint]
+}
+
+// nextFault returns the fault to apply to a request, if any. s.mu must be held.
+func (s *Server) nextFault(key string) *Fault {
+	faults := s.faults[key]
+	if len(faults) == 0 {
+		return nil
+	}
+	fault := faults[0]
+	if fault.Times > 0 {
+		fault.Times--
+		if fault.Times == 0 {
+			s.faults[key] = faults[1:]
+		}
+	}
+	return fault
+}
+
+func (s *Server) authorized(r *http.Request) bool {
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.tokens[token]
+}
+
+func (s *Server) issueToken(w http.ResponseWriter, r *http.Request, _ string) {
+	if err := r.ParseForm(); err != nil || r.PostForm.Get("grant_type") != "client_credentials" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "unsupported_grant_type"})
+		return
+	}
+
+	s.mu.Lock()
+	token := fmt.Sprintf("cftest-token-%d", len(s.tokens)+1)
+	s.tokens[token] = true
+	s.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"access_token": token,
+		"token_type":   "bearer",
+		"expires_in":   int(tokenLifetime.Seconds()),
+	})
+}
+
+// listRoutes pages through the routes ordered by GUID, honouring the page,
+// per_page and include parameters
+func (s *Server) listRoutes(w http.ResponseWriter, r *http.Request, _ string) {
+	query := r.URL.Query()
+	page, perPage := 1, defaultPerPage
+	if value := query.Get("page"); value != "" {
+		page, _ = strconv.Atoi(value)
+	}
+	if value := query.Get("per_page"); value != "" {
+		perPage, _ = strconv.Atoi(value)
+	}
+	if page < 1 || perPage < 1 || perPage > 5000 {
+		writeError(w, http.StatusBadRequest, 10005, "CF-BadQueryParameter", "The query parameter is invalid")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.pageSizeLimit > 0 && perPage > s.pageSizeLimit {
+		perPage = s.pageSizeLimit
+	}
+
+	guids := make([]string, 0, len(s.routes))
+	for guid := range s.routes {
+		guids = append(guids, guid)
+	}
+	sort.Strings(guids)
+
+	totalPages := (len(guids) + perPage - 1) / perPage
+	if totalPages == 0 {
+		totalPages = 1
+	}
+	pageLink := func(page int) *ccLink {
+		link := url.Values{}
+		for key, values := range query {
+			link[key] = values
+		}
+		link.Set("page", strconv.Itoa(page))
+		link.Set("per_page", strconv.Itoa(perPage))
+		return &ccLink{Href: ExternalURL + "/v3/routes?" + link.Encode()}
+	}
+
+	var routes []model.Route
+	for i := (page - 1) * perPage; i < len(guids) && i < page*perPage; i++ {
+		routes = append(routes, s.routes[guids[i]])
+	}
+	list := ccRouteList{
+		Pagination: ccPagination{
+			TotalResults: len(guids),
+			TotalPages:   totalPages,
+			First:        *pageLink(1),
+			Last:         *pageLink(totalPages),
+		},
+		Resources: []ccRoute{},
+		Included:  s.includeForRoutes(query.Get("include"), routes),
+	}
+	for _, route := range routes {
+		list.Resources = append(list.Resources, renderRoute(route))
+	}
+	if page < totalPages {
+		list.Pagination.Next = pageLink(page + 1)
+	}
+	if page > 1 {
+		list.Pagination.Previous = pageLink(page - 1)
+	}
+
+	writeJSON(w, http.StatusOK, list)
+}
+
+func (s *Server) getRoute(w http.ResponseWriter, r *http.Request, guid string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	route, ok := s.routes[guid]
+	if !ok {
+		writeError(w, http.StatusNotFound, 10010, "CF-ResourceNotFound", "Route not found")
+		return
+	}
+	rendered := renderRoute(route)
+	if included := s.includeForRoutes(r.URL.Query().Get("include"), []model.Route{route}); included != nil {
+		rendered.Included = included
+	}
+	writeJSON(w, http.StatusOK, rendered)
+}
+
+// includeForRoutes collects the spaces, organizations and domains of routes
+// that were asked for and exist, keyed like CC keys them. It returns nil when
+// nothing was asked for. s.mu must be held.
+func (s *Server) includeForRoutes(include string, routes []model.Route) map[string]interface{} {
+	if include == "" {
+		return nil
+	}
+	included := make(map[string]interface{})
+	spaces, organizations, domains := []ccSpace{}, []ccOrganization{}, []ccDomain{}
+	seen := make(map[string]bool)
+	firstSighting := func(kind, guid string) bool {
+		if seen[kind+guid] {
+			return false
+		}
+		seen[kind+guid] = true
+		return true
+	}
+
+	for _, resource := range strings.Split(include, ",") {
+		switch resource {
+		case "space", "space.organization":
+			included["spaces"] = &spaces
+			if resource == "space.organization" {
+				included["organizations"] = &organizations
+			}
+		case "domain":
+			included["domains"] = &domains
+		}
+		for _, route := range routes {
+			switch resource {
+			case "space", "space.organization":
+				space, ok := s.spaces[route.Relationships["space"].Data.GUID]
+				if !ok {
+					continue
+				}
+				if firstSighting("space", space.GUID) {
+					spaces = append(spaces, renderSpace(space))
+				}
+				if resource != "space.organization" {
+					continue
+				}
+				organization, ok := s.organizations[space.Relationships["organization"].Data.GUID]
+				if ok && firstSighting("organization", organization.GUID) {
+					organizations = append(organizations, renderOrganization(organization))
+				}
+			case "domain":
+				domain, ok := s.domains[route.Relationships["domain"].Data.GUID]
+				if ok && firstSighting("domain", domain.GUID) {
+					domains = append(domains, renderDomain(domain))
+				}
+			}
+		}
+	}
+	return included
+}
+
+func (s *Server) getSpace(w http.ResponseWriter, r *http.Request, guid string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	space, ok := s.spaces[guid]
+	if !ok {
+		writeError(w, http.StatusNotFound, 10010, "CF-ResourceNotFound", "Space not found")
+		return
+	}
+	rendered := renderSpace(space)
+	if r.URL.Query().Get("include") == "organization" {
+		organizations := []ccOrganization{}
+		if organization, ok := s.organizations[space.Relationships["organization"].Data.GUID]; ok {
+			organizations = append(organizations, renderOrganization(organization))
+		}
+		rendered.Included = map[string]interface{}{"organizations": organizations}
+	}
+	writeJSON(w, http.StatusOK, rendered)
+}
+
+func (s *Server) getDomain(w http.ResponseWriter, _ *http.Request, guid string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	domain, ok := s.domains[guid]
+	if !ok {
+		writeError(w, http.StatusNotFound, 10010, "CF-ResourceNotFound", "Domain not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, renderDomain(domain))
+}
+
+func (s *Server) updateBuild(w http.ResponseWriter, r *http.Request, guid string) {
+	var update ccBuild
+	if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+		writeError(w, http.StatusUnprocessableEntity, 10008, "CF-UnprocessableEntity", err.Error())
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.builds[guid]; !ok {
+		writeError(w, http.StatusNotFound, 10010, "CF-ResourceNotFound", "Build not found")
+		return
+	}
+	build := buildFromUpdate(update)
+	s.builds[guid] = &build
+	update.GUID = guid
+	update.Links = selfLinks("/v3/builds/" + guid)
+	writeJSON(w, http.StatusOK, update)
+}
+
+func (s *Server) getDroplet(w http.ResponseWriter, _ *http.Request, guid string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	droplet, ok := s.droplets[guid]
+	if !ok {
+		writeError(w, http.StatusNotFound, 10010, "CF-ResourceNotFound", "Droplet not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, renderDroplet(guid, droplet))
+}
+
+// updateDroplet updates the image and merges the metadata of a droplet, where
+// like in CC only the labels and annotations given change
+func (s *Server) updateDroplet(w http.ResponseWriter, r *http.Request, guid string) {
+	var update ccDropletUpdate
+	if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+		writeError(w, http.StatusUnprocessableEntity, 10008, "CF-UnprocessableEntity", err.Error())
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	droplet, ok := s.droplets[guid]
+	if !ok {
+		writeError(w, http.StatusNotFound, 10010, "CF-ResourceNotFound", "Droplet not found")
+		return
+	}
+	if update.Image != nil {
+		droplet.Image = *update.Image
+	}
+	if update.Metadata != nil {
+		if droplet.Metadata == nil {
+			droplet.Metadata = &model.Metadata{}
+		}
+		droplet.Metadata.Labels = mergeMap(droplet.Metadata.Labels, update.Metadata.Labels)
+		droplet.Metadata.Annotations = mergeMap(droplet.Metadata.Annotations, update.Metadata.Annotations)
+	}
+	s.droplets[guid] = droplet
+	writeJSON(w, http.StatusOK, renderDroplet(guid, droplet))
+}
+
+func (s *Server) getApp(w http.ResponseWriter, r *http.Request, guid string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	app, ok := s.apps[guid]
+	if !ok {
+		writeError(w, http.StatusNotFound, 10010, "CF-ResourceNotFound", "App not found")
+		return
+	}
+	rendered := renderApp(app)
+	include := r.URL.Query().Get("include")
+	if include == "space" || include == "space.organization" {
+		spaces, organizations := []ccSpace{}, []ccOrganization{}
+		if space, ok := s.spaces[app.Relationships["space"].Data.GUID]; ok {
+			spaces = append(spaces, renderSpace(space))
+			organization, ok := s.organizations[space.Relationships["organization"].Data.GUID]
+			if ok {
+				organizations = append(organizations, renderOrganization(organization))
+			}
+		}
+		included := map[string]interface{}{"spaces": spaces}
+		if include == "space.organization" {
+			included["organizations"] = organizations
+		}
+		rendered.Included = included
+	}
+	writeJSON(w, http.StatusOK, rendered)
+}
+
+func withBody(r *http.Request, body []byte) *http.Request {
+	r.Body = ioutil.NopCloser(bytes.NewReader(body))
+	return r
+}
+
+func writeError(w http.ResponseWriter, status, code int, title, detail string) {
+	writeJSON(w, status, ccErrorList{Errors: []ccError{{Code: code, Title: title, Detail: detail}}})
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+func mergeMap(into, from map[string]string) map[string]string {
+	if len(from) == 0 {
+		return into
+	}
+	if into == nil {
+		into = make(map[string]string)
+	}
+	for key, value := range from {
+		into[key] = value
+	}
+	return into
+}
+
+func copyDroplet(droplet model.Droplet) model.Droplet {
+	if droplet.Metadata != nil {
+		droplet.Metadata = &model.Metadata{
+			Labels:      mergeMap(nil, droplet.Metadata.Labels),
+			Annotations: mergeMap(nil, droplet.Metadata.Annotations),
+		}
+	}
+	return droplet
+}
@@ -0,0 +1,309 @@
+package cftest_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"code.cloudfoundry.org/capi-k8s-release/src/cf-api-controllers/cf"
+	"code.cloudfoundry.org/capi-k8s-release/src/cf-api-controllers/cf/auth"
+	"code.cloudfoundry.org/capi-k8s-release/src/cf-api-controllers/cf/cffakes"
+	. "code.cloudfoundry.org/capi-k8s-release/src/cf-api-controllers/cf/cftest"
+	"code.cloudfoundry.org/capi-k8s-release/src/cf-api-controllers/cf/model"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// emptyMetadata is how resources without labels or annotations come back
+var emptyMetadata = model.Metadata{Labels: map[string]string{}, Annotations: map[string]string{}}
+
+var _ = Describe("Server", func() {
+	var (
+		server     *Server
+		restClient *cf.RestClient
+		client     *cf.Client
+	)
+
+	BeforeEach(func() {
+		server = NewServer()
+
+		httpClient, err := cf.NewHTTPClient(cf.TLSConfig{})
+		Expect(err).NotTo(HaveOccurred())
+		uaaClient, err := auth.NewUAAClient(server.URL, "some-client", "some-secret", httpClient)
+		Expect(err).NotTo(HaveOccurred())
+		restClient = cf.NewRestClient(server.URL, httpClient, uaaClient)
+		client = cf.NewClient(restClient)
+
+		server.AddOrganization(model.Organization{GUID: "org-guid", Name: "some-org"})
+		server.AddSpace(model.Space{
+			GUID: "space-guid",
+			Name: "some-space",
+			Relationships: map[string]model.Relationship{
+				"organization": {Data: model.RelationshipData{GUID: "org-guid"}},
+			},
+		})
+		server.AddDomain(model.Domain{GUID: "domain-guid", Name: "apps.example.com"})
+		for _, guid := range []string{"route-guid-1", "route-guid-2", "route-guid-3"} {
+			server.AddRoute(model.Route{
+				GUID: guid,
+				Host: guid,
+				Relationships: map[string]model.Relationship{
+					"space":  {Data: model.RelationshipData{GUID: "space-guid"}},
+					"domain": {Data: model.RelationshipData{GUID: "domain-guid"}},
+				},
+			})
+		}
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	Describe("routes", func() {
+		It("lists the routes with their spaces, organizations and domains", func() {
+			routeList, err := client.ListRoutes(context.Background())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(routeList.Resources).To(HaveLen(3))
+			Expect(routeList.Pagination.TotalResults).To(Equal(3))
+			Expect(routeList.Included.Spaces).To(HaveLen(1))
+			Expect(routeList.Included.Organizations).To(Equal([]model.Organization{{GUID: "org-guid", Name: "some-org", Metadata: emptyMetadata}}))
+			Expect(routeList.Included.Domains).To(Equal([]model.Domain{{GUID: "domain-guid", Name: "apps.example.com"}}))
+		})
+
+		It("pages through the routes with links to the external URL", func() {
+			var hosts []string
+			pages, err := cf.EachPage(context.Background(), restClient, "/v3/routes?per_page=2&include=space", func(raw json.RawMessage) error {
+				var page model.RouteList
+				Expect(json.Unmarshal(raw, &page)).To(Succeed())
+				Expect(page.Included.Spaces).To(HaveLen(1))
+				if page.Pagination.Next != nil {
+					Expect(page.Pagination.Next.Href).To(HavePrefix(ExternalURL + "/v3/routes?"))
+				}
+				for _, route := range page.Resources {
+					hosts = append(hosts, route.Host)
+				}
+				return nil
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(pages).To(Equal(2))
+			Expect(hosts).To(Equal([]string{"route-guid-1", "route-guid-2", "route-guid-3"}))
+		})
+
+		It("can be made to page through fewer routes than asked for", func() {
+			server.LimitPageSize(2)
+
+			routeList, err := client.ListRoutes(context.Background())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(routeList.Resources).To(HaveLen(3))
+			Expect(routeList.Included.Organizations).To(HaveLen(1))
+			Expect(server.Requests(http.MethodGet, "/v3/routes")).To(HaveLen(2))
+		})
+
+		It("renders them the way CC does", func() {
+			port := 1024
+			server.AddRoute(model.Route{
+				GUID:     "tcp-route-guid",
+				Protocol: model.RouteProtocolTCP,
+				Port:     &port,
+				Destinations: []model.Destination{{
+					GUID: "destination-guid",
+					App:  model.DestinationApp{GUID: "app-guid", Process: model.DestinationProcess{Type: "web"}},
+					Port: 8080,
+				}},
+				Relationships: map[string]model.Relationship{
+					"space": {Data: model.RelationshipData{GUID: "space-guid"}},
+				},
+			})
+
+			var route map[string]interface{}
+			Expect(restClient.Get(context.Background(), "/v3/routes/tcp-route-guid?include=space.organization", &route)).To(Succeed())
+			Expect(route).To(HaveKeyWithValue("protocol", "tcp"))
+			Expect(route).To(HaveKeyWithValue("port", BeNumerically("==", 1024)))
+			Expect(route).To(HaveKeyWithValue("metadata", map[string]interface{}{
+				"labels":      map[string]interface{}{},
+				"annotations": map[string]interface{}{},
+			}))
+			Expect(route).To(HaveKeyWithValue("relationships", map[string]interface{}{
+				"space": map[string]interface{}{"data": map[string]interface{}{"guid": "space-guid"}},
+			}))
+			Expect(route["destinations"]).To(ConsistOf(And(
+				HaveKeyWithValue("app", map[string]interface{}{
+					"guid":    "app-guid",
+					"process": map[string]interface{}{"type": "web"},
+				}),
+				HaveKeyWithValue("protocol", BeNil()),
+			)))
+			Expect(route["included"]).To(And(HaveKey("spaces"), HaveKey("organizations"), Not(HaveKey("domains"))))
+		})
+
+		It("gets a single route until it is deleted", func() {
+			route, err := client.GetRoute(context.Background(), "route-guid-2")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(route.Host).To(Equal("route-guid-2"))
+			Expect(route.Included.Domains).To(HaveLen(1))
+
+			server.DeleteRoute("route-guid-2")
+			_, err = client.GetRoute(context.Background(), "route-guid-2")
+			Expect(errors.Is(err, cf.ErrNotFound)).To(BeTrue())
+		})
+	})
+
+	Describe("spaces and domains", func() {
+		It("gets a space with its organization", func() {
+			space, err := client.GetSpace(context.Background(), "space-guid")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(space.Name).To(Equal("some-space"))
+			Expect(space.Included.Organizations).To(Equal([]model.Organization{{GUID: "org-guid", Name: "some-org", Metadata: emptyMetadata}}))
+		})
+
+		It("gets a domain", func() {
+			domain, err := client.GetDomain(context.Background(), "domain-guid")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(domain.Name).To(Equal("apps.example.com"))
+
+			_, err = client.GetDomain(context.Background(), "other-domain-guid")
+			Expect(errors.Is(err, cf.ErrNotFound)).To(BeTrue())
+		})
+	})
+
+	Describe("builds", func() {
+		It("records updates to the builds it knows", func() {
+			server.AddBuild("build-guid")
+			_, updated := server.Build("build-guid")
+			Expect(updated).To(BeFalse())
+
+			Expect(client.UpdateBuild(context.Background(), "build-guid", model.Build{
+				State: model.BuildStagedState,
+				Lifecycle: model.Lifecycle{
+					Type: model.KpackLifecycleType,
+					Data: model.LifecycleData{
+						Image:        "registry.example.org/some-app@sha256:abc",
+						ProcessTypes: map[string]string{"web": "some-start-command"},
+						Buildpacks:   []model.Buildpack{{ID: "some-buildpack", Version: "1.0.0"}},
+						Stack:        "some-stack",
+						RunImage:     "registry.example.org/run@sha256:def",
+					},
+				},
+			})).To(Succeed())
+			build, updated := server.Build("build-guid")
+			Expect(updated).To(BeTrue())
+			Expect(build.State).To(Equal(model.BuildStagedState))
+			Expect(build.Lifecycle.Type).To(Equal(model.KpackLifecycleType))
+			Expect(build.Lifecycle.Data.Image).To(Equal("registry.example.org/some-app@sha256:abc"))
+			Expect(build.Lifecycle.Data.ProcessTypes).To(Equal(map[string]string{"web": "some-start-command"}))
+			Expect(build.Lifecycle.Data.Buildpacks).To(Equal([]model.Buildpack{{ID: "some-buildpack", Version: "1.0.0"}}))
+			Expect(build.Lifecycle.Data.Stack).To(Equal("some-stack"))
+			Expect(build.Lifecycle.Data.RunImage).To(Equal("registry.example.org/run@sha256:def"))
+		})
+
+		It("does not find other builds", func() {
+			err := client.UpdateBuild(context.Background(), "unknown-build-guid", model.Build{})
+			Expect(err).To(MatchError("failed to patch build: received status 404: CF-ResourceNotFound: Build not found"))
+		})
+	})
+
+	Describe("droplets", func() {
+		It("updates the image and merges the metadata", func() {
+			server.AddDroplet("droplet-guid", model.Droplet{
+				Image:    "registry.example.org/some-app@sha256:old",
+				Metadata: &model.Metadata{Annotations: map[string]string{"existing": "annotation"}},
+			})
+
+			Expect(client.UpdateDroplet(context.Background(), "droplet-guid", model.Droplet{
+				Image:    "registry.example.org/some-app@sha256:new",
+				Metadata: &model.Metadata{Annotations: map[string]string{"new": "annotation"}},
+			})).To(Succeed())
+
+			droplet, err := client.GetDroplet(context.Background(), "droplet-guid")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(droplet.Image).To(Equal("registry.example.org/some-app@sha256:new"))
+			Expect(droplet.Metadata.Annotations).To(Equal(map[string]string{"existing": "annotation", "new": "annotation"}))
+		})
+	})
+
+	Describe("apps", func() {
+		It("includes the app's space and organization", func() {
+			server.AddApp(model.App{
+				GUID: "app-guid",
+				Relationships: map[string]model.Relationship{
+					"space": {Data: model.RelationshipData{GUID: "space-guid"}},
+				},
+			})
+
+			app, err := client.GetApp(context.Background(), "app-guid")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(app.Included.Spaces).To(HaveLen(1))
+			Expect(app.Included.Organizations).To(Equal([]model.Organization{{GUID: "org-guid", Name: "some-org", Metadata: emptyMetadata}}))
+		})
+	})
+
+	Describe("authentication", func() {
+		It("rejects tokens it did not issue", func() {
+			tokens := new(cffakes.FakeTokenFetcher)
+			tokens.FetchReturns("forged-token", nil)
+
+			err := cf.NewRestClient(server.URL, &http.Client{}, tokens).Get(context.Background(), "/v3/routes", nil)
+			Expect(err).To(MatchError("received status 401: CF-InvalidAuthToken: Invalid Auth Token"))
+		})
+	})
+
+	Describe("requests", func() {
+		It("records them with their request IDs", func() {
+			_, err := client.GetRoute(context.Background(), "route-guid-1")
+			Expect(err).NotTo(HaveOccurred())
+
+			requests := server.Requests(http.MethodGet, "/v3/routes/:guid")
+			Expect(requests).To(HaveLen(1))
+			Expect(requests[0].Path).To(Equal("/v3/routes/route-guid-1?include=space.organization,domain"))
+			Expect(requests[0].RequestID).NotTo(BeEmpty())
+			Expect(server.Requests(http.MethodPost, "/oauth/token")).To(HaveLen(1))
+		})
+	})
+
+	Describe("faults", func() {
+		It("fails as many requests as asked to with an error envelope", func() {
+			server.InjectFault(http.MethodGet, "/v3/routes/:guid", Fault{
+				StatusCode: http.StatusServiceUnavailable,
+				Errors:     []model.Error{{Code: 10015, Title: "CF-ServiceUnavailable", Detail: "try again"}},
+				Times:      1,
+			})
+
+			_, err := client.GetRoute(context.Background(), "route-guid-1")
+			Expect(err).To(MatchError("failed to get route: received status 503: CF-ServiceUnavailable: try again"))
+			var apiErr *cf.APIError
+			Expect(errors.As(err, &apiErr)).To(BeTrue())
+			Expect(apiErr.RequestID).To(HaveSuffix("::cftest"))
+
+			_, err = client.GetRoute(context.Background(), "route-guid-1")
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("holds responses back until the client gives up", func() {
+			server.InjectFault(http.MethodGet, "/v3/droplets/:guid", Fault{Delay: time.Minute})
+
+			ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+			defer cancel()
+			_, err := client.GetDroplet(ctx, "droplet-guid")
+			Expect(errors.Is(err, context.DeadlineExceeded)).To(BeTrue())
+		})
+
+		It("can fail the UAA token endpoint", func() {
+			server.InjectFault(http.MethodPost, "/oauth/token", Fault{StatusCode: http.StatusInternalServerError})
+
+			_, err := client.GetRoute(context.Background(), "route-guid-1")
+			Expect(err).To(MatchError(HavePrefix("failed to get route: failed to fetch UAA token: ")))
+			Expect(server.Requests(http.MethodGet, "/v3/routes/:guid")).To(BeEmpty())
+		})
+
+		It("stops failing when the faults are cleared", func() {
+			server.InjectFault(http.MethodGet, "/v3/routes/:guid", Fault{StatusCode: http.StatusInternalServerError})
+			server.ClearFaults()
+
+			_, err := client.GetRoute(context.Background(), "route-guid-1")
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+})
@@ -1,130 +1,191 @@
 package cf
 
 import (
-	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
-	"io"
-	"net/http"
+	"time"
 
 	"code.cloudfoundry.org/capi-k8s-release/src/cf-api-controllers/cf/model"
 )
 
-func NewClient(host string, restClient Rest, uaaClient TokenFetcher) *Client {
-	// TODO: We may want to consider using cloudfoundry/tlsconfig for using
-	// standard TLS configs in Golang.
+func NewClient(restClient Rest) *Client {
 	return &Client{
-		host:       host,
 		restClient: restClient,
-		uaaClient:  uaaClient,
-		httpClient: &http.Client{},
 	}
 }
 
-//go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 . Rest
-type Rest interface {
-	Patch(url string, authToken string, body io.Reader) (*http.Response, error)
-}
-
 //go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 . TokenFetcher
 type TokenFetcher interface {
-	Fetch() (string, error)
+	Fetch(ctx context.Context) (string, error)
+	// Invalidate drops a token the CF API rejected, so it isn't fetched again
+	Invalidate()
 }
 
 // TODO: rename this to `Client`
 //go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 . ClientInterface
 type ClientInterface interface {
-	ListRoutes() (model.RouteList, error)
+	ListRoutes(ctx context.Context) (model.RouteList, error)
+	GetRoute(ctx context.Context, routeGUID string) (model.RouteResponse, error)
+	GetSpace(ctx context.Context, spaceGUID string) (model.SpaceResponse, error)
+	GetDomain(ctx context.Context, domainGUID string) (model.Domain, error)
 }
 
 type Client struct {
-	host       string
 	restClient Rest
-	uaaClient  TokenFetcher
-	httpClient *http.Client
 }
 
+// DefaultTimeout bounds each request to the CF API and UAA when no timeout is
+// configured
+const DefaultTimeout = 30 * time.Second
+
 // determined by CC API: https://v3-apidocs.cloudfoundry.org/version/3.76.0/index.html#get-a-route
 const MaxResultsPerPage int = 5000
 
-func (c *Client) UpdateBuild(buildGUID string, build model.Build) error {
-	token, err := c.uaaClient.Fetch()
+func (c *Client) UpdateBuild(ctx context.Context, buildGUID string, build model.Build) error {
+	err := c.restClient.Patch(ctx, fmt.Sprintf("/v3/builds/%s", buildGUID), build, nil)
 	if err != nil {
-		return err
-	}
-
-	raw, err := json.Marshal(build)
-	if err != nil {
-		return err
+		return fmt.Errorf("failed to patch build: %w", err)
 	}
+	return nil
+}
 
-	resp, err := c.restClient.Patch(
-		fmt.Sprintf("%s/v3/builds/%s", c.host, buildGUID),
-		token,
-		bytes.NewReader(raw),
-	)
+func (c *Client) UpdateDroplet(ctx context.Context, dropletGUID string, droplet model.Droplet) error {
+	err := c.restClient.Patch(ctx, fmt.Sprintf("/v3/droplets/%s", dropletGUID), droplet, nil)
 	if err != nil {
-		return err
-	}
-	if resp.StatusCode != 200 {
-		return fmt.Errorf("failed to patch build, received status %d", resp.StatusCode)
+		return fmt.Errorf("failed to patch droplet: %w", err)
 	}
-
 	return nil
 }
 
-func (c *Client) UpdateDroplet(dropletGUID string, droplet model.Droplet) error {
-	token, err := c.uaaClient.Fetch()
+func (c *Client) GetDroplet(ctx context.Context, dropletGUID string) (model.Droplet, error) {
+	var droplet model.Droplet
+	err := c.restClient.Get(ctx, fmt.Sprintf("/v3/droplets/%s", dropletGUID), &droplet)
 	if err != nil {
-		return err
+		return model.Droplet{}, fmt.Errorf("failed to get droplet: %w", err)
 	}
+	return droplet, nil
+}
 
-	raw, err := json.Marshal(droplet)
+// GetRoute fetches a single route with its space, the space's organization and
+// its domain included. A route that no longer exists in CC results in an error
+// matching ErrNotFound.
+func (c *Client) GetRoute(ctx context.Context, routeGUID string) (model.RouteResponse, error) {
+	var route model.RouteResponse
+	err := c.restClient.Get(ctx, fmt.Sprintf("/v3/routes/%s?include=space.organization,domain", routeGUID), &route)
 	if err != nil {
-		return err
+		return model.RouteResponse{}, fmt.Errorf("failed to get route: %w", err)
 	}
+	return route, nil
+}
 
-	resp, err := c.restClient.Patch(
-		fmt.Sprintf("%s/v3/droplets/%s", c.host, dropletGUID),
-		token,
-		bytes.NewReader(raw),
-	)
+// GetSpace fetches a single space with its organization included, for a route
+// whose space CC left out of a listing
+func (c *Client) GetSpace(ctx context.Context, spaceGUID string) (model.SpaceResponse, error) {
+	var space model.SpaceResponse
+	err := c.restClient.Get(ctx, fmt.Sprintf("/v3/spaces/%s?include=organization", spaceGUID), &space)
 	if err != nil {
-		return err
-	}
-	if resp.StatusCode != 200 {
-		return fmt.Errorf("failed to patch droplet, received status %d", resp.StatusCode)
+		return model.SpaceResponse{}, fmt.Errorf("failed to get space: %w", err)
 	}
-
-	return nil
+	return space, nil
 }
 
-// TODO: shouldn't this use the REST client?
-func (c *Client) ListRoutes() (model.RouteList, error) {
-	token, err := c.uaaClient.Fetch()
+// GetDomain fetches a single domain, for a route whose domain CC left out of a
+// listing
+func (c *Client) GetDomain(ctx context.Context, domainGUID string) (model.Domain, error) {
+	var domain model.Domain
+	err := c.restClient.Get(ctx, fmt.Sprintf("/v3/domains/%s", domainGUID), &domain)
 	if err != nil {
-		return model.RouteList{}, err
+		return model.Domain{}, fmt.Errorf("failed to get domain: %w", err)
 	}
+	return domain, nil
+}
 
-	pathAndQuery := fmt.Sprintf("%s/v3/routes?per_page=%d&include=space,domain", c.host, MaxResultsPerPage)
-	request, err := http.NewRequest("GET", pathAndQuery, nil)
+// GetApp fetches a single app with its space and the space's organization
+// included. An app that no longer exists in CC results in an error matching
+// ErrNotFound.
+func (c *Client) GetApp(ctx context.Context, appGUID string) (model.AppResponse, error) {
+	var app model.AppResponse
+	err := c.restClient.Get(ctx, fmt.Sprintf("/v3/apps/%s?include=space.organization", appGUID), &app)
 	if err != nil {
-		return model.RouteList{}, err
+		return model.AppResponse{}, fmt.Errorf("failed to get app: %w", err)
 	}
-	request.Header.Set("Authorization", "bearer "+token)
+	return app, nil
+}
 
-	resp, err := c.httpClient.Do(request)
-	if err != nil {
-		return model.RouteList{}, fmt.Errorf("failed to list routes, HTTP error: %w", err)
-	}
-	if resp.StatusCode != 200 {
-		return model.RouteList{}, fmt.Errorf("failed to list routes, received status: %d", resp.StatusCode)
-	}
+// IncompleteListError is returned alongside whatever was fetched when a
+// paginated listing could not be walked to the end. Callers may act on the
+// partial result but must not assume anything missing from it is gone from CC.
+type IncompleteListError struct {
+	PagesFetched int
+	Err          error
+}
 
+func (e *IncompleteListError) Error() string {
+	return fmt.Sprintf("incomplete list after %d page(s): %s", e.PagesFetched, e.Err)
+}
+
+func (e *IncompleteListError) Unwrap() error {
+	return e.Err
+}
+
+// ListRoutes follows `pagination.next` until every page of /v3/routes has been
+// fetched, merging the included spaces, organizations and domains of each page.
+func (c *Client) ListRoutes(ctx context.Context) (model.RouteList, error) {
 	var routeList model.RouteList
-	err = json.NewDecoder(resp.Body).Decode(&routeList)
+	seenSpaces := make(map[string]bool)
+	seenDomains := make(map[string]bool)
+	seenOrganizations := make(map[string]bool)
+	firstPage := true
+
+	path := fmt.Sprintf("/v3/routes?per_page=%d&include=space.organization,domain", MaxResultsPerPage)
+	pagesFetched, err := EachPage(ctx, c.restClient, path, func(raw json.RawMessage) error {
+		var page model.RouteList
+		if err := json.Unmarshal(raw, &page); err != nil {
+			return fmt.Errorf("failed to deserialize response from CF API: %w", err)
+		}
+
+		if firstPage {
+			routeList.Pagination = page.Pagination
+			firstPage = false
+		}
+		routeList.Resources = append(routeList.Resources, page.Resources...)
+		for _, space := range page.Included.Spaces {
+			if !seenSpaces[space.GUID] {
+				seenSpaces[space.GUID] = true
+				routeList.Included.Spaces = append(routeList.Included.Spaces, space)
+			}
+		}
+		for _, domain := range page.Included.Domains {
+			if !seenDomains[domain.GUID] {
+				seenDomains[domain.GUID] = true
+				routeList.Included.Domains = append(routeList.Included.Domains, domain)
+			}
+		}
+		for _, organization := range page.Included.Organizations {
+			if !seenOrganizations[organization.GUID] {
+				seenOrganizations[organization.GUID] = true
+				routeList.Included.Organizations = append(routeList.Included.Organizations, organization)
+			}
+		}
+		return nil
+	})
 	if err != nil {
-		return model.RouteList{}, fmt.Errorf("failed to deserialize response from CF API: %w", err)
+		// an *IncompleteListError comes with the routes fetched so far
+		return routeList, fmt.Errorf("failed to list routes: %w", err)
+	}
+
+	// routes deleted while we were paging shift later pages, which can make us
+	// skip over routes that still exist
+	if len(routeList.Resources) < routeList.Pagination.TotalResults {
+		return routeList, &IncompleteListError{
+			PagesFetched: pagesFetched,
+			Err: fmt.Errorf(
+				"received %d routes but CF API reported %d",
+				len(routeList.Resources),
+				routeList.Pagination.TotalResults,
+			),
+		}
 	}
 
 	return routeList, nil
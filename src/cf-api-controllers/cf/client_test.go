@@ -1,10 +1,10 @@
 package cf_test
 
 import (
-	"encoding/json"
+	"context"
 	"errors"
-	"io/ioutil"
 	"net/http"
+	"time"
 
 	"github.com/onsi/gomega/ghttp"
 
@@ -31,7 +31,7 @@ var _ = Describe("Client", func() {
 		tokenFetcher.FetchReturns("valid-token", nil)
 
 		restClient = new(cffakes.FakeRest)
-		client = NewClient("http://capi.host", restClient, tokenFetcher)
+		client = NewClient(restClient)
 	})
 
 	Describe("UpdateBuild", func() {
@@ -43,29 +43,178 @@ var _ = Describe("Client", func() {
 			build model.Build
 		)
 
-		When("successfully updates", func() {
+		BeforeEach(func() {
+			build = model.Build{State: "SUCCESS"}
+		})
+
+		It("patches the build in CF API", func() {
+			Expect(client.UpdateBuild(context.Background(), guid, build)).To(Succeed())
+
+			Expect(restClient.PatchCallCount()).To(Equal(1))
+			_, actualPath, actualBody, actualResult := restClient.PatchArgsForCall(0)
+			Expect(actualPath).To(Equal("/v3/builds/guid"))
+			Expect(actualBody).To(Equal(build))
+			Expect(actualResult).To(BeNil())
+		})
+
+		When("CF API server client fails to Patch", func() {
 			BeforeEach(func() {
-				build = model.Build{State: "SUCCESS"}
-				restClient.PatchReturns(&http.Response{StatusCode: 200}, nil)
+				restClient.PatchReturns(errors.New("fail"))
+			})
+
+			It("errors", func() {
+				Expect(client.UpdateBuild(context.Background(), guid, build)).To(MatchError("failed to patch build: fail"))
 			})
+		})
 
-			It("fetches a token and updates CF API server", func() {
-				Expect(client.UpdateBuild(guid, build)).To(Succeed())
+		When("CC responds with an error envelope", func() {
+			var statusCode int
 
-				Expect(tokenFetcher.FetchCallCount()).To(Equal(1))
+			JustBeforeEach(func() {
+				restClient.PatchReturns(&APIError{
+					StatusCode: statusCode,
+					Errors:     []model.Error{{Code: 10010, Title: "CF-ResourceNotFound", Detail: "Build not found"}},
+				})
+			})
 
-				raw, err := json.Marshal(build)
-				Expect(err).To(BeNil())
+			When("the build is not found", func() {
+				BeforeEach(func() {
+					statusCode = http.StatusNotFound
+				})
+
+				It("returns a permanent NotFound error with CC's details", func() {
+					err := client.UpdateBuild(context.Background(), guid, build)
+					Expect(err).To(MatchError("failed to patch build: received status 404: CF-ResourceNotFound: Build not found"))
+					Expect(errors.Is(err, ErrNotFound)).To(BeTrue())
+					Expect(IsPermanent(err)).To(BeTrue())
+
+					var apiErr *APIError
+					Expect(errors.As(err, &apiErr)).To(BeTrue())
+					Expect(apiErr.Errors).To(Equal([]model.Error{{Code: 10010, Title: "CF-ResourceNotFound", Detail: "Build not found"}}))
+				})
+			})
+
+			When("the request conflicts with the build's state", func() {
+				BeforeEach(func() {
+					statusCode = http.StatusConflict
+				})
+
+				It("returns a permanent Conflict error", func() {
+					err := client.UpdateBuild(context.Background(), guid, build)
+					Expect(errors.Is(err, ErrConflict)).To(BeTrue())
+					Expect(IsPermanent(err)).To(BeTrue())
+				})
+			})
+
+			When("the request is unprocessable", func() {
+				BeforeEach(func() {
+					statusCode = http.StatusUnprocessableEntity
+				})
+
+				It("returns a permanent Unprocessable error", func() {
+					err := client.UpdateBuild(context.Background(), guid, build)
+					Expect(errors.Is(err, ErrUnprocessable)).To(BeTrue())
+					Expect(IsPermanent(err)).To(BeTrue())
+				})
+			})
+
+			When("CC is unavailable", func() {
+				BeforeEach(func() {
+					statusCode = http.StatusServiceUnavailable
+				})
+
+				It("returns a transient Unavailable error", func() {
+					err := client.UpdateBuild(context.Background(), guid, build)
+					Expect(errors.Is(err, ErrUnavailable)).To(BeTrue())
+					Expect(IsPermanent(err)).To(BeFalse())
+				})
+			})
+		})
+	})
+
+	Describe("UpdateDroplet", func() {
+		const (
+			guid = "guid"
+		)
+		var (
+			droplet model.Droplet
+		)
+
+		BeforeEach(func() {
+			droplet = model.Droplet{Image: "updated-image-reference"}
+		})
+
+		It("patches the droplet in CF API", func() {
+			Expect(client.UpdateDroplet(context.Background(), guid, droplet)).To(Succeed())
+
+			Expect(restClient.PatchCallCount()).To(Equal(1))
+			_, actualPath, actualBody, actualResult := restClient.PatchArgsForCall(0)
+			Expect(actualPath).To(Equal("/v3/droplets/guid"))
+			Expect(actualBody).To(Equal(droplet))
+			Expect(actualResult).To(BeNil())
+		})
+
+		When("CF API server client fails to Patch", func() {
+			BeforeEach(func() {
+				restClient.PatchReturns(errors.New("fail"))
+			})
+
+			It("errors", func() {
+				Expect(client.UpdateDroplet(context.Background(), guid, droplet)).To(MatchError("failed to patch droplet: fail"))
+			})
+		})
 
-				Expect(restClient.PatchCallCount()).To(Equal(1))
+		When("the droplet is not found", func() {
+			BeforeEach(func() {
+				restClient.PatchReturns(&APIError{
+					StatusCode: http.StatusNotFound,
+					Errors:     []model.Error{{Code: 10010, Title: "CF-ResourceNotFound", Detail: "Droplet not found"}},
+				})
+			})
 
-				actualURL, actualAuthToken, actualBodyIO := restClient.PatchArgsForCall(0)
-				Expect(actualURL).To(Equal("http://capi.host/v3/builds/guid"))
-				Expect(actualAuthToken).To(Equal("valid-token"))
+			It("returns a permanent NotFound error", func() {
+				err := client.UpdateDroplet(context.Background(), guid, droplet)
+				Expect(err).To(MatchError("failed to patch droplet: received status 404: CF-ResourceNotFound: Droplet not found"))
+				Expect(errors.Is(err, ErrNotFound)).To(BeTrue())
+				Expect(IsPermanent(err)).To(BeTrue())
+			})
+		})
+	})
 
-				actualBody, err := ioutil.ReadAll(actualBodyIO)
+	Describe("GetDroplet", func() {
+		var (
+			fakeCFAPIServer *ghttp.Server
+		)
+
+		BeforeEach(func() {
+			fakeCFAPIServer = ghttp.NewServer()
+
+			client = NewClient(NewRestClient(fakeCFAPIServer.URL(), &http.Client{}, tokenFetcher))
+		})
+
+		AfterEach(func() {
+			fakeCFAPIServer.Close()
+		})
+
+		When("CF API is operating normally", func() {
+			BeforeEach(func() {
+				fakeCFAPIServer.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", "/v3/droplets/some-droplet-guid"),
+						ghttp.VerifyHeaderKV("Authorization", "Bearer valid-token"),
+						ghttp.RespondWith(200, `{
+	 "guid": "some-droplet-guid",
+	 "state": "STAGED",
+	 "image": "registry.example.org/some-app@sha256:abc"
+}`),
+					),
+				)
+			})
+
+			It("returns the droplet", func() {
+				droplet, err := client.GetDroplet(context.Background(), "some-droplet-guid")
 				Expect(err).NotTo(HaveOccurred())
-				Expect(actualBody).To(Equal(raw))
+				Expect(droplet).To(Equal(model.Droplet{Image: "registry.example.org/some-app@sha256:abc"}))
 			})
 		})
 
@@ -75,62 +224,186 @@ var _ = Describe("Client", func() {
 			})
 
 			It("errors", func() {
-				Expect(client.UpdateBuild(guid, build)).ToNot(Succeed())
+				_, err := client.GetDroplet(context.Background(), "some-droplet-guid")
+				Expect(err).To(MatchError("failed to get droplet: failed to fetch UAA token: fail"))
 			})
 		})
 
-		When("CF API server client fails to Patch", func() {
+		When("a non-200 status code is received", func() {
 			BeforeEach(func() {
-				restClient.PatchReturns(nil, errors.New("fail"))
+				fakeCFAPIServer.AppendHandlers(
+					ghttp.RespondWith(404, `{}`),
+				)
 			})
 
 			It("errors", func() {
-				Expect(client.UpdateBuild(guid, build)).ToNot(Succeed())
+				_, err := client.GetDroplet(context.Background(), "some-droplet-guid")
+				Expect(err).To(MatchError("failed to get droplet: received status 404"))
 			})
 		})
 
-		When("a non-400+ status code is received", func() {
+		When("CF API does not respond before the context is done", func() {
+			var release chan struct{}
+
 			BeforeEach(func() {
-				restClient.PatchReturns(&http.Response{StatusCode: 500}, nil)
+				release = make(chan struct{})
+				fakeCFAPIServer.AppendHandlers(func(w http.ResponseWriter, _ *http.Request) {
+					<-release
+				})
 			})
 
-			It("errors", func() {
-				Expect(client.UpdateBuild(guid, build)).ToNot(Succeed())
+			AfterEach(func() {
+				close(release)
+			})
+
+			It("abandons the request", func() {
+				ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+				defer cancel()
+
+				_, err := client.GetDroplet(ctx, "some-droplet-guid")
+				Expect(errors.Is(err, context.DeadlineExceeded)).To(BeTrue(), "expected a deadline error, got %v", err)
 			})
 		})
 	})
 
-	Describe("UpdateDroplet", func() {
-		const (
-			guid = "guid"
-		)
+	Describe("GetRoute", func() {
 		var (
-			droplet model.Droplet
+			fakeCFAPIServer *ghttp.Server
 		)
 
-		When("successfully updates", func() {
+		BeforeEach(func() {
+			fakeCFAPIServer = ghttp.NewServer()
+
+			client = NewClient(NewRestClient(fakeCFAPIServer.URL(), &http.Client{}, tokenFetcher))
+		})
+
+		AfterEach(func() {
+			fakeCFAPIServer.Close()
+		})
+
+		When("CF API is operating normally", func() {
 			BeforeEach(func() {
-				droplet = model.Droplet{Image: "updated-image-reference"}
-				restClient.PatchReturns(&http.Response{StatusCode: 200}, nil)
+				fakeCFAPIServer.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", "/v3/routes/some-route-guid", "include=space.organization,domain"),
+						ghttp.VerifyHeaderKV("Authorization", "Bearer valid-token"),
+						ghttp.RespondWith(200, `{
+	 "guid": "some-route-guid",
+	 "host": "a-hostname",
+	 "path": "/some_path",
+	 "url": "a-hostname.a-domain.com/some_path",
+	 "destinations": [],
+	 "relationships": {
+	   "space": {"data": {"guid": "some-space-guid"}},
+	   "domain": {"data": {"guid": "some-domain-guid"}}
+	 },
+	 "included": {
+	   "spaces": [{"guid": "some-space-guid", "name": "some-space"}],
+	   "domains": [{"guid": "some-domain-guid", "name": "a-domain.com"}],
+	   "organizations": [{"guid": "some-org-guid", "name": "some-org"}]
+	 }
+}`),
+					),
+				)
 			})
 
-			It("fetches a token and updates CF API server", func() {
-				Expect(client.UpdateDroplet(guid, droplet)).To(Succeed())
+			It("returns the route with its space, organization and domain", func() {
+				route, err := client.GetRoute(context.Background(), "some-route-guid")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(route.GUID).To(Equal("some-route-guid"))
+				Expect(route.Host).To(Equal("a-hostname"))
+				Expect(route.Relationships["space"].Data.GUID).To(Equal("some-space-guid"))
+				Expect(route.Included.Spaces).To(Equal([]model.Space{{GUID: "some-space-guid", Name: "some-space"}}))
+				Expect(route.Included.Domains).To(Equal([]model.Domain{{GUID: "some-domain-guid", Name: "a-domain.com"}}))
+				Expect(route.Included.Organizations).To(Equal([]model.Organization{{GUID: "some-org-guid", Name: "some-org"}}))
+			})
+		})
 
-				Expect(tokenFetcher.FetchCallCount()).To(Equal(1))
+		When("uaa client fails to fetch a token", func() {
+			BeforeEach(func() {
+				tokenFetcher.FetchReturns("", errors.New("fail"))
+			})
 
-				raw, err := json.Marshal(droplet)
-				Expect(err).To(BeNil())
+			It("errors", func() {
+				_, err := client.GetRoute(context.Background(), "some-route-guid")
+				Expect(err).To(MatchError("failed to get route: failed to fetch UAA token: fail"))
+			})
+		})
+
+		When("the route does not exist", func() {
+			BeforeEach(func() {
+				fakeCFAPIServer.AppendHandlers(
+					ghttp.RespondWith(404, `{"errors": [{"code": 10010, "title": "CF-ResourceNotFound", "detail": "Route not found"}]}`),
+				)
+			})
+
+			It("returns an error matching ErrNotFound", func() {
+				_, err := client.GetRoute(context.Background(), "some-route-guid")
+				Expect(errors.Is(err, ErrNotFound)).To(BeTrue())
+				Expect(err).To(MatchError("failed to get route: received status 404: CF-ResourceNotFound: Route not found"))
+			})
+		})
+	})
 
-				Expect(restClient.PatchCallCount()).To(Equal(1))
+	Describe("GetApp", func() {
+		var (
+			fakeCFAPIServer *ghttp.Server
+		)
 
-				actualURL, actualAuthToken, actualBodyIO := restClient.PatchArgsForCall(0)
-				Expect(actualURL).To(Equal("http://capi.host/v3/droplets/guid"))
-				Expect(actualAuthToken).To(Equal("valid-token"))
+		BeforeEach(func() {
+			fakeCFAPIServer = ghttp.NewServer()
+
+			client = NewClient(NewRestClient(fakeCFAPIServer.URL(), &http.Client{}, tokenFetcher))
+		})
+
+		AfterEach(func() {
+			fakeCFAPIServer.Close()
+		})
+
+		When("CF API is operating normally", func() {
+			BeforeEach(func() {
+				fakeCFAPIServer.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", "/v3/apps/some-app-guid", "include=space.organization"),
+						ghttp.VerifyHeaderKV("Authorization", "Bearer valid-token"),
+						ghttp.RespondWith(200, `{
+	 "guid": "some-app-guid",
+	 "name": "some-app",
+	 "metadata": {"labels": {}, "annotations": {}},
+	 "relationships": {
+	   "space": {"data": {"guid": "some-space-guid"}}
+	 },
+	 "included": {
+	   "spaces": [{
+	     "guid": "some-space-guid",
+	     "name": "some-space",
+	     "metadata": {"labels": {"stack-rebase.cloudfoundry.org/opt-out": "true"}, "annotations": {}},
+	     "relationships": {"organization": {"data": {"guid": "some-org-guid"}}}
+	   }],
+	   "organizations": [{
+	     "guid": "some-org-guid",
+	     "name": "some-org",
+	     "metadata": {"labels": {}, "annotations": {"owner": "someone"}}
+	   }]
+	 }
+}`),
+					),
+				)
+			})
 
-				actualBody, err := ioutil.ReadAll(actualBodyIO)
+			It("returns the app with its space and organization", func() {
+				app, err := client.GetApp(context.Background(), "some-app-guid")
 				Expect(err).NotTo(HaveOccurred())
-				Expect(actualBody).To(Equal(raw))
+				Expect(app.GUID).To(Equal("some-app-guid"))
+				Expect(app.Name).To(Equal("some-app"))
+				Expect(app.Relationships["space"].Data.GUID).To(Equal("some-space-guid"))
+
+				Expect(app.Included.Spaces).To(HaveLen(1))
+				Expect(app.Included.Spaces[0].Metadata.Labels).To(HaveKeyWithValue("stack-rebase.cloudfoundry.org/opt-out", "true"))
+				Expect(app.Included.Spaces[0].Relationships["organization"].Data.GUID).To(Equal("some-org-guid"))
+				Expect(app.Included.Organizations).To(HaveLen(1))
+				Expect(app.Included.Organizations[0].Name).To(Equal("some-org"))
+				Expect(app.Included.Organizations[0].Metadata.Annotations).To(HaveKeyWithValue("owner", "someone"))
 			})
 		})
 
@@ -140,27 +413,125 @@ var _ = Describe("Client", func() {
 			})
 
 			It("errors", func() {
-				Expect(client.UpdateDroplet(guid, droplet)).ToNot(Succeed())
+				_, err := client.GetApp(context.Background(), "some-app-guid")
+				Expect(err).To(MatchError("failed to get app: failed to fetch UAA token: fail"))
 			})
 		})
 
-		When("CF API server client fails to Patch", func() {
+		When("the app does not exist", func() {
 			BeforeEach(func() {
-				restClient.PatchReturns(nil, errors.New("fail"))
+				fakeCFAPIServer.AppendHandlers(
+					ghttp.RespondWith(404, `{"errors": [{"code": 10010, "title": "CF-ResourceNotFound", "detail": "App not found"}]}`),
+				)
 			})
 
-			It("errors", func() {
-				Expect(client.UpdateDroplet(guid, droplet)).ToNot(Succeed())
+			It("returns an error matching ErrNotFound", func() {
+				_, err := client.GetApp(context.Background(), "some-app-guid")
+				Expect(errors.Is(err, ErrNotFound)).To(BeTrue())
+				Expect(err).To(MatchError("failed to get app: received status 404: CF-ResourceNotFound: App not found"))
+			})
+		})
+	})
+
+	Describe("GetSpace", func() {
+		var (
+			fakeCFAPIServer *ghttp.Server
+		)
+
+		BeforeEach(func() {
+			fakeCFAPIServer = ghttp.NewServer()
+
+			client = NewClient(NewRestClient(fakeCFAPIServer.URL(), &http.Client{}, tokenFetcher))
+		})
+
+		AfterEach(func() {
+			fakeCFAPIServer.Close()
+		})
+
+		When("CF API is operating normally", func() {
+			BeforeEach(func() {
+				fakeCFAPIServer.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", "/v3/spaces/some-space-guid", "include=organization"),
+						ghttp.VerifyHeaderKV("Authorization", "Bearer valid-token"),
+						ghttp.RespondWith(200, `{
+	 "guid": "some-space-guid",
+	 "name": "some-space",
+	 "relationships": {"organization": {"data": {"guid": "some-org-guid"}}},
+	 "included": {
+	   "organizations": [{"guid": "some-org-guid", "name": "some-org"}]
+	 }
+}`),
+					),
+				)
+			})
+
+			It("returns the space with its organization", func() {
+				space, err := client.GetSpace(context.Background(), "some-space-guid")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(space.GUID).To(Equal("some-space-guid"))
+				Expect(space.Name).To(Equal("some-space"))
+				Expect(space.Relationships["organization"].Data.GUID).To(Equal("some-org-guid"))
+				Expect(space.Included.Organizations).To(Equal([]model.Organization{{GUID: "some-org-guid", Name: "some-org"}}))
 			})
 		})
 
-		When("a non-400+ status code is received", func() {
+		When("the space does not exist", func() {
 			BeforeEach(func() {
-				restClient.PatchReturns(&http.Response{StatusCode: 500}, nil)
+				fakeCFAPIServer.AppendHandlers(
+					ghttp.RespondWith(404, `{"errors": [{"code": 10010, "title": "CF-ResourceNotFound", "detail": "Space not found"}]}`),
+				)
+			})
+
+			It("returns an error matching ErrNotFound", func() {
+				_, err := client.GetSpace(context.Background(), "some-space-guid")
+				Expect(errors.Is(err, ErrNotFound)).To(BeTrue())
+				Expect(err).To(MatchError("failed to get space: received status 404: CF-ResourceNotFound: Space not found"))
+			})
+		})
+	})
+
+	Describe("GetDomain", func() {
+		var (
+			fakeCFAPIServer *ghttp.Server
+		)
+
+		BeforeEach(func() {
+			fakeCFAPIServer = ghttp.NewServer()
+
+			client = NewClient(NewRestClient(fakeCFAPIServer.URL(), &http.Client{}, tokenFetcher))
+		})
+
+		AfterEach(func() {
+			fakeCFAPIServer.Close()
+		})
+
+		When("CF API is operating normally", func() {
+			BeforeEach(func() {
+				fakeCFAPIServer.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", "/v3/domains/some-domain-guid"),
+						ghttp.VerifyHeaderKV("Authorization", "Bearer valid-token"),
+						ghttp.RespondWith(200, `{"guid": "some-domain-guid", "name": "a-domain.com"}`),
+					),
+				)
+			})
+
+			It("returns the domain", func() {
+				domain, err := client.GetDomain(context.Background(), "some-domain-guid")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(domain).To(Equal(model.Domain{GUID: "some-domain-guid", Name: "a-domain.com"}))
+			})
+		})
+
+		When("uaa client fails to fetch a token", func() {
+			BeforeEach(func() {
+				tokenFetcher.FetchReturns("", errors.New("fail"))
 			})
 
 			It("errors", func() {
-				Expect(client.UpdateDroplet(guid, droplet)).ToNot(Succeed())
+				_, err := client.GetDomain(context.Background(), "some-domain-guid")
+				Expect(err).To(MatchError("failed to get domain: failed to fetch UAA token: fail"))
 			})
 		})
 	})
@@ -173,7 +544,7 @@ var _ = Describe("Client", func() {
 		BeforeEach(func() {
 			fakeCFAPIServer = ghttp.NewServer()
 
-			client = NewClient(fakeCFAPIServer.URL(), restClient, tokenFetcher)
+			client = NewClient(NewRestClient(fakeCFAPIServer.URL(), &http.Client{}, tokenFetcher))
 		})
 
 		AfterEach(func() {
@@ -232,8 +603,8 @@ var _ = Describe("Client", func() {
 	       }
 	     ],
 	     "metadata": {
-	       "labels": {},
-	       "annotations": {}
+	       "labels": {"env": "production"},
+	       "annotations": {"contact": "team@example.com"}
 	     },
 	     "relationships": {
 	       "space": {
@@ -348,6 +719,19 @@ var _ = Describe("Client", func() {
           }
         }
       }
+    ],
+    "organizations": [
+      {
+        "guid": "e00705b9-7b42-4561-ae97-2520399d2133",
+        "created_at": "2017-02-01T01:33:58Z",
+        "updated_at": "2017-02-01T01:33:58Z",
+        "name": "my-org",
+        "suspended": false,
+        "metadata": {
+          "labels": {},
+          "annotations": {}
+        }
+      }
     ]
 }
 	}`),
@@ -356,7 +740,7 @@ var _ = Describe("Client", func() {
 			})
 
 			It("returns a list of routes", func() {
-				routeList, err := client.ListRoutes()
+				routeList, err := client.ListRoutes(context.Background())
 				routes := routeList.Resources
 
 				Expect(err).To(BeNil())
@@ -367,6 +751,9 @@ var _ = Describe("Client", func() {
 				Expect(routes[0].Host).To(Equal("a-hostname"))
 				Expect(routes[0].Path).To(Equal("/some_path"))
 				Expect(routes[0].URL).To(Equal("a-hostname.a-domain.com/some_path"))
+				Expect(routes[0].Protocol).To(Equal(model.RouteProtocolHTTP))
+				Expect(routes[0].Metadata.Labels).To(Equal(map[string]string{"env": "production"}))
+				Expect(routes[0].Metadata.Annotations).To(Equal(map[string]string{"contact": "team@example.com"}))
 
 				Expect(routes[0].Destinations).To(HaveLen(2))
 				Expect(routes[0].Destinations[0].GUID).To(Equal("385bf117-17f5-4689-8c5c-08c6cc821fed"))
@@ -387,6 +774,166 @@ var _ = Describe("Client", func() {
 				Expect(domain.GUID).To(Equal("0b5f3633-194c-42d2-9408-972366617e0e"))
 				Expect(domain.Name).To(Equal("test-domain.com"))
 				Expect(domain.Internal).To(BeFalse())
+
+				Expect(routeList.Included.Organizations).To(HaveLen(1))
+				Expect(routeList.Included.Organizations[0].Name).To(Equal("my-org"))
+			})
+		})
+
+		When("CF API returns more than one page of routes", func() {
+			BeforeEach(func() {
+				fakeCFAPIServer.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", "/v3/routes", "per_page=5000&include=space.organization,domain"),
+						ghttp.RespondWith(200, `{
+	"pagination": {
+		"total_results": 2,
+		"total_pages": 2,
+		"next": { "href": "https://api.example.org/v3/routes?include=space.organization%2Cdomain&page=2&per_page=5000" }
+	},
+	"resources": [
+		{
+			"guid": "route-guid-1",
+			"relationships": {
+				"space": { "data": { "guid": "space-guid" } },
+				"domain": { "data": { "guid": "domain-guid-1" } }
+			}
+		}
+	],
+	"included": {
+		"spaces": [ { "guid": "space-guid", "name": "my-space" } ],
+		"domains": [ { "guid": "domain-guid-1", "name": "one.example.com" } ],
+		"organizations": [ { "guid": "org-guid", "name": "my-org" } ]
+	}
+}`),
+					),
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", "/v3/routes", "include=space.organization%2Cdomain&page=2&per_page=5000"),
+						ghttp.VerifyHeaderKV("Authorization", "Bearer valid-token"),
+						ghttp.RespondWith(200, `{
+	"pagination": {
+		"total_results": 2,
+		"total_pages": 2,
+		"next": null
+	},
+	"resources": [
+		{
+			"guid": "route-guid-2",
+			"relationships": {
+				"space": { "data": { "guid": "space-guid" } },
+				"domain": { "data": { "guid": "domain-guid-2" } }
+			}
+		}
+	],
+	"included": {
+		"spaces": [ { "guid": "space-guid", "name": "my-space" } ],
+		"domains": [ { "guid": "domain-guid-2", "name": "two.example.com" } ],
+		"organizations": [ { "guid": "org-guid", "name": "my-org" } ]
+	}
+}`),
+					),
+				)
+			})
+
+			It("follows the next links against the configured host and merges every page", func() {
+				routeList, err := client.ListRoutes(context.Background())
+				Expect(err).NotTo(HaveOccurred())
+				Expect(fakeCFAPIServer.ReceivedRequests()).To(HaveLen(2))
+
+				Expect(routeList.Resources).To(HaveLen(2))
+				Expect(routeList.Resources[0].GUID).To(Equal("route-guid-1"))
+				Expect(routeList.Resources[1].GUID).To(Equal("route-guid-2"))
+
+				Expect(routeList.Included.Spaces).To(HaveLen(1))
+				Expect(routeList.Included.Spaces[0].GUID).To(Equal("space-guid"))
+
+				Expect(routeList.Included.Domains).To(HaveLen(2))
+				Expect(routeList.Included.Domains[0].GUID).To(Equal("domain-guid-1"))
+				Expect(routeList.Included.Domains[1].GUID).To(Equal("domain-guid-2"))
+
+				Expect(routeList.Included.Organizations).To(HaveLen(1))
+				Expect(routeList.Included.Organizations[0].GUID).To(Equal("org-guid"))
+			})
+		})
+
+		When("CF API fails part way through the page walk", func() {
+			BeforeEach(func() {
+				fakeCFAPIServer.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", "/v3/routes"),
+						ghttp.RespondWith(200, `{
+	"pagination": {
+		"total_results": 2,
+		"total_pages": 2,
+		"next": { "href": "https://api.example.org/v3/routes?page=2&per_page=5000" }
+	},
+	"resources": [ { "guid": "route-guid-1" } ]
+}`),
+					),
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", "/v3/routes"),
+						ghttp.RespondWith(503, ""),
+					),
+				)
+			})
+
+			It("returns the routes fetched so far with an IncompleteListError", func() {
+				routeList, err := client.ListRoutes(context.Background())
+
+				var incompleteErr *IncompleteListError
+				Expect(errors.As(err, &incompleteErr)).To(BeTrue())
+				Expect(incompleteErr.PagesFetched).To(Equal(1))
+				Expect(err.Error()).To(ContainSubstring("failed to list routes: incomplete list after 1 page(s): received status 503"))
+
+				Expect(routeList.Resources).To(HaveLen(1))
+				Expect(routeList.Resources[0].GUID).To(Equal("route-guid-1"))
+			})
+		})
+
+		When("CF API returns fewer routes than it reports in total", func() {
+			BeforeEach(func() {
+				fakeCFAPIServer.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", "/v3/routes"),
+						ghttp.RespondWith(200, `{
+	"pagination": {
+		"total_results": 3,
+		"total_pages": 1,
+		"next": null
+	},
+	"resources": [ { "guid": "route-guid-1" } ]
+}`),
+					),
+				)
+			})
+
+			It("returns an IncompleteListError", func() {
+				_, err := client.ListRoutes(context.Background())
+
+				var incompleteErr *IncompleteListError
+				Expect(errors.As(err, &incompleteErr)).To(BeTrue())
+				Expect(err.Error()).To(ContainSubstring("received 1 routes but CF API reported 3"))
+			})
+		})
+
+		When("CF API returns a next link pointing back at a page already fetched", func() {
+			BeforeEach(func() {
+				fakeCFAPIServer.RouteToHandler("GET", "/v3/routes", ghttp.RespondWith(200, `{
+	"pagination": {
+		"total_results": 1,
+		"total_pages": 1,
+		"next": { "href": "https://api.example.org/v3/routes?per_page=5000&include=space.organization,domain" }
+	},
+	"resources": [ { "guid": "route-guid-1" } ]
+}`))
+			})
+
+			It("stops walking and returns an IncompleteListError", func() {
+				_, err := client.ListRoutes(context.Background())
+
+				var incompleteErr *IncompleteListError
+				Expect(errors.As(err, &incompleteErr)).To(BeTrue())
+				Expect(err.Error()).To(ContainSubstring("pagination loop detected"))
 			})
 		})
 
@@ -396,7 +943,7 @@ var _ = Describe("Client", func() {
 			})
 
 			It("returns a meaningful error", func() {
-				_, err := client.ListRoutes()
+				_, err := client.ListRoutes(context.Background())
 
 				Expect(err).ToNot(BeNil())
 				Expect(err.Error()).To(ContainSubstring("connection refused"))
@@ -414,10 +961,10 @@ var _ = Describe("Client", func() {
 			})
 
 			It("returns a meaningful error", func() {
-				_, err := client.ListRoutes()
+				_, err := client.ListRoutes(context.Background())
 
 				Expect(err).ToNot(BeNil())
-				Expect(err.Error()).To(ContainSubstring("failed to list routes, received status: 418"))
+				Expect(err.Error()).To(ContainSubstring("failed to list routes: received status 418"))
 			})
 		})
 
@@ -432,7 +979,7 @@ var _ = Describe("Client", func() {
 			})
 
 			It("returns a meaningful error", func() {
-				_, err := client.ListRoutes()
+				_, err := client.ListRoutes(context.Background())
 
 				Expect(err).ToNot(BeNil())
 				Expect(err.Error()).To(ContainSubstring("failed to deserialize response from CF API"))
@@ -445,7 +992,7 @@ var _ = Describe("Client", func() {
 			})
 
 			It("errors", func() {
-				_, err := client.ListRoutes()
+				_, err := client.ListRoutes(context.Background())
 
 				Expect(err).ToNot(BeNil())
 				Expect(err.Error()).To(ContainSubstring("uaa-fail"))
@@ -0,0 +1,89 @@
+package cf
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"code.cloudfoundry.org/capi-k8s-release/src/cf-api-controllers/cf/model"
+)
+
+// maxErrorBodyBytes bounds how much of an error response is read
+const maxErrorBodyBytes = 1 << 20
+
+// These can be matched against errors returned by the Client with errors.Is
+var (
+	ErrNotFound      = errors.New("not found")
+	ErrConflict      = errors.New("conflict")
+	ErrUnprocessable = errors.New("unprocessable entity")
+	ErrUnavailable   = errors.New("unavailable")
+)
+
+// APIError is a non-2xx response from the CF API, along with the errors CC
+// listed in its v3 error envelope
+type APIError struct {
+	StatusCode int
+	Errors     []model.Error
+	// RequestID is the X-Vcap-Request-Id CC logged the request under
+	RequestID string
+}
+
+func (e *APIError) Error() string {
+	details := make([]string, 0, len(e.Errors))
+	for _, ccErr := range e.Errors {
+		details = append(details, fmt.Sprintf("%s: %s", ccErr.Title, ccErr.Detail))
+	}
+	if len(details) == 0 {
+		return fmt.Sprintf("received status %d", e.StatusCode)
+	}
+	return fmt.Sprintf("received status %d: %s", e.StatusCode, strings.Join(details, "; "))
+}
+
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound
+	case ErrConflict:
+		return e.StatusCode == http.StatusConflict
+	case ErrUnprocessable:
+		return e.StatusCode == http.StatusUnprocessableEntity
+	case ErrUnavailable:
+		return e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= http.StatusInternalServerError
+	}
+	return false
+}
+
+// IsPermanent reports whether err is a response from the CF API that
+// repeating the same request cannot change. Errors without a response, such
+// as network failures, are assumed to be transient.
+func IsPermanent(err error) bool {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+
+	switch apiErr.StatusCode {
+	case http.StatusUnauthorized, http.StatusRequestTimeout, http.StatusTooManyRequests:
+		// a fresh token or a less busy CC may succeed
+		return false
+	}
+	return apiErr.StatusCode >= 400 && apiErr.StatusCode < 500
+}
+
+// newAPIError builds an APIError from a response, decoding the error envelope
+// if the body holds one
+func newAPIError(resp *http.Response) *APIError {
+	apiErr := &APIError{StatusCode: resp.StatusCode}
+	if resp.Body == nil {
+		return apiErr
+	}
+
+	var errorList model.ErrorList
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxErrorBodyBytes)).Decode(&errorList); err == nil {
+		apiErr.Errors = errorList.Errors
+	}
+	return apiErr
+}
@@ -0,0 +1,111 @@
+package kubernetes
+
+import (
+	"strings"
+
+	"code.cloudfoundry.org/cf-k8s-networking/routecontroller/apis/networking/v1alpha1"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	cfmodel "code.cloudfoundry.org/capi-k8s-release/src/cf-api-controllers/cf/model"
+)
+
+const (
+	CFOrgNameAnnotation   = "cloudfoundry.org/org_name"
+	CFSpaceNameAnnotation = "cloudfoundry.org/space_name"
+
+	// RouteMetadataDomain prefixes the labels and annotations of a CF route
+	// mirrored onto its Route: `env` becomes `route.metadata.cloudfoundry.org/env`
+	// and `example.com/team` becomes
+	// `example.com.route.metadata.cloudfoundry.org/team`. CC reserves the
+	// cloudfoundry.org domain, so these can't clash with keys users set.
+	RouteMetadataDomain = "route.metadata.cloudfoundry.org"
+)
+
+// MirroredMetadataKey returns the key a CF label or annotation is mirrored
+// under, and false if that is not a valid key in k8s, e.g. because it is too
+// long
+func MirroredMetadataKey(key string) (string, bool) {
+	prefix, name := RouteMetadataDomain, key
+	if i := strings.LastIndex(key, "/"); i >= 0 {
+		prefix, name = key[:i]+"."+RouteMetadataDomain, key[i+1:]
+	}
+	mirrored := prefix + "/" + name
+	return mirrored, len(validation.IsQualifiedName(mirrored)) == 0
+}
+
+// isMirroredMetadataKey reports whether key is that of a CF label or
+// annotation mirrored onto a Route
+func isMirroredMetadataKey(key string) bool {
+	i := strings.LastIndex(key, "/")
+	if i < 0 {
+		return false
+	}
+	prefix := key[:i]
+	return prefix == RouteMetadataDomain || strings.HasSuffix(prefix, "."+RouteMetadataDomain)
+}
+
+// routeLabels adds the CF route's labels that are valid in k8s to labels
+func routeLabels(labels map[string]string, metadata cfmodel.Metadata) map[string]string {
+	for key, value := range metadata.Labels {
+		mirrored, ok := MirroredMetadataKey(key)
+		if ok && len(validation.IsValidLabelValue(value)) == 0 {
+			labels[mirrored] = value
+		}
+	}
+	return labels
+}
+
+// routeAnnotations names the route's org and space, and mirrors the CF route's
+// annotations. The org is left out when CC didn't include it.
+func routeAnnotations(route *cfmodel.Route, space *cfmodel.Space, organization *cfmodel.Organization) map[string]string {
+	annotations := map[string]string{
+		CFSpaceNameAnnotation: space.Name,
+	}
+	if organization != nil {
+		annotations[CFOrgNameAnnotation] = organization.Name
+	}
+	for key, value := range route.Metadata.Annotations {
+		if mirrored, ok := MirroredMetadataKey(key); ok {
+			annotations[mirrored] = value
+		}
+	}
+	return annotations
+}
+
+// metadataConverged reports whether actual carries every key of desired, and
+// no mirrored key desired has since lost. Keys set by others are ignored.
+func metadataConverged(desired, actual map[string]string) bool {
+	for key, value := range desired {
+		if actualValue, ok := actual[key]; !ok || actualValue != value {
+			return false
+		}
+	}
+	for key := range actual {
+		if _, ok := desired[key]; !ok && isMirroredMetadataKey(key) {
+			return false
+		}
+	}
+	return true
+}
+
+// convergeMetadata sets the keys of desired on actual and removes the mirrored
+// keys desired has lost, keeping the keys set by others
+func convergeMetadata(desired, actual map[string]string) map[string]string {
+	converged := make(map[string]string, len(actual)+len(desired))
+	for key, value := range actual {
+		if _, ok := desired[key]; ok || !isMirroredMetadataKey(key) {
+			converged[key] = value
+		}
+	}
+	for key, value := range desired {
+		converged[key] = value
+	}
+	return converged
+}
+
+// ApplyRoute updates actual to match desired, as CompareRoutes compares them
+func ApplyRoute(desired v1alpha1.Route, actual *v1alpha1.Route) {
+	actual.Spec = desired.Spec
+	actual.Labels = convergeMetadata(desired.Labels, actual.Labels)
+	actual.Annotations = convergeMetadata(desired.Annotations, actual.Annotations)
+}
@@ -0,0 +1,101 @@
+package kubernetes_test
+
+import (
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	. "code.cloudfoundry.org/capi-k8s-release/src/cf-api-controllers/cf/kubernetes"
+	"code.cloudfoundry.org/cf-k8s-networking/routecontroller/apis/networking/v1alpha1"
+)
+
+var _ = Describe("Route metadata", func() {
+	Describe("MirroredMetadataKey", func() {
+		It("prefixes keys without a prefix", func() {
+			key, ok := MirroredMetadataKey("env")
+			Expect(ok).To(BeTrue())
+			Expect(key).To(Equal("route.metadata.cloudfoundry.org/env"))
+		})
+
+		It("nests the prefix of a key under its own", func() {
+			key, ok := MirroredMetadataKey("example.com/team")
+			Expect(ok).To(BeTrue())
+			Expect(key).To(Equal("example.com.route.metadata.cloudfoundry.org/team"))
+		})
+
+		It("rejects keys that don't fit in k8s once prefixed", func() {
+			_, ok := MirroredMetadataKey(strings.Repeat("a", 230) + ".example.com/team")
+			Expect(ok).To(BeFalse())
+		})
+	})
+
+	var desiredRoute, actualRoute v1alpha1.Route
+
+	BeforeEach(func() {
+		desiredRoute = v1alpha1.Route{
+			ObjectMeta: v1.ObjectMeta{
+				Labels: map[string]string{
+					CFRouteGuidLabel:                      "route-guid",
+					"route.metadata.cloudfoundry.org/env": "production",
+				},
+				Annotations: map[string]string{
+					CFSpaceNameAnnotation: "space-name",
+				},
+			},
+			Spec: v1alpha1.RouteSpec{Host: "host"},
+		}
+		actualRoute = *desiredRoute.DeepCopy()
+	})
+
+	Describe("CompareRoutes", func() {
+		It("ignores labels and annotations set by others", func() {
+			actualRoute.Labels["example.com/other"] = "value"
+			actualRoute.Annotations["kubectl.kubernetes.io/last-applied-configuration"] = "{}"
+
+			Expect(CompareRoutes(desiredRoute, actualRoute)).To(BeTrue())
+		})
+
+		It("detects a changed label", func() {
+			actualRoute.Labels["route.metadata.cloudfoundry.org/env"] = "staging"
+
+			Expect(CompareRoutes(desiredRoute, actualRoute)).To(BeFalse())
+		})
+
+		It("detects a label removed in CF", func() {
+			actualRoute.Labels["example.com.route.metadata.cloudfoundry.org/team"] = "routing"
+
+			Expect(CompareRoutes(desiredRoute, actualRoute)).To(BeFalse())
+		})
+
+		It("detects a renamed space", func() {
+			actualRoute.Annotations[CFSpaceNameAnnotation] = "old-space-name"
+
+			Expect(CompareRoutes(desiredRoute, actualRoute)).To(BeFalse())
+		})
+	})
+
+	Describe("ApplyRoute", func() {
+		BeforeEach(func() {
+			actualRoute.Spec.Host = "old-host"
+			actualRoute.Labels["route.metadata.cloudfoundry.org/env"] = "staging"
+			actualRoute.Labels["route.metadata.cloudfoundry.org/removed"] = "value"
+			actualRoute.Labels["example.com/other"] = "value"
+			delete(actualRoute.Annotations, CFSpaceNameAnnotation)
+		})
+
+		It("makes the Route match while keeping the labels and annotations set by others", func() {
+			ApplyRoute(desiredRoute, &actualRoute)
+
+			Expect(actualRoute.Spec.Host).To(Equal("host"))
+			Expect(actualRoute.Labels).To(Equal(map[string]string{
+				CFRouteGuidLabel:                      "route-guid",
+				"route.metadata.cloudfoundry.org/env": "production",
+				"example.com/other":                   "value",
+			}))
+			Expect(actualRoute.Annotations).To(Equal(map[string]string{CFSpaceNameAnnotation: "space-name"}))
+			Expect(CompareRoutes(desiredRoute, actualRoute)).To(BeTrue())
+		})
+	})
+})
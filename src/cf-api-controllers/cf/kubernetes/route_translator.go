@@ -1,10 +1,13 @@
 package kubernetes
 
 import (
+	"fmt"
+	"reflect"
+	"strings"
+
 	cfmodel "code.cloudfoundry.org/capi-k8s-release/src/cf-api-controllers/cf/model"
 	"code.cloudfoundry.org/cf-k8s-networking/routecontroller/apis/networking/v1alpha1"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"reflect"
 )
 
 const (
@@ -21,7 +24,48 @@ const (
 	CFRouteGuidLabel   = "cloudfoundry.org/route_guid"
 )
 
-func TranslateRoute(route *cfmodel.Route, space *cfmodel.Space, domain *cfmodel.Domain, namespace string) v1alpha1.Route {
+// SupportsRoute reports whether route can be expressed as a Route resource at
+// all. The Route API only routes HTTP, so it has no place for TCP routes.
+func SupportsRoute(route *cfmodel.Route) bool {
+	return route.Protocol != cfmodel.RouteProtocolTCP
+}
+
+// UnsupportedRouteError names the parts of a CF route the Route API can't
+// express: the protocol and port of TCP routes and the protocol of
+// destinations, which it routes as HTTP/1
+type UnsupportedRouteError struct {
+	Unsupported []string
+}
+
+func (e *UnsupportedRouteError) Error() string {
+	return "the Route API can't express " + strings.Join(e.Unsupported, ", ")
+}
+
+// CheckRouteSupported returns an *UnsupportedRouteError if the Route
+// translated from route would leave out any of it
+func CheckRouteSupported(route *cfmodel.Route) error {
+	var unsupported []string
+	if !SupportsRoute(route) {
+		protocol := fmt.Sprintf("protocol %s", route.Protocol)
+		if route.Port != nil {
+			protocol += fmt.Sprintf(" on port %d", *route.Port)
+		}
+		unsupported = append(unsupported, protocol)
+	}
+	for _, dest := range route.Destinations {
+		if dest.Protocol != "" && dest.Protocol != cfmodel.DestinationProtocolHTTP1 {
+			unsupported = append(unsupported, fmt.Sprintf("protocol %s of destination %s", dest.Protocol, dest.GUID))
+		}
+	}
+	if len(unsupported) > 0 {
+		return &UnsupportedRouteError{Unsupported: unsupported}
+	}
+	return nil
+}
+
+// TranslateRoute builds the Route for a CF route, mirroring its metadata. The
+// organization may be nil when CC didn't include it.
+func TranslateRoute(route *cfmodel.Route, space *cfmodel.Space, organization *cfmodel.Organization, domain *cfmodel.Domain, namespace string) v1alpha1.Route {
 	destinations := make([]v1alpha1.RouteDestination, 0)
 
 	for _, dest := range route.Destinations {
@@ -49,7 +93,7 @@ func TranslateRoute(route *cfmodel.Route, space *cfmodel.Space, domain *cfmodel.
 		ObjectMeta: v1.ObjectMeta{
 			Name:      route.GUID,
 			Namespace: namespace,
-			Labels: map[string]string{
+			Labels: routeLabels(map[string]string{
 				KubeNameLabel:      route.GUID,
 				KubeVersionLabel:   "0.0.0",
 				KubeManagedByLabel: "cloudfoundry",
@@ -59,7 +103,8 @@ func TranslateRoute(route *cfmodel.Route, space *cfmodel.Space, domain *cfmodel.
 				CFSpaceGuidLabel:   space.GUID,
 				CFDomainGuidLabel:  domain.GUID,
 				CFRouteGuidLabel:   route.GUID,
-			},
+			}, route.Metadata),
+			Annotations: routeAnnotations(route, space, organization),
 		},
 		Spec: v1alpha1.RouteSpec{
 			Host: route.Host,
@@ -77,6 +122,10 @@ func TranslateRoute(route *cfmodel.Route, space *cfmodel.Space, domain *cfmodel.
 	return routeCR
 }
 
+// CompareRoutes reports whether actualRoute matches desiredRoute, ignoring the
+// labels and annotations set by others
 func CompareRoutes(desiredRoute, actualRoute v1alpha1.Route) bool {
-	return reflect.DeepEqual(desiredRoute.Spec, actualRoute.Spec)
+	return reflect.DeepEqual(desiredRoute.Spec, actualRoute.Spec) &&
+		metadataConverged(desiredRoute.Labels, actualRoute.Labels) &&
+		metadataConverged(desiredRoute.Annotations, actualRoute.Annotations)
 }
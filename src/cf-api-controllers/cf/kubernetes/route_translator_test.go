@@ -1,6 +1,8 @@
 package kubernetes_test
 
 import (
+	"errors"
+
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 	"github.com/onsi/gomega/gstruct"
@@ -13,10 +15,11 @@ import (
 
 var _ = Describe("RouteTranslator", func() {
 	var (
-		route   model.Route
-		space   model.Space
-		domain  model.Domain
-		routeCR v1alpha1.Route
+		route        model.Route
+		space        model.Space
+		organization model.Organization
+		domain       model.Domain
+		routeCR      v1alpha1.Route
 	)
 
 	const (
@@ -60,12 +63,17 @@ var _ = Describe("RouteTranslator", func() {
 				}
 				space = model.Space{
 					GUID: "space-guid",
+					Name: "space-name",
 					Relationships: map[string]model.Relationship{
 						"organization": {
 							Data: model.RelationshipData{GUID: "org-guid"},
 						},
 					},
 				}
+				organization = model.Organization{
+					GUID: "org-guid",
+					Name: "org-name",
+				}
 				domain = model.Domain{
 					GUID:     "domain-guid",
 					Name:     "domain.com",
@@ -74,7 +82,7 @@ var _ = Describe("RouteTranslator", func() {
 			})
 
 			It("returns a valid Route CR", func() {
-				routeCR = TranslateRoute(&route, &space, &domain, namespace)
+				routeCR = TranslateRoute(&route, &space, &organization, &domain, namespace)
 
 				Expect(routeCR).NotTo(BeNil())
 
@@ -108,6 +116,42 @@ var _ = Describe("RouteTranslator", func() {
 				Expect(routeCR.Spec.Destinations[0].Selector.MatchLabels).To(HaveKeyWithValue("cloudfoundry.org/app_guid", route.Destinations[0].App.GUID))
 				Expect(routeCR.Spec.Destinations[0].Selector.MatchLabels).To(HaveKeyWithValue("cloudfoundry.org/process_type", route.Destinations[0].App.Process.Type))
 			})
+
+			It("annotates the Route with the names of its org and space", func() {
+				routeCR = TranslateRoute(&route, &space, &organization, &domain, namespace)
+
+				Expect(routeCR.ObjectMeta.Annotations).To(Equal(map[string]string{
+					"cloudfoundry.org/org_name":   "org-name",
+					"cloudfoundry.org/space_name": "space-name",
+				}))
+			})
+
+			It("leaves out the org's name when CC didn't include the org", func() {
+				routeCR = TranslateRoute(&route, &space, nil, &domain, namespace)
+
+				Expect(routeCR.ObjectMeta.Annotations).NotTo(HaveKey("cloudfoundry.org/org_name"))
+				Expect(routeCR.ObjectMeta.Annotations).To(HaveKeyWithValue("cloudfoundry.org/space_name", "space-name"))
+			})
+
+			It("mirrors the route's labels and annotations under a prefix", func() {
+				route.Metadata = model.Metadata{
+					Labels: map[string]string{
+						"env":              "production",
+						"example.com/team": "routing",
+						"bad":              "not a valid label value",
+					},
+					Annotations: map[string]string{
+						"contact": "routing@example.com",
+					},
+				}
+				routeCR = TranslateRoute(&route, &space, &organization, &domain, namespace)
+
+				Expect(routeCR.ObjectMeta.Labels).To(HaveKeyWithValue("route.metadata.cloudfoundry.org/env", "production"))
+				Expect(routeCR.ObjectMeta.Labels).To(HaveKeyWithValue("example.com.route.metadata.cloudfoundry.org/team", "routing"))
+				Expect(routeCR.ObjectMeta.Labels).NotTo(HaveKey("route.metadata.cloudfoundry.org/bad"))
+				Expect(routeCR.ObjectMeta.Labels).To(HaveKeyWithValue("cloudfoundry.org/route_guid", route.GUID))
+				Expect(routeCR.ObjectMeta.Annotations).To(HaveKeyWithValue("route.metadata.cloudfoundry.org/contact", "routing@example.com"))
+			})
 		})
 	})
 
@@ -407,6 +451,52 @@ var _ = Describe("RouteTranslator", func() {
 			})
 		})
 	})
+
+	Describe("SupportsRoute", func() {
+		It("supports HTTP routes", func() {
+			Expect(SupportsRoute(&model.Route{Protocol: model.RouteProtocolHTTP})).To(BeTrue())
+		})
+
+		It("supports routes from a CC that doesn't report their protocol", func() {
+			Expect(SupportsRoute(&model.Route{})).To(BeTrue())
+		})
+
+		It("does not support TCP routes", func() {
+			Expect(SupportsRoute(&model.Route{Protocol: model.RouteProtocolTCP, Port: intToPtr(1024)})).To(BeFalse())
+		})
+	})
+
+	Describe("CheckRouteSupported", func() {
+		It("accepts HTTP routes to HTTP/1 destinations", func() {
+			route := &model.Route{
+				Protocol: model.RouteProtocolHTTP,
+				Destinations: []model.Destination{
+					{GUID: "destination-guid-1", Protocol: model.DestinationProtocolHTTP1},
+					{GUID: "destination-guid-2"},
+				},
+			}
+			Expect(CheckRouteSupported(route)).To(Succeed())
+		})
+
+		It("names the protocol and port of TCP routes", func() {
+			route := &model.Route{Protocol: model.RouteProtocolTCP, Port: intToPtr(1024)}
+			Expect(CheckRouteSupported(route)).To(MatchError("the Route API can't express protocol tcp on port 1024"))
+		})
+
+		It("names the destinations that aren't HTTP/1", func() {
+			route := &model.Route{
+				Protocol: model.RouteProtocolHTTP,
+				Destinations: []model.Destination{
+					{GUID: "destination-guid-1", Protocol: model.DestinationProtocolHTTP1},
+					{GUID: "destination-guid-2", Protocol: model.DestinationProtocolHTTP2},
+				},
+			}
+			err := CheckRouteSupported(route)
+			var unsupported *UnsupportedRouteError
+			Expect(errors.As(err, &unsupported)).To(BeTrue())
+			Expect(unsupported.Unsupported).To(ConsistOf("protocol http2 of destination destination-guid-2"))
+		})
+	})
 })
 
 func intToPtr(i int) *int {
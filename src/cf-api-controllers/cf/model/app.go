@@ -0,0 +1,20 @@
+package model
+
+type App struct {
+	GUID          string                  `json:"guid"`
+	Name          string                  `json:"name"`
+	Metadata      Metadata                `json:"metadata"`
+	Relationships map[string]Relationship `json:"relationships"`
+}
+
+// AppResponse is a single app fetched from `/v3/apps/:guid` along with its
+// included space and organization
+type AppResponse struct {
+	App
+	Included AppIncluded `json:"included"`
+}
+
+type AppIncluded struct {
+	Spaces        []Space        `json:"spaces"`
+	Organizations []Organization `json:"organizations"`
+}
@@ -26,6 +26,16 @@ type Lifecycle struct {
 type LifecycleData struct {
 	Image        string            `json:"image"`
 	ProcessTypes map[string]string `json:"processTypes"`
+	Buildpacks   []Buildpack       `json:"buildpacks,omitempty"`
+	Stack        string            `json:"stack,omitempty"`
+	RunImage     string            `json:"runImage,omitempty"`
+}
+
+// Buildpack is a buildpack that took part in building a droplet
+type Buildpack struct {
+	ID       string `json:"id"`
+	Version  string `json:"version"`
+	Homepage string `json:"homepage,omitempty"`
 }
 
 func NewBuildFromKpackBuild(kpackBuild *buildv1alpha1.Build) Build {
@@ -34,8 +44,23 @@ func NewBuildFromKpackBuild(kpackBuild *buildv1alpha1.Build) Build {
 		Lifecycle: Lifecycle{
 			Type: KpackLifecycleType,
 			Data: LifecycleData{
-				Image: kpackBuild.Status.LatestImage,
+				Image:      kpackBuild.Status.LatestImage,
+				Buildpacks: newBuildpacksFromKpackBuild(kpackBuild),
+				Stack:      kpackBuild.Status.Stack.ID,
+				RunImage:   kpackBuild.Status.Stack.RunImage,
 			},
 		},
 	}
 }
+
+func newBuildpacksFromKpackBuild(kpackBuild *buildv1alpha1.Build) []Buildpack {
+	var buildpacks []Buildpack
+	for _, buildpack := range kpackBuild.Status.BuildMetadata {
+		buildpacks = append(buildpacks, Buildpack{
+			ID:       buildpack.Id,
+			Version:  buildpack.Version,
+			Homepage: buildpack.Homepage,
+		})
+	}
+	return buildpacks
+}
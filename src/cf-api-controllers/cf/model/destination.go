@@ -1,10 +1,18 @@
 package model
 
+// destination protocols of HTTP routes
+const (
+	DestinationProtocolHTTP1 = "http1"
+	DestinationProtocolHTTP2 = "http2"
+)
+
 type Destination struct {
 	GUID   string         `json:"guid"`
 	App    DestinationApp `json:"app"`
 	Weight *int           `json:"weight"`
 	Port   int            `json:"port"`
+	// Protocol is how the app is spoken to, which CC leaves out for TCP routes
+	Protocol string `json:"protocol,omitempty"`
 }
 
 type DestinationApp struct {
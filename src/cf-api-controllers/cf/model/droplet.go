@@ -1,7 +1,8 @@
 package model
 
 // Droplet represents the payload that will be sent to CF API server when an Image
-// has been rebased.
+// has been rebased, and the part of a droplet read back from it.
 type Droplet struct {
-	Image string `json:"image"`
+	Image    string    `json:"image"`
+	Metadata *Metadata `json:"metadata,omitempty"`
 }
@@ -0,0 +1,12 @@
+package model
+
+// ErrorList is the body of an error response from the CF API v3
+type ErrorList struct {
+	Errors []Error `json:"errors"`
+}
+
+type Error struct {
+	Code   int    `json:"code"`
+	Title  string `json:"title"`
+	Detail string `json:"detail"`
+}
@@ -0,0 +1,8 @@
+package model
+
+// Metadata holds the labels and annotations users can set on CF resources.
+// When updating a resource, only the keys given are changed.
+type Metadata struct {
+	Labels      map[string]string `json:"labels,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
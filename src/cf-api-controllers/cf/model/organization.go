@@ -0,0 +1,7 @@
+package model
+
+type Organization struct {
+	GUID     string   `json:"guid"`
+	Name     string   `json:"name"`
+	Metadata Metadata `json:"metadata"`
+}
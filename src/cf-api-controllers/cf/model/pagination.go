@@ -0,0 +1,11 @@
+package model
+
+type Pagination struct {
+	TotalResults int   `json:"total_results"`
+	TotalPages   int   `json:"total_pages"`
+	Next         *Link `json:"next"`
+}
+
+type Link struct {
+	Href string `json:"href"`
+}
@@ -1,5 +1,11 @@
 package model
 
+// route protocols
+const (
+	RouteProtocolHTTP = "http"
+	RouteProtocolTCP  = "tcp"
+)
+
 type Route struct {
 	GUID          string                  `json:"guid"`
 	Host          string                  `json:"host"`
@@ -7,4 +13,18 @@ type Route struct {
 	URL           string                  `json:"url"`
 	Destinations  []Destination           `json:"destinations"`
 	Relationships map[string]Relationship `json:"relationships"`
+	Metadata      Metadata                `json:"metadata"`
+
+	// Protocol is that of the route's domain. Routes from a CC that predates
+	// it have none and are HTTP routes.
+	Protocol string `json:"protocol"`
+	// Port is only set for TCP routes
+	Port *int `json:"port"`
+}
+
+// RouteResponse is a single route fetched from `/v3/routes/:guid` along with
+// its included space, the space's organization and domain
+type RouteResponse struct {
+	Route
+	Included RouteListIncluded `json:"included"`
 }
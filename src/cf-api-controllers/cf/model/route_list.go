@@ -1,14 +1,13 @@
 package model
 
 type RouteList struct {
-	Pagination struct {
-		TotalPages int `json:"total_pages"`
-	}
-	Resources []Route           `json:"resources"`
-	Included  RouteListIncluded `json:"included"`
+	Pagination Pagination        `json:"pagination"`
+	Resources  []Route           `json:"resources"`
+	Included   RouteListIncluded `json:"included"`
 }
 
 type RouteListIncluded struct {
-	Spaces  []Space  `json:"spaces"`
-	Domains []Domain `json:"domains"`
+	Spaces        []Space        `json:"spaces"`
+	Domains       []Domain       `json:"domains"`
+	Organizations []Organization `json:"organizations"`
 }
@@ -3,5 +3,17 @@ package model
 type Space struct {
 	GUID          string                  `json:"guid"`
 	Name          string                  `json:"name"`
+	Metadata      Metadata                `json:"metadata"`
 	Relationships map[string]Relationship `json:"relationships"`
 }
+
+// SpaceResponse is a single space fetched from `/v3/spaces/:guid` along with
+// its included organization
+type SpaceResponse struct {
+	Space
+	Included SpaceIncluded `json:"included"`
+}
+
+type SpaceIncluded struct {
+	Organizations []Organization `json:"organizations"`
+}
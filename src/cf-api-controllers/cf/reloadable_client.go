@@ -0,0 +1,65 @@
+package cf
+
+import (
+	"context"
+	"sync"
+
+	"code.cloudfoundry.org/capi-k8s-release/src/cf-api-controllers/cf/model"
+)
+
+// ReloadableClient is a Client that can be swapped for one with a different
+// CF API host, credentials or TLS settings while the controllers use it
+type ReloadableClient struct {
+	mu     sync.RWMutex
+	client *Client
+}
+
+func NewReloadableClient(client *Client) *ReloadableClient {
+	return &ReloadableClient{client: client}
+}
+
+// Reload makes every later request go through client. Requests already in
+// flight finish with the previous one.
+func (r *ReloadableClient) Reload(client *Client) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.client = client
+}
+
+func (r *ReloadableClient) current() *Client {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.client
+}
+
+func (r *ReloadableClient) UpdateBuild(ctx context.Context, buildGUID string, build model.Build) error {
+	return r.current().UpdateBuild(ctx, buildGUID, build)
+}
+
+func (r *ReloadableClient) UpdateDroplet(ctx context.Context, dropletGUID string, droplet model.Droplet) error {
+	return r.current().UpdateDroplet(ctx, dropletGUID, droplet)
+}
+
+func (r *ReloadableClient) GetDroplet(ctx context.Context, dropletGUID string) (model.Droplet, error) {
+	return r.current().GetDroplet(ctx, dropletGUID)
+}
+
+func (r *ReloadableClient) GetRoute(ctx context.Context, routeGUID string) (model.RouteResponse, error) {
+	return r.current().GetRoute(ctx, routeGUID)
+}
+
+func (r *ReloadableClient) GetSpace(ctx context.Context, spaceGUID string) (model.SpaceResponse, error) {
+	return r.current().GetSpace(ctx, spaceGUID)
+}
+
+func (r *ReloadableClient) GetDomain(ctx context.Context, domainGUID string) (model.Domain, error) {
+	return r.current().GetDomain(ctx, domainGUID)
+}
+
+func (r *ReloadableClient) GetApp(ctx context.Context, appGUID string) (model.AppResponse, error) {
+	return r.current().GetApp(ctx, appGUID)
+}
+
+func (r *ReloadableClient) ListRoutes(ctx context.Context) (model.RouteList, error) {
+	return r.current().ListRoutes(ctx)
+}
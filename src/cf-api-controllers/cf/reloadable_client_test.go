@@ -0,0 +1,56 @@
+package cf_test
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/onsi/gomega/ghttp"
+
+	. "code.cloudfoundry.org/capi-k8s-release/src/cf-api-controllers/cf"
+	"code.cloudfoundry.org/capi-k8s-release/src/cf-api-controllers/cf/cffakes"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ReloadableClient", func() {
+	var (
+		oldCFAPIServer *ghttp.Server
+		newCFAPIServer *ghttp.Server
+		tokenFetcher   *cffakes.FakeTokenFetcher
+		client         *ReloadableClient
+	)
+
+	BeforeEach(func() {
+		tokenFetcher = new(cffakes.FakeTokenFetcher)
+		tokenFetcher.FetchReturns("valid-token", nil)
+
+		oldCFAPIServer = ghttp.NewServer()
+		newCFAPIServer = ghttp.NewServer()
+		for _, server := range []*ghttp.Server{oldCFAPIServer, newCFAPIServer} {
+			server.RouteToHandler("GET", "/v3/droplets/some-droplet-guid",
+				ghttp.RespondWith(200, `{"image": "registry.example.org/some-app@sha256:abc"}`),
+			)
+		}
+
+		client = NewReloadableClient(NewClient(NewRestClient(oldCFAPIServer.URL(), &http.Client{}, tokenFetcher)))
+	})
+
+	AfterEach(func() {
+		oldCFAPIServer.Close()
+		newCFAPIServer.Close()
+	})
+
+	It("sends requests to the CF API it was last reloaded with", func() {
+		_, err := client.GetDroplet(context.Background(), "some-droplet-guid")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(oldCFAPIServer.ReceivedRequests()).To(HaveLen(1))
+
+		client.Reload(NewClient(NewRestClient(newCFAPIServer.URL(), &http.Client{}, tokenFetcher)))
+
+		_, err = client.GetDroplet(context.Background(), "some-droplet-guid")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(oldCFAPIServer.ReceivedRequests()).To(HaveLen(1))
+		Expect(newCFAPIServer.ReceivedRequests()).To(HaveLen(1))
+	})
+})
@@ -1,33 +1,205 @@
 package cf
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"code.cloudfoundry.org/capi-k8s-release/src/cf-api-controllers/cf/model"
+	"code.cloudfoundry.org/capi-k8s-release/src/cf-api-controllers/metrics"
 )
 
-func (r *RestClient) Patch(url, authToken string, body io.Reader) (*http.Response, error) {
-	req, err := http.NewRequest(
-		http.MethodPatch,
-		url,
-		body,
-	)
+// RequestIDHeader carries the ID CC logs a request under, which it echoes back
+// with its own suffix appended
+const RequestIDHeader = "X-Vcap-Request-Id"
+
+//go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 . Rest
+
+// Rest sends authenticated requests to the CF API v3 for a path such as
+// /v3/routes?include=space. Bodies are sent as JSON and responses are decoded
+// into result unless it is nil. A non-2xx response results in an *APIError.
+type Rest interface {
+	Get(ctx context.Context, path string, result interface{}) error
+	Post(ctx context.Context, path string, body interface{}, result interface{}) error
+	Patch(ctx context.Context, path string, body interface{}, result interface{}) error
+	Delete(ctx context.Context, path string) error
+}
+
+// RestClient implements Rest against the CF API at host, with tokens from UAA
+type RestClient struct {
+	host       string
+	httpClient *http.Client
+	tokens     TokenFetcher
+}
+
+// NewRestClient creates a RestClient sending its requests with httpClient,
+// e.g. one from NewHTTPClient
+func NewRestClient(host string, httpClient *http.Client, tokens TokenFetcher) *RestClient {
+	return &RestClient{
+		host:       strings.TrimSuffix(host, "/"),
+		httpClient: httpClient,
+		tokens:     tokens,
+	}
+}
+
+func (r *RestClient) Get(ctx context.Context, path string, result interface{}) error {
+	return r.do(ctx, http.MethodGet, path, nil, result)
+}
+
+func (r *RestClient) Post(ctx context.Context, path string, body interface{}, result interface{}) error {
+	return r.do(ctx, http.MethodPost, path, body, result)
+}
+
+func (r *RestClient) Patch(ctx context.Context, path string, body interface{}, result interface{}) error {
+	return r.do(ctx, http.MethodPatch, path, body, result)
+}
+
+func (r *RestClient) Delete(ctx context.Context, path string) error {
+	return r.do(ctx, http.MethodDelete, path, nil, nil)
+}
+
+// do sends a single request, abandoning it when ctx is done
+func (r *RestClient) do(ctx context.Context, method, path string, body interface{}, result interface{}) error {
+	token, err := r.tokens.Fetch(ctx)
 	if err != nil {
-		return nil, err
+		return fmt.Errorf("failed to fetch UAA token: %w", err)
+	}
+
+	var bodyReader io.Reader
+	if body != nil {
+		raw, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		bodyReader = bytes.NewReader(raw)
 	}
 
-	req.Header.Add("Content-Type", "application/json")
-	req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", authToken))
+	request, err := http.NewRequestWithContext(ctx, method, r.host+path, bodyReader)
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Authorization", "Bearer "+token)
+	request.Header.Set("Accept", "application/json")
+	if body != nil {
+		request.Header.Set("Content-Type", "application/json")
+	}
+	requestID := uuid.New().String()
+	request.Header.Set(RequestIDHeader, requestID)
 
-	resp, err := r.Do(req)
+	start := time.Now()
+	resp, err := r.httpClient.Do(request)
+	observeRequest(method, endpointTemplate(path), start, resp)
 	if err != nil {
-		return resp, err
+		return err
 	}
 	defer resp.Body.Close()
 
-	return resp, nil
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		apiErr := newAPIError(resp)
+		apiErr.RequestID = requestID
+		if echoed := resp.Header.Get(RequestIDHeader); echoed != "" {
+			apiErr.RequestID = echoed
+		}
+		// a revoked token, or one issued for a since rotated secret, would
+		// otherwise keep being handed out until it expires
+		if resp.StatusCode == http.StatusUnauthorized {
+			r.tokens.Invalidate()
+		}
+		return apiErr
+	}
+
+	if result == nil || resp.StatusCode == http.StatusNoContent {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+		return fmt.Errorf("failed to deserialize response from CF API: %w", err)
+	}
+	return nil
 }
 
-type RestClient struct {
-	*http.Client
+// observeRequest records a CF API call under its endpoint template so GUIDs
+// don't end up in metric labels. A nil response is recorded as "error".
+func observeRequest(method, endpoint string, start time.Time, resp *http.Response) {
+	code := "error"
+	if resp != nil {
+		code = strconv.Itoa(resp.StatusCode)
+	}
+	metrics.CFAPIRequests.WithLabelValues(endpoint, method, code).Inc()
+	metrics.CFAPIRequestDuration.WithLabelValues(endpoint, method).Observe(time.Since(start).Seconds())
+}
+
+// endpointTemplate replaces the GUIDs in a CF API v3 path with :guid and drops
+// the query, so that neither ends up in metric labels, e.g.
+// /v3/routes/abc/destinations becomes /v3/routes/:guid/destinations
+func endpointTemplate(path string) string {
+	if i := strings.IndexByte(path, '?'); i >= 0 {
+		path = path[:i]
+	}
+	segments := strings.Split(strings.TrimPrefix(path, "/"), "/")
+	// after the version, collections alternate with the GUIDs of their
+	// resources, up to the name of a relationship
+	for i := 2; i < len(segments); i += 2 {
+		if segments[i-1] == "relationships" {
+			break
+		}
+		segments[i] = ":guid"
+	}
+	return "/" + strings.Join(segments, "/")
+}
+
+// EachPage GETs path and then every page its `pagination.next` links lead to,
+// handing the body of each page to visit. CC renders links with its external
+// hostname, so only their path and query are followed. Failing after some
+// pages were visited results in an *IncompleteListError. It returns how many
+// pages were visited.
+func EachPage(ctx context.Context, rest Rest, path string, visit func(page json.RawMessage) error) (int, error) {
+	visited := make(map[string]bool)
+	pagesVisited := 0
+	incomplete := func(err error) (int, error) {
+		if pagesVisited == 0 {
+			return 0, err
+		}
+		return pagesVisited, &IncompleteListError{PagesFetched: pagesVisited, Err: err}
+	}
+
+	for path != "" {
+		if visited[path] {
+			return incomplete(fmt.Errorf("pagination loop detected at %s", path))
+		}
+		visited[path] = true
+
+		var page json.RawMessage
+		if err := rest.Get(ctx, path, &page); err != nil {
+			return incomplete(err)
+		}
+		var links struct {
+			Pagination model.Pagination `json:"pagination"`
+		}
+		if err := json.Unmarshal(page, &links); err != nil {
+			return incomplete(fmt.Errorf("failed to deserialize response from CF API: %w", err))
+		}
+		if err := visit(page); err != nil {
+			return incomplete(err)
+		}
+		pagesVisited++
+
+		path = ""
+		if next := links.Pagination.Next; next != nil && next.Href != "" {
+			nextURL, err := url.Parse(next.Href)
+			if err != nil {
+				return incomplete(fmt.Errorf("failed to parse next page link: %w", err))
+			}
+			path = nextURL.RequestURI()
+		}
+	}
+	return pagesVisited, nil
 }
@@ -1,60 +1,307 @@
-package cf
+package cf_test
 
 import (
-	"bytes"
-	"fmt"
-	"io"
-	"io/ioutil"
+	"context"
+	"encoding/json"
+	"errors"
 	"net/http"
-	"net/http/httptest"
+
+	"github.com/onsi/gomega/ghttp"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	. "code.cloudfoundry.org/capi-k8s-release/src/cf-api-controllers/cf"
+	"code.cloudfoundry.org/capi-k8s-release/src/cf-api-controllers/cf/cffakes"
+	"code.cloudfoundry.org/capi-k8s-release/src/cf-api-controllers/cf/model"
+	"code.cloudfoundry.org/capi-k8s-release/src/cf-api-controllers/metrics"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 )
 
 var _ = Describe("RestClient", func() {
-	Describe("PATCH", func() {
-		var (
-			restClient RestClient
-			testServer *httptest.Server
-			authToken  string
-			body       io.Reader
-		)
+	var (
+		fakeCFAPIServer *ghttp.Server
+		tokenFetcher    *cffakes.FakeTokenFetcher
+		restClient      *RestClient
+	)
 
-		BeforeEach(func() {
-			status := []byte(`{"status":"SUCCESS"}`)
-			authToken = "valid-auth-token-returned-by-uaa"
+	BeforeEach(func() {
+		fakeCFAPIServer = ghttp.NewServer()
+		tokenFetcher = new(cffakes.FakeTokenFetcher)
+		tokenFetcher.FetchReturns("valid-token", nil)
 
-			body = bytes.NewReader(status)
-			testServer = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-				Expect(r.URL.String()).To(Equal("/"))
+		restClient = NewRestClient(fakeCFAPIServer.URL(), &http.Client{}, tokenFetcher)
+	})
 
-				b, err := ioutil.ReadAll(r.Body)
-				Expect(err).NotTo(HaveOccurred())
-				Expect(b).To(Equal(status))
+	AfterEach(func() {
+		fakeCFAPIServer.Close()
+	})
 
-				Expect(r.Header.Get("Authorization")).To(Equal(fmt.Sprintf("Bearer %s", authToken)))
-				Expect(r.Header.Get("Content-Type")).To(Equal("application/json"))
+	Describe("Get", func() {
+		It("sends an authenticated request and decodes the response", func() {
+			fakeCFAPIServer.AppendHandlers(ghttp.CombineHandlers(
+				ghttp.VerifyRequest(http.MethodGet, "/v3/droplets/some-droplet-guid"),
+				ghttp.VerifyHeaderKV("Authorization", "Bearer valid-token"),
+				ghttp.VerifyHeaderKV("Accept", "application/json"),
+				ghttp.RespondWith(http.StatusOK, `{"image": "registry.example.org/some-app@sha256:abc"}`),
+			))
 
-				w.WriteHeader(http.StatusOK)
-			}))
+			var droplet model.Droplet
+			Expect(restClient.Get(context.Background(), "/v3/droplets/some-droplet-guid", &droplet)).To(Succeed())
+			Expect(droplet).To(Equal(model.Droplet{Image: "registry.example.org/some-app@sha256:abc"}))
+		})
 
-			restClient = RestClient{
-				testServer.Client(),
-			}
+		It("tags each request with a request ID", func() {
+			fakeCFAPIServer.AppendHandlers(
+				ghttp.RespondWith(http.StatusOK, `{}`),
+				ghttp.RespondWith(http.StatusOK, `{}`),
+			)
+
+			Expect(restClient.Get(context.Background(), "/v3/info", nil)).To(Succeed())
+			Expect(restClient.Get(context.Background(), "/v3/info", nil)).To(Succeed())
+
+			first := fakeCFAPIServer.ReceivedRequests()[0].Header.Get(RequestIDHeader)
+			second := fakeCFAPIServer.ReceivedRequests()[1].Header.Get(RequestIDHeader)
+			Expect(first).NotTo(BeEmpty())
+			Expect(second).NotTo(BeEmpty())
+			Expect(first).NotTo(Equal(second))
+		})
+
+		It("counts the request by endpoint and status code", func() {
+			fakeCFAPIServer.AppendHandlers(ghttp.RespondWith(http.StatusOK, `{}`))
+			counter := metrics.CFAPIRequests.WithLabelValues("/v3/routes/:guid/destinations", http.MethodGet, "200")
+			before := testutil.ToFloat64(counter)
+
+			Expect(restClient.Get(context.Background(), "/v3/routes/some-route-guid/destinations?per_page=10", nil)).To(Succeed())
+
+			Expect(testutil.ToFloat64(counter)).To(Equal(before + 1))
 		})
 
-		AfterEach(func() {
-			testServer.Close()
+		When("the response is not JSON", func() {
+			BeforeEach(func() {
+				fakeCFAPIServer.AppendHandlers(ghttp.RespondWith(http.StatusOK, `<html></html>`))
+			})
+
+			It("returns a meaningful error", func() {
+				var droplet model.Droplet
+				err := restClient.Get(context.Background(), "/v3/droplets/some-droplet-guid", &droplet)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(HavePrefix("failed to deserialize response from CF API: "))
+			})
 		})
 
-		When("request is valid", func() {
-			It("receives a 200 OK response from CF API", func() {
-				response, err := restClient.Patch(testServer.URL, authToken, body)
-				Expect(err).NotTo(HaveOccurred())
-				Expect(http.StatusOK).To(Equal(response.StatusCode))
+		When("CC responds with an error envelope", func() {
+			BeforeEach(func() {
+				fakeCFAPIServer.AppendHandlers(ghttp.RespondWith(
+					http.StatusNotFound,
+					`{"errors": [{"code": 10010, "title": "CF-ResourceNotFound", "detail": "Droplet not found"}]}`,
+					http.Header{RequestIDHeader: []string{"some-request-id::cc-suffix"}},
+				))
 			})
+
+			It("returns an APIError with CC's details and request ID", func() {
+				err := restClient.Get(context.Background(), "/v3/droplets/some-droplet-guid", nil)
+				Expect(err).To(MatchError("received status 404: CF-ResourceNotFound: Droplet not found"))
+				Expect(errors.Is(err, ErrNotFound)).To(BeTrue())
+
+				var apiErr *APIError
+				Expect(errors.As(err, &apiErr)).To(BeTrue())
+				Expect(apiErr.Errors).To(Equal([]model.Error{{Code: 10010, Title: "CF-ResourceNotFound", Detail: "Droplet not found"}}))
+				Expect(apiErr.RequestID).To(Equal("some-request-id::cc-suffix"))
+			})
+
+			It("keeps the token", func() {
+				_ = restClient.Get(context.Background(), "/v3/droplets/some-droplet-guid", nil)
+				Expect(tokenFetcher.InvalidateCallCount()).To(Equal(0))
+			})
+		})
+
+		When("CC rejects the token", func() {
+			BeforeEach(func() {
+				fakeCFAPIServer.AppendHandlers(ghttp.RespondWith(
+					http.StatusUnauthorized,
+					`{"errors": [{"code": 1000, "title": "CF-InvalidAuthToken", "detail": "Invalid Auth Token"}]}`,
+				))
+			})
+
+			It("invalidates the token and returns the APIError", func() {
+				err := restClient.Get(context.Background(), "/v3/droplets/some-droplet-guid", nil)
+				Expect(err).To(MatchError("received status 401: CF-InvalidAuthToken: Invalid Auth Token"))
+				Expect(tokenFetcher.InvalidateCallCount()).To(Equal(1))
+			})
+		})
+
+		When("CC responds with an error without an envelope", func() {
+			BeforeEach(func() {
+				fakeCFAPIServer.AppendHandlers(ghttp.RespondWith(http.StatusBadGateway, `bad gateway`))
+			})
+
+			It("returns an APIError with the request ID it sent", func() {
+				err := restClient.Get(context.Background(), "/v3/droplets/some-droplet-guid", nil)
+				Expect(err).To(MatchError("received status 502"))
+
+				var apiErr *APIError
+				Expect(errors.As(err, &apiErr)).To(BeTrue())
+				Expect(apiErr.RequestID).To(Equal(fakeCFAPIServer.ReceivedRequests()[0].Header.Get(RequestIDHeader)))
+			})
+		})
+
+		When("fetching a token fails", func() {
+			BeforeEach(func() {
+				tokenFetcher.FetchReturns("", errors.New("uaa is down"))
+			})
+
+			It("does not send the request", func() {
+				err := restClient.Get(context.Background(), "/v3/droplets/some-droplet-guid", nil)
+				Expect(err).To(MatchError("failed to fetch UAA token: uaa is down"))
+				Expect(fakeCFAPIServer.ReceivedRequests()).To(BeEmpty())
+			})
+		})
+	})
+
+	Describe("Post", func() {
+		It("sends the body as JSON and decodes the response", func() {
+			fakeCFAPIServer.AppendHandlers(ghttp.CombineHandlers(
+				ghttp.VerifyRequest(http.MethodPost, "/v3/builds"),
+				ghttp.VerifyHeaderKV("Authorization", "Bearer valid-token"),
+				ghttp.VerifyContentType("application/json"),
+				ghttp.VerifyJSON(`{"state": "STAGING"}`),
+				ghttp.RespondWith(http.StatusCreated, `{"state": "STAGED"}`),
+			))
+
+			var build map[string]string
+			Expect(restClient.Post(context.Background(), "/v3/builds", map[string]string{"state": "STAGING"}, &build)).To(Succeed())
+			Expect(build).To(Equal(map[string]string{"state": "STAGED"}))
+		})
+	})
+
+	Describe("Patch", func() {
+		It("sends the body as JSON", func() {
+			fakeCFAPIServer.AppendHandlers(ghttp.CombineHandlers(
+				ghttp.VerifyRequest(http.MethodPatch, "/v3/builds/some-build-guid"),
+				ghttp.VerifyHeaderKV("Authorization", "Bearer valid-token"),
+				ghttp.VerifyContentType("application/json"),
+				ghttp.VerifyJSON(`{"state": "STAGED"}`),
+				ghttp.RespondWith(http.StatusOK, `{}`),
+			))
+
+			Expect(restClient.Patch(context.Background(), "/v3/builds/some-build-guid", map[string]string{"state": "STAGED"}, nil)).To(Succeed())
+		})
+
+		It("counts a failed request with an error code", func() {
+			fakeCFAPIServer.Close()
+			counter := metrics.CFAPIRequests.WithLabelValues("/v3/builds/:guid", http.MethodPatch, "error")
+			before := testutil.ToFloat64(counter)
+
+			Expect(restClient.Patch(context.Background(), "/v3/builds/some-build-guid", model.Build{}, nil)).NotTo(Succeed())
+
+			Expect(testutil.ToFloat64(counter)).To(Equal(before + 1))
+		})
+	})
+
+	Describe("Delete", func() {
+		It("sends the request without a body", func() {
+			fakeCFAPIServer.AppendHandlers(ghttp.CombineHandlers(
+				ghttp.VerifyRequest(http.MethodDelete, "/v3/routes/some-route-guid"),
+				ghttp.VerifyHeaderKV("Authorization", "Bearer valid-token"),
+				ghttp.RespondWith(http.StatusAccepted, nil),
+			))
+
+			Expect(restClient.Delete(context.Background(), "/v3/routes/some-route-guid")).To(Succeed())
+			Expect(fakeCFAPIServer.ReceivedRequests()[0].ContentLength).To(BeZero())
+		})
+	})
+})
+
+var _ = Describe("EachPage", func() {
+	var (
+		rest  *cffakes.FakeRest
+		pages map[string]string
+	)
+
+	BeforeEach(func() {
+		rest = new(cffakes.FakeRest)
+		pages = map[string]string{
+			"/v3/routes":        `{"pagination": {"next": {"href": "https://api.example.com/v3/routes?page=2"}}, "resources": [1]}`,
+			"/v3/routes?page=2": `{"pagination": {"next": null}, "resources": [2]}`,
+		}
+		rest.GetCalls(func(_ context.Context, path string, result interface{}) error {
+			page, ok := pages[path]
+			if !ok {
+				return &APIError{StatusCode: http.StatusServiceUnavailable}
+			}
+			return json.Unmarshal([]byte(page), result)
 		})
+	})
+
+	visitResources := func(visited *[]int) func(json.RawMessage) error {
+		return func(raw json.RawMessage) error {
+			var page struct {
+				Resources []int `json:"resources"`
+			}
+			if err := json.Unmarshal(raw, &page); err != nil {
+				return err
+			}
+			*visited = append(*visited, page.Resources...)
+			return nil
+		}
+	}
+
+	It("follows the next links by their path and query", func() {
+		var visited []int
+		pagesVisited, err := EachPage(context.Background(), rest, "/v3/routes", visitResources(&visited))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(pagesVisited).To(Equal(2))
+		Expect(visited).To(Equal([]int{1, 2}))
+	})
 
+	When("the first page fails", func() {
+		BeforeEach(func() {
+			delete(pages, "/v3/routes")
+		})
+
+		It("returns the error as is", func() {
+			_, err := EachPage(context.Background(), rest, "/v3/routes", visitResources(new([]int)))
+			Expect(err).To(MatchError("received status 503"))
+		})
+	})
+
+	When("a later page fails", func() {
+		BeforeEach(func() {
+			delete(pages, "/v3/routes?page=2")
+		})
+
+		It("returns an IncompleteListError", func() {
+			var visited []int
+			pagesVisited, err := EachPage(context.Background(), rest, "/v3/routes", visitResources(&visited))
+			Expect(pagesVisited).To(Equal(1))
+			Expect(visited).To(Equal([]int{1}))
+
+			var incompleteListErr *IncompleteListError
+			Expect(errors.As(err, &incompleteListErr)).To(BeTrue())
+			Expect(incompleteListErr.PagesFetched).To(Equal(1))
+			Expect(errors.Is(err, ErrUnavailable)).To(BeTrue())
+		})
+	})
+
+	When("a next link leads back to a page already visited", func() {
+		BeforeEach(func() {
+			pages["/v3/routes?page=2"] = `{"pagination": {"next": {"href": "https://api.example.com/v3/routes"}}}`
+		})
+
+		It("stops with an IncompleteListError", func() {
+			_, err := EachPage(context.Background(), rest, "/v3/routes", visitResources(new([]int)))
+			Expect(err).To(MatchError("incomplete list after 2 page(s): pagination loop detected at /v3/routes"))
+		})
+	})
+
+	When("visiting a page fails", func() {
+		It("stops with the error", func() {
+			_, err := EachPage(context.Background(), rest, "/v3/routes", func(json.RawMessage) error {
+				return errors.New("unexpected page")
+			})
+			Expect(err).To(MatchError("unexpected page"))
+			Expect(rest.GetCallCount()).To(Equal(1))
+		})
 	})
 })
@@ -0,0 +1,62 @@
+package cf
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// TLSConfig configures the TLS connections to the CF API and UAA. The
+// servers' certificates are verified unless InsecureSkipVerify is set.
+type TLSConfig struct {
+	// CACertificate is a PEM bundle of CAs trusted on top of the system's
+	CACertificate []byte
+	// ClientCertificate and ClientKey are the PEM encoded certificate and key
+	// presented to the servers for mutual TLS, if set
+	ClientCertificate []byte
+	ClientKey         []byte
+	// InsecureSkipVerify turns off verification of the servers' certificates
+	InsecureSkipVerify bool
+}
+
+// NewTLSConfig builds the crypto/tls config of the connections
+func NewTLSConfig(config TLSConfig) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: config.InsecureSkipVerify,
+	}
+
+	if len(config.CACertificate) > 0 {
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(config.CACertificate) {
+			return nil, errors.New("CA certificate contains no PEM encoded certificate")
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if len(config.ClientCertificate) > 0 || len(config.ClientKey) > 0 {
+		certificate, err := tls.X509KeyPair(config.ClientCertificate, config.ClientKey)
+		if err != nil {
+			return nil, fmt.Errorf("invalid client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{certificate}
+	}
+
+	return tlsConfig, nil
+}
+
+// NewHTTPClient creates an HTTP client connecting according to config
+func NewHTTPClient(config TLSConfig) (*http.Client, error) {
+	tlsConfig, err := NewTLSConfig(config)
+	if err != nil {
+		return nil, err
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+	return &http.Client{Transport: transport}, nil
+}
@@ -0,0 +1,134 @@
+package cf_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	. "code.cloudfoundry.org/capi-k8s-release/src/cf-api-controllers/cf"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("NewHTTPClient", func() {
+	var (
+		server   *httptest.Server
+		serverCA []byte
+	)
+
+	BeforeEach(func() {
+		server = httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		}))
+	})
+
+	JustBeforeEach(func() {
+		server.StartTLS()
+		serverCA = pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	get := func(config TLSConfig) error {
+		httpClient, err := NewHTTPClient(config)
+		Expect(err).NotTo(HaveOccurred())
+
+		response, err := httpClient.Get(server.URL)
+		if err != nil {
+			return err
+		}
+		defer response.Body.Close()
+		Expect(response.StatusCode).To(Equal(http.StatusNoContent))
+		return nil
+	}
+
+	It("verifies the server's certificate by default", func() {
+		err := get(TLSConfig{})
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("certificate"))
+	})
+
+	It("trusts the given CA", func() {
+		Expect(get(TLSConfig{CACertificate: serverCA})).To(Succeed())
+	})
+
+	It("skips verification when opted in", func() {
+		Expect(get(TLSConfig{InsecureSkipVerify: true})).To(Succeed())
+	})
+
+	When("the server requires a client certificate", func() {
+		var clientCertificate, clientKey []byte
+
+		BeforeEach(func() {
+			clientCertificate, clientKey = generateCertificate("cf-api-controllers")
+
+			clientCAs := x509.NewCertPool()
+			Expect(clientCAs.AppendCertsFromPEM(clientCertificate)).To(BeTrue())
+			server.TLS = &tls.Config{
+				ClientAuth: tls.RequireAndVerifyClientCert,
+				ClientCAs:  clientCAs,
+			}
+		})
+
+		It("presents the given certificate", func() {
+			Expect(get(TLSConfig{
+				CACertificate:     serverCA,
+				ClientCertificate: clientCertificate,
+				ClientKey:         clientKey,
+			})).To(Succeed())
+		})
+
+		It("is refused without one", func() {
+			Expect(get(TLSConfig{CACertificate: serverCA})).NotTo(Succeed())
+		})
+	})
+
+	It("returns an error for a CA without certificates", func() {
+		_, err := NewHTTPClient(TLSConfig{CACertificate: []byte("not a certificate")})
+		Expect(err).To(MatchError("CA certificate contains no PEM encoded certificate"))
+	})
+
+	It("returns an error for a client certificate without its key", func() {
+		clientCertificate, _ := generateCertificate("cf-api-controllers")
+		_, err := NewHTTPClient(TLSConfig{ClientCertificate: clientCertificate})
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(HavePrefix("invalid client certificate: "))
+	})
+})
+
+// generateCertificate creates a self-signed client certificate and its key,
+// PEM encoded
+func generateCertificate(commonName string) ([]byte, []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	Expect(err).NotTo(HaveOccurred())
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: commonName},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	Expect(err).NotTo(HaveOccurred())
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	Expect(err).NotTo(HaveOccurred())
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
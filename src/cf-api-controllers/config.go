@@ -5,6 +5,15 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/types"
+
+	"code.cloudfoundry.org/capi-k8s-release/src/cf-api-controllers/cf"
+	"code.cloudfoundry.org/capi-k8s-release/src/cf-api-controllers/controllers"
+	"code.cloudfoundry.org/capi-k8s-release/src/cf-api-controllers/image_registry"
 )
 
 type Config struct {
@@ -13,33 +22,85 @@ type Config struct {
 	uaaClientName      string
 	uaaClientSecret    string
 	workloadsNamespace string
+	rebaseTarget       string
+	stackRebaseRollout *types.NamespacedName
+	readinessWindow    time.Duration
+	controllersConfig  string
+	tls                cf.TLSConfig
+	cfAPITimeout       time.Duration
+	registryTimeout    time.Duration
 }
 
 func LoadConfig() (*Config, error) {
 	c := &Config{}
 
-	if c.cfAPIHost = os.Getenv("CF_API_HOST"); c.cfAPIHost == "" {
+	// the CFAPIControllersConfig provides the CF API and UAA settings, which
+	// are then only needed from the environment as a fallback
+	c.controllersConfig = os.Getenv("CONTROLLERS_CONFIG")
+	required := c.controllersConfig == ""
+
+	if c.cfAPIHost = os.Getenv("CF_API_HOST"); c.cfAPIHost == "" && required {
 		return nil, envNotSetErr("CF_API_HOST")
 	}
 
-	if c.uaaEndpoint = os.Getenv("UAA_ENDPOINT"); c.uaaEndpoint == "" {
+	if c.uaaEndpoint = os.Getenv("UAA_ENDPOINT"); c.uaaEndpoint == "" && required {
 		return nil, envNotSetErr("UAA_ENDPOINT")
 	}
 
-	if c.uaaClientName = os.Getenv("UAA_CLIENT_NAME"); c.uaaClientName == "" {
+	if c.uaaClientName = os.Getenv("UAA_CLIENT_NAME"); c.uaaClientName == "" && required {
 		return nil, envNotSetErr("UAA_CLIENT_NAME")
 	}
 
-	if c.workloadsNamespace = os.Getenv("WORKLOADS_NAMESPACE"); c.workloadsNamespace == "" {
+	if c.workloadsNamespace = os.Getenv("WORKLOADS_NAMESPACE"); c.workloadsNamespace == "" && required {
 		return nil, envNotSetErr("WORKLOADS_NAMESPACE")
 	}
 
+	switch c.rebaseTarget = os.Getenv("REBASE_TARGET"); c.rebaseTarget {
+	case "":
+		c.rebaseTarget = controllers.StatefulSetRebaseTarget
+	case controllers.StatefulSetRebaseTarget, controllers.LRPRebaseTarget:
+	default:
+		return nil, fmt.Errorf(
+			"`REBASE_TARGET` environment variable must be one of %q or %q, got %q",
+			controllers.StatefulSetRebaseTarget, controllers.LRPRebaseTarget, c.rebaseTarget,
+		)
+	}
+
+	if rollout := os.Getenv("STACK_REBASE_ROLLOUT"); rollout != "" {
+		parts := strings.Split(rollout, "/")
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return nil, fmt.Errorf("`STACK_REBASE_ROLLOUT` environment variable must be of the form namespace/name, got %q", rollout)
+		}
+		c.stackRebaseRollout = &types.NamespacedName{Namespace: parts[0], Name: parts[1]}
+	}
+
+	if window := os.Getenv("REBASE_READINESS_WINDOW"); window != "" {
+		var err error
+		if c.readinessWindow, err = time.ParseDuration(window); err != nil || c.readinessWindow < 0 {
+			return nil, fmt.Errorf("`REBASE_READINESS_WINDOW` environment variable must be a non-negative duration, got %q", window)
+		}
+	}
+
+	if err := c.loadTLSConfig(); err != nil {
+		return nil, err
+	}
+
 	var err error
-	c.uaaClientSecret, err = c.fetchUaaClientSecret()
-	if err != nil {
+	if c.cfAPITimeout, err = timeoutFromEnv("CF_API_TIMEOUT", cf.DefaultTimeout); err != nil {
+		return nil, err
+	}
+	if c.registryTimeout, err = timeoutFromEnv("IMAGE_REGISTRY_TIMEOUT", image_registry.DefaultTimeout); err != nil {
 		return nil, err
 	}
 
+	if required || os.Getenv("UAA_CLIENT_SECRET_FILE") != "" || os.Getenv("UAA_CLIENT_SECRET") != "" {
+		var err error
+		c.uaaClientSecret, err = c.fetchUaaClientSecret()
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	return c, nil
 }
 
@@ -63,6 +124,104 @@ func (c *Config) WorkloadsNamespace() string {
 	return c.workloadsNamespace
 }
 
+// RebaseTarget is the kind of workload stack rebases are rolled out to:
+// "statefulset" (the default) or Eirini's "lrp"
+func (c *Config) RebaseTarget() string {
+	return c.rebaseTarget
+}
+
+// StackRebaseRollout is the StackRebaseRollout that paces stack rebases, if
+// one was configured
+func (c *Config) StackRebaseRollout() (types.NamespacedName, bool) {
+	if c.stackRebaseRollout == nil {
+		return types.NamespacedName{}, false
+	}
+	return *c.stackRebaseRollout, true
+}
+
+// RebaseReadinessWindow is how long a rebased app has to become ready before
+// the rebase is rolled back, zero when rebases aren't checked
+func (c *Config) RebaseReadinessWindow() time.Duration {
+	return c.readinessWindow
+}
+
+// ControllersConfig is the name of the CFAPIControllersConfig the controllers
+// are configured by, if one was set
+func (c *Config) ControllersConfig() (string, bool) {
+	return c.controllersConfig, c.controllersConfig != ""
+}
+
+// Settings are the controllers' settings from the environment, which are only
+// complete when a CFAPIControllersConfig isn't required to fill them in
+func (c *Config) Settings() (controllers.ControllersSettings, bool) {
+	settings := controllers.ControllersSettings{
+		CFAPIHost:            c.cfAPIHost,
+		UAAEndpoint:          c.uaaEndpoint,
+		UAAClientName:        c.uaaClientName,
+		UAAClientSecret:      c.uaaClientSecret,
+		WorkloadsNamespace:   c.workloadsNamespace,
+		TLS:                  c.tls,
+		CFAPITimeout:         c.cfAPITimeout,
+		ImageRegistryTimeout: c.registryTimeout,
+	}
+	complete := c.cfAPIHost != "" && c.uaaEndpoint != "" && c.uaaClientName != "" &&
+		c.uaaClientSecret != "" && c.workloadsNamespace != ""
+	return settings, complete
+}
+
+// timeoutFromEnv parses the positive duration of a timeout from the env var,
+// if it is set
+func timeoutFromEnv(env string, defaultTimeout time.Duration) (time.Duration, error) {
+	value := os.Getenv(env)
+	if value == "" {
+		return defaultTimeout, nil
+	}
+	timeout, err := time.ParseDuration(value)
+	if err != nil || timeout <= 0 {
+		return 0, fmt.Errorf("`%s` environment variable must be a positive duration, got %q", env, value)
+	}
+	return timeout, nil
+}
+
+// TLS configures the connections to the CF API and UAA
+func (c *Config) TLS() cf.TLSConfig {
+	return c.tls
+}
+
+// loadTLSConfig reads the CA bundle and client certificate files, and whether
+// the servers' certificates are verified at all
+func (c *Config) loadTLSConfig() error {
+	var err error
+	if caFile := os.Getenv("TLS_CA_CERT_FILE"); caFile != "" {
+		if c.tls.CACertificate, err = ioutil.ReadFile(caFile); err != nil {
+			return err
+		}
+	}
+
+	certFile, keyFile := os.Getenv("TLS_CLIENT_CERT_FILE"), os.Getenv("TLS_CLIENT_KEY_FILE")
+	if (certFile == "") != (keyFile == "") {
+		return errors.New("`TLS_CLIENT_CERT_FILE` and `TLS_CLIENT_KEY_FILE` environment variables must be set together")
+	}
+	if certFile != "" {
+		if c.tls.ClientCertificate, err = ioutil.ReadFile(certFile); err != nil {
+			return err
+		}
+		if c.tls.ClientKey, err = ioutil.ReadFile(keyFile); err != nil {
+			return err
+		}
+	}
+
+	if insecure := os.Getenv("TLS_INSECURE_SKIP_VERIFY"); insecure != "" {
+		if c.tls.InsecureSkipVerify, err = strconv.ParseBool(insecure); err != nil {
+			return fmt.Errorf("`TLS_INSECURE_SKIP_VERIFY` environment variable must be a boolean, got %q", insecure)
+		}
+	}
+
+	// fail at startup rather than on the first request
+	_, err = cf.NewTLSConfig(c.tls)
+	return err
+}
+
 func (c *Config) fetchUaaClientSecret() (string, error) {
 	secretFile := os.Getenv("UAA_CLIENT_SECRET_FILE")
 	if secretFile == "" {
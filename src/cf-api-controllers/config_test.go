@@ -1,11 +1,19 @@
 package main_test
 
 import (
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+
 	"code.cloudfoundry.org/capi-k8s-release/src/cf-api-controllers"
+	"code.cloudfoundry.org/capi-k8s-release/src/cf-api-controllers/cf"
+	"code.cloudfoundry.org/capi-k8s-release/src/cf-api-controllers/image_registry"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 	"io/ioutil"
+	"k8s.io/apimachinery/pkg/types"
 	"os"
+	"time"
 )
 
 var _ = Describe("Config", func() {
@@ -41,6 +49,251 @@ var _ = Describe("Config", func() {
 			Expect(config.UAAClientName()).To(Equal(expectedUAAClientName))
 			Expect(config.UAAClientSecret()).To(Equal(expectedUAAClientSecretFromEnv))
 			Expect(config.WorkloadsNamespace()).To(Equal(expectedWorkloadsNamespace))
+			Expect(config.RebaseTarget()).To(Equal("statefulset"))
+		})
+
+		Context("when the REBASE_TARGET env var is set", func() {
+			AfterEach(func() {
+				err := os.Unsetenv("REBASE_TARGET")
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("loads the rebase target", func() {
+				err := os.Setenv("REBASE_TARGET", "lrp")
+				Expect(err).NotTo(HaveOccurred())
+
+				config, err := main.LoadConfig()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(config.RebaseTarget()).To(Equal("lrp"))
+			})
+
+			It("returns an error for an unknown target", func() {
+				err := os.Setenv("REBASE_TARGET", "deployment")
+				Expect(err).NotTo(HaveOccurred())
+
+				_, err = main.LoadConfig()
+				Expect(err).To(MatchError("`REBASE_TARGET` environment variable must be one of \"statefulset\" or \"lrp\", got \"deployment\""))
+			})
+		})
+
+		It("does not configure a stack rebase rollout by default", func() {
+			config, err := main.LoadConfig()
+			Expect(err).NotTo(HaveOccurred())
+			_, ok := config.StackRebaseRollout()
+			Expect(ok).To(BeFalse())
+		})
+
+		Context("when the STACK_REBASE_ROLLOUT env var is set", func() {
+			AfterEach(func() {
+				err := os.Unsetenv("STACK_REBASE_ROLLOUT")
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("loads the rollout's namespace and name", func() {
+				err := os.Setenv("STACK_REBASE_ROLLOUT", "cf-system/some-rollout")
+				Expect(err).NotTo(HaveOccurred())
+
+				config, err := main.LoadConfig()
+				Expect(err).NotTo(HaveOccurred())
+				rollout, ok := config.StackRebaseRollout()
+				Expect(ok).To(BeTrue())
+				Expect(rollout).To(Equal(types.NamespacedName{Namespace: "cf-system", Name: "some-rollout"}))
+			})
+
+			It("returns an error when it is not namespaced", func() {
+				err := os.Setenv("STACK_REBASE_ROLLOUT", "some-rollout")
+				Expect(err).NotTo(HaveOccurred())
+
+				_, err = main.LoadConfig()
+				Expect(err).To(MatchError("`STACK_REBASE_ROLLOUT` environment variable must be of the form namespace/name, got \"some-rollout\""))
+			})
+		})
+
+		It("does not check rebased apps for readiness by default", func() {
+			config, err := main.LoadConfig()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(config.RebaseReadinessWindow()).To(BeZero())
+		})
+
+		Context("when the REBASE_READINESS_WINDOW env var is set", func() {
+			AfterEach(func() {
+				err := os.Unsetenv("REBASE_READINESS_WINDOW")
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("loads the window", func() {
+				err := os.Setenv("REBASE_READINESS_WINDOW", "5m")
+				Expect(err).NotTo(HaveOccurred())
+
+				config, err := main.LoadConfig()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(config.RebaseReadinessWindow()).To(Equal(5 * time.Minute))
+			})
+
+			It("returns an error when it is not a duration", func() {
+				err := os.Setenv("REBASE_READINESS_WINDOW", "soon")
+				Expect(err).NotTo(HaveOccurred())
+
+				_, err = main.LoadConfig()
+				Expect(err).To(MatchError("`REBASE_READINESS_WINDOW` environment variable must be a non-negative duration, got \"soon\""))
+			})
+		})
+
+		It("bounds CF API and image registry requests by default", func() {
+			config, err := main.LoadConfig()
+			Expect(err).NotTo(HaveOccurred())
+
+			settings, _ := config.Settings()
+			Expect(settings.CFAPITimeout).To(Equal(cf.DefaultTimeout))
+			Expect(settings.ImageRegistryTimeout).To(Equal(image_registry.DefaultTimeout))
+		})
+
+		Context("when the timeout env vars are set", func() {
+			AfterEach(func() {
+				Expect(os.Unsetenv("CF_API_TIMEOUT")).To(Succeed())
+				Expect(os.Unsetenv("IMAGE_REGISTRY_TIMEOUT")).To(Succeed())
+			})
+
+			It("loads the timeouts", func() {
+				Expect(os.Setenv("CF_API_TIMEOUT", "10s")).To(Succeed())
+				Expect(os.Setenv("IMAGE_REGISTRY_TIMEOUT", "2m")).To(Succeed())
+
+				config, err := main.LoadConfig()
+				Expect(err).NotTo(HaveOccurred())
+
+				settings, _ := config.Settings()
+				Expect(settings.CFAPITimeout).To(Equal(10 * time.Second))
+				Expect(settings.ImageRegistryTimeout).To(Equal(2 * time.Minute))
+			})
+
+			It("returns an error when a timeout is not positive", func() {
+				Expect(os.Setenv("CF_API_TIMEOUT", "0s")).To(Succeed())
+
+				_, err := main.LoadConfig()
+				Expect(err).To(MatchError("`CF_API_TIMEOUT` environment variable must be a positive duration, got \"0s\""))
+			})
+		})
+
+		It("is not configured by a CFAPIControllersConfig by default", func() {
+			config, err := main.LoadConfig()
+			Expect(err).NotTo(HaveOccurred())
+			_, ok := config.ControllersConfig()
+			Expect(ok).To(BeFalse())
+
+			settings, complete := config.Settings()
+			Expect(complete).To(BeTrue())
+			Expect(settings.CFAPIHost).To(Equal(expectedCFAPIHost))
+			Expect(settings.WorkloadsNamespace).To(Equal(expectedWorkloadsNamespace))
+		})
+
+		Context("when the CONTROLLERS_CONFIG env var is set", func() {
+			BeforeEach(func() {
+				err := os.Setenv("CONTROLLERS_CONFIG", "cf-api-controllers")
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			AfterEach(func() {
+				err := os.Unsetenv("CONTROLLERS_CONFIG")
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("loads the config's name", func() {
+				config, err := main.LoadConfig()
+				Expect(err).NotTo(HaveOccurred())
+				name, ok := config.ControllersConfig()
+				Expect(ok).To(BeTrue())
+				Expect(name).To(Equal("cf-api-controllers"))
+			})
+
+			It("does not require the CF API and UAA env vars", func() {
+				for _, env := range []string{"CF_API_HOST", "UAA_ENDPOINT", "UAA_CLIENT_NAME", "UAA_CLIENT_SECRET", "WORKLOADS_NAMESPACE"} {
+					err := os.Unsetenv(env)
+					Expect(err).NotTo(HaveOccurred())
+				}
+
+				config, err := main.LoadConfig()
+				Expect(err).NotTo(HaveOccurred())
+				_, complete := config.Settings()
+				Expect(complete).To(BeFalse())
+			})
+		})
+
+		It("verifies the CF API and UAA certificates by default", func() {
+			config, err := main.LoadConfig()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(config.TLS()).To(Equal(cf.TLSConfig{}))
+
+			settings, _ := config.Settings()
+			Expect(settings.TLS).To(Equal(cf.TLSConfig{}))
+		})
+
+		Context("when the TLS env vars are set", func() {
+			var caFile *os.File
+
+			BeforeEach(func() {
+				server := httptest.NewTLSServer(http.NotFoundHandler())
+				defer server.Close()
+
+				var err error
+				caFile, err = ioutil.TempFile("", "fake-ca-cert")
+				Expect(err).NotTo(HaveOccurred())
+				err = pem.Encode(caFile, &pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			AfterEach(func() {
+				for _, env := range []string{"TLS_CA_CERT_FILE", "TLS_CLIENT_CERT_FILE", "TLS_CLIENT_KEY_FILE", "TLS_INSECURE_SKIP_VERIFY"} {
+					err := os.Unsetenv(env)
+					Expect(err).NotTo(HaveOccurred())
+				}
+				err := os.Remove(caFile.Name())
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("loads the CA bundle", func() {
+				err := os.Setenv("TLS_CA_CERT_FILE", caFile.Name())
+				Expect(err).NotTo(HaveOccurred())
+
+				config, err := main.LoadConfig()
+				Expect(err).NotTo(HaveOccurred())
+				expectedCA, err := ioutil.ReadFile(caFile.Name())
+				Expect(err).NotTo(HaveOccurred())
+				Expect(config.TLS().CACertificate).To(Equal(expectedCA))
+				Expect(config.TLS().InsecureSkipVerify).To(BeFalse())
+			})
+
+			It("returns an error when the CA bundle has no certificate", func() {
+				err := os.Setenv("TLS_CA_CERT_FILE", "config_test.go")
+				Expect(err).NotTo(HaveOccurred())
+
+				_, err = main.LoadConfig()
+				Expect(err).To(MatchError("CA certificate contains no PEM encoded certificate"))
+			})
+
+			It("returns an error when only the client certificate is set", func() {
+				err := os.Setenv("TLS_CLIENT_CERT_FILE", caFile.Name())
+				Expect(err).NotTo(HaveOccurred())
+
+				_, err = main.LoadConfig()
+				Expect(err).To(MatchError("`TLS_CLIENT_CERT_FILE` and `TLS_CLIENT_KEY_FILE` environment variables must be set together"))
+			})
+
+			It("skips verification only when opted in", func() {
+				err := os.Setenv("TLS_INSECURE_SKIP_VERIFY", "true")
+				Expect(err).NotTo(HaveOccurred())
+
+				config, err := main.LoadConfig()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(config.TLS().InsecureSkipVerify).To(BeTrue())
+			})
+
+			It("returns an error when the opt-in is not a boolean", func() {
+				err := os.Setenv("TLS_INSECURE_SKIP_VERIFY", "sure")
+				Expect(err).NotTo(HaveOccurred())
+
+				_, err = main.LoadConfig()
+				Expect(err).To(MatchError("`TLS_INSECURE_SKIP_VERIFY` environment variable must be a boolean, got \"sure\""))
+			})
 		})
 
 		Context("when the CF_API_HOST env var is not set", func() {
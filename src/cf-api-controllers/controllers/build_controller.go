@@ -21,21 +21,27 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"strconv"
 	"strings"
+	"time"
 
+	"code.cloudfoundry.org/capi-k8s-release/src/cf-api-controllers/cf"
 	"code.cloudfoundry.org/capi-k8s-release/src/cf-api-controllers/cf/model"
 	"code.cloudfoundry.org/capi-k8s-release/src/cf-api-controllers/image_registry"
+	"code.cloudfoundry.org/capi-k8s-release/src/cf-api-controllers/metrics"
 	"github.com/buildpacks/lifecycle"
 	"github.com/buildpacks/lifecycle/launch"
 	"github.com/go-logr/logr"
 	buildv1alpha1 "github.com/pivotal/kpack/pkg/apis/build/v1alpha1"
 	corev1alpha1 "github.com/pivotal/kpack/pkg/apis/core/v1alpha1"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 )
@@ -44,11 +50,21 @@ const BuildGUIDLabel = "cloudfoundry.org/build_guid"
 const BuildReasonAnnotation = "image.kpack.io/reason"
 const StackUpdateBuildReason = "STACK"
 
+// BuildUpdateAttemptsAnnotation counts the failed attempts to update the CC
+// build of a kpack Build
+const BuildUpdateAttemptsAnnotation = "cloudfoundry.org/build_update_attempts"
+
+const (
+	DefaultMaxBuildUpdateAttempts = 10
+	DefaultBuildUpdateBaseDelay   = time.Second
+	DefaultBuildUpdateMaxDelay    = time.Minute
+)
+
 //go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 -o fake/controller_runtime_client.go --fake-name ControllerRuntimeClient sigs.k8s.io/controller-runtime/pkg/client.Client
 
 //go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 -o fake/cf_build_updater.go --fake-name CFBuildUpdater . CfBuildUpdater
 type CfBuildUpdater interface {
-	UpdateBuild(buildGUID string, build model.Build) error
+	UpdateBuild(ctx context.Context, buildGUID string, build model.Build) error
 }
 
 // BuildReconciler reconciles a Build object
@@ -58,13 +74,33 @@ type BuildReconciler struct {
 	Scheme   *runtime.Scheme
 	CFClient CfBuildUpdater
 	image_registry.ImageConfigFetcher
+
+	// MaxUpdateAttempts bounds the attempts to update the CC build, defaulting
+	// to DefaultMaxBuildUpdateAttempts
+	MaxUpdateAttempts int
+	// UpdateBaseDelay is the delay after the first failed attempt, doubling
+	// after each further one up to UpdateMaxDelay
+	UpdateBaseDelay time.Duration
+	UpdateMaxDelay  time.Duration
+
+	// LogFetcher, if set, is used to include the end of a failed step's output
+	// in the error reported to CC
+	LogFetcher BuildLogFetcher
+
+	Switch  *ControllerSwitch
+	Context context.Context
 }
 
 // +kubebuilder:rbac:groups=kpack.io,resources=builds,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=kpack.io,resources=builds/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups="",resources=pods/log,verbs=get
 
 func (r *BuildReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
-	ctx := context.Background()
+	if !r.Switch.Admit(req) {
+		return ctrl.Result{}, nil
+	}
+
+	ctx := reconcileContext(r.Context)
 
 	var build buildv1alpha1.Build
 	err := r.Get(ctx, req.NamespacedName, &build)
@@ -83,9 +119,14 @@ func (r *BuildReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 		"status", build.Status,
 	)
 
+	if attempts := buildUpdateAttempts(&build); attempts >= r.maxUpdateAttempts() {
+		logger.WithValues("attempts", attempts).Info("Giving up on build, CF API update attempts exhausted")
+		return ctrl.Result{}, nil
+	}
+
 	condition := build.Status.GetCondition(corev1alpha1.ConditionSucceeded)
 	if condition.IsTrue() {
-		return r.reconcileSuccessfulBuild(&build, logger)
+		return r.reconcileSuccessfulBuild(ctx, &build, logger)
 	}
 
 	failureMessage := fmt.Sprintf(
@@ -94,16 +135,24 @@ func (r *BuildReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 		condition.Message,
 	)
 
-	failedContainerState := findAnyFailedContainerState(build.Status.StepStates)
+	failedStep, failedContainerState := findFailedStep(build.Status)
 	if failedContainerState != nil {
+		step := "Step"
+		if failedStep != "" {
+			step = fmt.Sprintf("Step '%s'", failedStep)
+		}
 		failureMessage = fmt.Sprintf(
-			"Kpack build failed during container execution: Step failure reason: '%s', message: '%s'.",
+			"Kpack build failed during container execution: %s failure reason: '%s', message: '%s'.",
+			step,
 			failedContainerState.Terminated.Reason,
 			failedContainerState.Terminated.Message,
 		)
+		if logs := r.fetchStepLogs(&build, failedStep, logger); logs != "" {
+			failureMessage = fmt.Sprintf("%s Last lines of step output:\n%s", failureMessage, logs)
+		}
 	}
 
-	return r.reconcileFailedBuild(&build, failureMessage, logger)
+	return r.reconcileFailedBuild(ctx, &build, failureMessage, logger)
 }
 
 func (r *BuildReconciler) SetupWithManager(mgr ctrl.Manager) error {
@@ -118,11 +167,16 @@ func (r *BuildReconciler) SetupWithManager(mgr ctrl.Manager) error {
 			UpdateFunc: func(e event.UpdateEvent) bool {
 				r.Log.WithValues("requestLink", e.MetaNew.GetSelfLink()).
 					V(1).Info("Build update event received")
+				// recording an attempt must not cut the backoff short
+				if onlyMetadataChanged(e.ObjectOld, e.ObjectNew) {
+					return false
+				}
 				return r.buildFilter(e.ObjectNew)
 			},
 			DeleteFunc:  func(_ event.DeleteEvent) bool { return false },
 			GenericFunc: func(_ event.GenericEvent) bool { return false },
 		}).
+		Watches(r.Switch, new(handler.Funcs)).
 		Complete(r)
 }
 
@@ -161,22 +215,62 @@ func (r *BuildReconciler) buildFilter(e runtime.Object) bool {
 	return true
 }
 
-func (r *BuildReconciler) extractProcessTypes(build *buildv1alpha1.Build) (map[string]string, error) {
-	imageConfig, err := r.FetchImageConfig(build.Status.LatestImage, build.Spec.ServiceAccount, build.Namespace)
+// addImageMetadata adds what the lifecycle recorded in the built image to the
+// lifecycle data sent to CC: the process types, and the buildpacks and stack
+// where kpack's Build status doesn't already provide them
+func (r *BuildReconciler) addImageMetadata(ctx context.Context, build *buildv1alpha1.Build, data *model.LifecycleData) error {
+	imageConfig, err := r.FetchImageConfig(ctx, build.Status.LatestImage, build.Spec.ServiceAccount, build.Namespace)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
 	var buildMetadata lifecycle.BuildMetadata
 	if err = json.Unmarshal([]byte(imageConfig.Labels[lifecycle.BuildMetadataLabel]), &buildMetadata); err != nil {
-		return nil, err
+		return err
 	}
 
-	ret := make(map[string]string)
+	data.ProcessTypes = make(map[string]string)
 	for _, process := range buildMetadata.Processes {
-		ret[process.Type] = extractFullCommand(process)
+		data.ProcessTypes[process.Type] = extractFullCommand(process)
+	}
+
+	if len(buildMetadata.Buildpacks) > 0 {
+		data.Buildpacks = mergeBuildpacks(buildMetadata.Buildpacks, data.Buildpacks)
+	}
+
+	if data.Stack == "" {
+		data.Stack = imageConfig.Labels[lifecycle.StackIDLabel]
+	}
+
+	if data.RunImage == "" {
+		// the run image is informational, so an image without (valid) layers
+		// metadata does not fail the build
+		var layersMetadata lifecycle.LayersMetadataCompat
+		if err := json.Unmarshal([]byte(imageConfig.Labels[lifecycle.LayerMetadataLabel]), &layersMetadata); err == nil {
+			data.RunImage = layersMetadata.RunImage.Reference
+		}
+	}
+
+	return nil
+}
+
+// mergeBuildpacks returns the buildpacks that took part in the build, as
+// recorded in the image, with the homepages known to kpack
+func mergeBuildpacks(imageBuildpacks []lifecycle.Buildpack, kpackBuildpacks []model.Buildpack) []model.Buildpack {
+	homepages := make(map[string]string)
+	for _, buildpack := range kpackBuildpacks {
+		homepages[buildpack.ID] = buildpack.Homepage
+	}
+
+	buildpacks := make([]model.Buildpack, 0, len(imageBuildpacks))
+	for _, buildpack := range imageBuildpacks {
+		buildpacks = append(buildpacks, model.Buildpack{
+			ID:       buildpack.ID,
+			Version:  buildpack.Version,
+			Homepage: homepages[buildpack.ID],
+		})
 	}
-	return ret, nil
+	return buildpacks
 }
 
 func extractFullCommand(process launch.Process) string {
@@ -184,13 +278,15 @@ func extractFullCommand(process launch.Process) string {
 	return strings.Join(commandWithArgs, " ")
 }
 
-func (r *BuildReconciler) reconcileSuccessfulBuild(build *buildv1alpha1.Build, logger logr.Logger) (ctrl.Result, error) {
+func (r *BuildReconciler) reconcileSuccessfulBuild(ctx context.Context, build *buildv1alpha1.Build, logger logr.Logger) (ctrl.Result, error) {
 	logger.V(1).Info("Build completed successfully, marking as staged")
 
-	processTypes, err := r.extractProcessTypes(build)
+	updateBuildRequest := model.NewBuildFromKpackBuild(build)
+	err := r.addImageMetadata(ctx, build, &updateBuildRequest.Lifecycle.Data)
 	if err != nil {
 		logger.Error(err, "Failed to fetch image config")
 		return r.reconcileFailedBuild(
+			ctx,
 			build,
 			fmt.Sprintf(
 				"Failed to handle successful kpack build: %s",
@@ -200,39 +296,167 @@ func (r *BuildReconciler) reconcileSuccessfulBuild(build *buildv1alpha1.Build, l
 		)
 	}
 
-	updateBuildRequest := model.NewBuildFromKpackBuild(build)
-	updateBuildRequest.Lifecycle.Data.ProcessTypes = processTypes
-	err = r.CFClient.UpdateBuild(build.GetLabels()[BuildGUIDLabel], updateBuildRequest)
+	err = r.CFClient.UpdateBuild(ctx, build.GetLabels()[BuildGUIDLabel], updateBuildRequest)
 	if err != nil {
 		logger.Error(err, "Failed to send request to CF API")
-		// TODO: should we limit number of requeues? [story: #173573889]
-		return ctrl.Result{Requeue: true}, err
+		if cf.IsPermanent(err) {
+			// there is no CC build left to mark as failed if it's gone
+			markFailed := !errors.Is(err, cf.ErrNotFound)
+			return r.abandonBuildUpdate(ctx, build, fmt.Sprintf("Failed to update build in CF API: %s", err), markFailed, logger)
+		}
+		return r.retryBuildUpdate(ctx, build, err, true, logger)
 	}
+	metrics.BuildsReported.WithLabelValues(model.BuildStagedState).Inc()
 
 	return ctrl.Result{}, nil
 }
 
-func (r *BuildReconciler) reconcileFailedBuild(build *buildv1alpha1.Build, errorMessage string, logger logr.Logger) (ctrl.Result, error) {
+func (r *BuildReconciler) reconcileFailedBuild(ctx context.Context, build *buildv1alpha1.Build, errorMessage string, logger logr.Logger) (ctrl.Result, error) {
 	logger.V(1).Info("Build failed, marking as failed staging")
 
-	err := r.CFClient.UpdateBuild(build.GetLabels()[BuildGUIDLabel], model.Build{
+	err := r.markBuildFailed(ctx, build, errorMessage)
+	if err != nil {
+		logger.Error(err, "Failed to send request to CF API")
+		if cf.IsPermanent(err) {
+			return r.abandonBuildUpdate(ctx, build, "", false, logger)
+		}
+		return r.retryBuildUpdate(ctx, build, err, false, logger)
+	}
+
+	return ctrl.Result{}, nil
+}
+
+func (r *BuildReconciler) markBuildFailed(ctx context.Context, build *buildv1alpha1.Build, errorMessage string) error {
+	err := r.CFClient.UpdateBuild(ctx, build.GetLabels()[BuildGUIDLabel], model.Build{
 		State: model.BuildFailedState,
 		Error: errorMessage,
 	})
 	if err != nil {
-		logger.Error(err, "Failed to send request to CF API")
-		return ctrl.Result{Requeue: true}, err
+		return err
+	}
+	metrics.BuildsReported.WithLabelValues(model.BuildFailedState).Inc()
+	return nil
+}
+
+// retryBuildUpdate records a failed attempt to update the CC build on the
+// kpack Build and requeues it with exponential backoff. Once the attempts
+// are exhausted, a build that staged successfully is marked as FAILED in CC
+// so that `cf push` doesn't wait on it forever.
+func (r *BuildReconciler) retryBuildUpdate(ctx context.Context, build *buildv1alpha1.Build, updateErr error, markFailedOnExhaustion bool, logger logr.Logger) (ctrl.Result, error) {
+	attempts := buildUpdateAttempts(build) + 1
+
+	original := build.DeepCopy()
+	if build.Annotations == nil {
+		build.Annotations = map[string]string{}
+	}
+	build.Annotations[BuildUpdateAttemptsAnnotation] = strconv.Itoa(attempts)
+	if err := r.Patch(ctx, build, client.MergeFrom(original)); err != nil {
+		logger.Error(err, "Failed to record CF API update attempt on build")
+		return ctrl.Result{}, err
 	}
 
+	logger = logger.WithValues("attempts", attempts)
+	if attempts < r.maxUpdateAttempts() {
+		delay := r.backoff(attempts)
+		logger.WithValues("delay", delay).Info("Retrying CF API update")
+		return ctrl.Result{RequeueAfter: delay}, nil
+	}
+
+	errorMessage := fmt.Sprintf("Failed to update build in CF API after %d attempts: %s", attempts, updateErr)
+	return r.abandonBuildUpdate(ctx, build, errorMessage, markFailedOnExhaustion, logger)
+}
+
+// abandonBuildUpdate stops reconciling a build whose CC build can't be
+// updated, making a last attempt to mark it as FAILED if asked to
+func (r *BuildReconciler) abandonBuildUpdate(ctx context.Context, build *buildv1alpha1.Build, errorMessage string, markFailed bool, logger logr.Logger) (ctrl.Result, error) {
+	if markFailed {
+		if err := r.markBuildFailed(ctx, build, errorMessage); err != nil {
+			logger.Error(err, "Failed to mark build as failed after giving up on CF API update")
+		}
+	}
+	logger.Info("Giving up on updating build in CF API")
 	return ctrl.Result{}, nil
 }
 
-// returns true if any container has terminated with a non-zero exit code
-func findAnyFailedContainerState(containerStates []corev1.ContainerState) *corev1.ContainerState {
-	for _, container := range containerStates {
+func (r *BuildReconciler) maxUpdateAttempts() int {
+	if r.MaxUpdateAttempts > 0 {
+		return r.MaxUpdateAttempts
+	}
+	return DefaultMaxBuildUpdateAttempts
+}
+
+// backoff returns the delay before the next attempt, given the number of
+// attempts that failed so far
+func (r *BuildReconciler) backoff(attempts int) time.Duration {
+	baseDelay, maxDelay := r.UpdateBaseDelay, r.UpdateMaxDelay
+	if baseDelay <= 0 {
+		baseDelay = DefaultBuildUpdateBaseDelay
+	}
+	if maxDelay <= 0 {
+		maxDelay = DefaultBuildUpdateMaxDelay
+	}
+
+	delay := baseDelay
+	for i := 1; i < attempts && delay < maxDelay; i++ {
+		delay *= 2
+	}
+	if delay > maxDelay {
+		return maxDelay
+	}
+	return delay
+}
+
+func buildUpdateAttempts(build *buildv1alpha1.Build) int {
+	attempts, err := strconv.Atoi(build.GetAnnotations()[BuildUpdateAttemptsAnnotation])
+	if err != nil {
+		return 0
+	}
+	return attempts
+}
+
+// onlyMetadataChanged reports whether an update left a Build's spec and
+// status untouched, as when an annotation is added
+func onlyMetadataChanged(oldObject, newObject runtime.Object) bool {
+	oldBuild, ok := oldObject.(*buildv1alpha1.Build)
+	if !ok {
+		return false
+	}
+	newBuild, ok := newObject.(*buildv1alpha1.Build)
+	if !ok {
+		return false
+	}
+	return equality.Semantic.DeepEqual(oldBuild.Spec, newBuild.Spec) &&
+		equality.Semantic.DeepEqual(oldBuild.Status, newBuild.Status)
+}
+
+// findFailedStep returns the state of the first step that terminated with a
+// non-zero exit code, along with its name (e.g. "detect" or "build") when
+// kpack has recorded it
+func findFailedStep(status buildv1alpha1.BuildStatus) (string, *corev1.ContainerState) {
+	for i, container := range status.StepStates {
 		if container.Terminated != nil && container.Terminated.ExitCode != 0 {
-			return &container
+			// steps run in order, so every step up to the failed one has
+			// terminated and is listed in StepsCompleted
+			if i < len(status.StepsCompleted) {
+				return status.StepsCompleted[i], &container
+			}
+			return "", &container
 		}
 	}
-	return nil
+	return "", nil
+}
+
+// fetchStepLogs returns the end of the failed step's output, or nothing if
+// it is unavailable: the failure must be reported to CC either way
+func (r *BuildReconciler) fetchStepLogs(build *buildv1alpha1.Build, step string, logger logr.Logger) string {
+	if r.LogFetcher == nil || step == "" || build.Status.PodName == "" {
+		return ""
+	}
+
+	logs, err := r.LogFetcher.FetchStepLogs(build.Namespace, build.Status.PodName, step)
+	if err != nil {
+		logger.WithValues("step", step).Error(err, "Failed to fetch build step logs")
+		return ""
+	}
+	return logs
 }
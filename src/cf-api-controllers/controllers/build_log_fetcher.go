@@ -0,0 +1,53 @@
+package controllers
+
+import (
+	"io/ioutil"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+)
+
+// DefaultBuildLogTailLines is how many lines of a failed step's output are
+// reported to CC
+const DefaultBuildLogTailLines = 10
+
+// maxBuildLogBytes keeps the reported output short enough to be read in `cf push`
+const maxBuildLogBytes = 2048
+
+//go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 -o fake/build_log_fetcher.go --fake-name BuildLogFetcher . BuildLogFetcher
+type BuildLogFetcher interface {
+	// FetchStepLogs returns the end of the output of one step of a build pod
+	FetchStepLogs(namespace, podName, step string) (string, error)
+}
+
+type podLogFetcher struct {
+	pods      corev1client.PodsGetter
+	tailLines int64
+}
+
+func NewPodLogFetcher(pods corev1client.PodsGetter, tailLines int64) BuildLogFetcher {
+	if tailLines <= 0 {
+		tailLines = DefaultBuildLogTailLines
+	}
+	return &podLogFetcher{pods: pods, tailLines: tailLines}
+}
+
+func (f *podLogFetcher) FetchStepLogs(namespace, podName, step string) (string, error) {
+	limitBytes := int64(maxBuildLogBytes)
+	stream, err := f.pods.Pods(namespace).GetLogs(podName, &corev1.PodLogOptions{
+		Container:  step,
+		TailLines:  &f.tailLines,
+		LimitBytes: &limitBytes,
+	}).Stream()
+	if err != nil {
+		return "", err
+	}
+	defer stream.Close()
+
+	logs, err := ioutil.ReadAll(stream)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(logs)), nil
+}
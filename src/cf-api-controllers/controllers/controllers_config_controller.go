@@ -0,0 +1,358 @@
+package controllers
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/url"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/util/workqueue"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	appsv1alpha1 "code.cloudfoundry.org/capi-k8s-release/src/cf-api-controllers/apis/apps.cloudfoundry.org/v1alpha1"
+	"code.cloudfoundry.org/capi-k8s-release/src/cf-api-controllers/cf"
+	"code.cloudfoundry.org/capi-k8s-release/src/cf-api-controllers/image_registry"
+)
+
+// names of the controllers that a CFAPIControllersConfig can turn off
+const (
+	BuildControllerName        = "build"
+	ImageControllerName        = "image"
+	PeriodicSyncControllerName = "periodicSync"
+	RouteControllerName        = "route"
+)
+
+// configSecretPollInterval is how often the CFAPIControllersConfig is applied
+// again, picking up a rotated UAA client secret
+const configSecretPollInterval = 5 * time.Minute
+
+// ControllersSettings are the settings of the controllers from a valid
+// CFAPIControllersConfig, or from the environment
+type ControllersSettings struct {
+	CFAPIHost          string
+	UAAEndpoint        string
+	UAAClientName      string
+	UAAClientSecret    string
+	WorkloadsNamespace string
+	TLS                cf.TLSConfig
+	// CFAPITimeout bounds each request to the CF API and UAA
+	CFAPITimeout time.Duration
+	// ImageRegistryTimeout bounds fetching the config of a built image
+	ImageRegistryTimeout time.Duration
+	// DisabledControllers holds the names of the controllers turned off
+	DisabledControllers map[string]bool
+}
+
+// ControllerSwitch turns a controller off and back on while the manager runs,
+// as a CFAPIControllersConfig disables and enables it. A nil switch is always
+// on.
+//
+// It is also a source for the controller it switches: the requests dropped
+// while it is off are queued again once it is back on, so that nothing
+// changed in the meantime is missed.
+type ControllerSwitch struct {
+	mu      sync.Mutex
+	off     bool
+	dropped map[ctrl.Request]bool
+	queue   workqueue.RateLimitingInterface
+}
+
+func (s *ControllerSwitch) Enabled() bool {
+	if s == nil {
+		return true
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return !s.off
+}
+
+// Admit reports whether the controller may handle req. While it is off, req
+// is kept to be queued again when it is turned back on.
+func (s *ControllerSwitch) Admit(req ctrl.Request) bool {
+	if s == nil {
+		return true
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.off {
+		return true
+	}
+	if s.dropped == nil {
+		s.dropped = make(map[ctrl.Request]bool)
+	}
+	s.dropped[req] = true
+	return false
+}
+
+func (s *ControllerSwitch) Set(enabled bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.off = !enabled
+	if s.off || s.queue == nil {
+		return
+	}
+	for req := range s.dropped {
+		s.queue.Add(req)
+	}
+	s.dropped = nil
+}
+
+// Start implements source.Source, queueing the dropped requests straight onto
+// the controller's queue rather than through the event handler
+func (s *ControllerSwitch) Start(_ handler.EventHandler, queue workqueue.RateLimitingInterface, _ ...predicate.Predicate) error {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.queue = queue
+	if !s.off {
+		for req := range s.dropped {
+			queue.Add(req)
+		}
+		s.dropped = nil
+	}
+	return nil
+}
+
+// ControllersConfigReconciler validates the CFAPIControllersConfig called Name
+// and hands its settings to Apply, reporting the outcome in the config's Valid
+// condition
+type ControllersConfigReconciler struct {
+	client.Client
+	Log logr.Logger
+	// APIReader reads the Secrets the config references, which would
+	// otherwise have every Secret of the cluster cached
+	APIReader client.Reader
+	Name      string
+	Apply     func(ControllersSettings) error
+	Context   context.Context
+
+	applied *ControllersSettings
+}
+
+// +kubebuilder:rbac:groups=apps.cloudfoundry.org,resources=cfapicontrollersconfigs,verbs=get;list;watch
+// +kubebuilder:rbac:groups=apps.cloudfoundry.org,resources=cfapicontrollersconfigs/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups="",resources=secrets,verbs=get
+
+func (r *ControllersConfigReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
+	ctx := reconcileContext(r.Context)
+	logger := r.Log.WithValues("cfapicontrollersconfig", req.Name)
+
+	var config appsv1alpha1.CFAPIControllersConfig
+	err := r.Get(ctx, req.NamespacedName, &config)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			logger.Info("CFAPIControllersConfig not found, keeping the current settings")
+			return ctrl.Result{}, nil
+		}
+		logger.Error(err, "failed to fetch CFAPIControllersConfig")
+		return ctrl.Result{}, err
+	}
+	original := config.Status.DeepCopy()
+	config.Status.ObservedGeneration = config.Generation
+
+	settings, err := LoadControllersSettings(ctx, r.APIReader, &config)
+	if err != nil {
+		logger.Error(err, "CFAPIControllersConfig is invalid, keeping the current settings")
+		setConfigCondition(&config, appsv1alpha1.FalseConditionStatus, appsv1alpha1.InvalidConditionReason, err.Error())
+	} else if err := r.apply(settings); err != nil {
+		logger.Error(err, "failed to apply CFAPIControllersConfig")
+		setConfigCondition(&config, appsv1alpha1.FalseConditionStatus, appsv1alpha1.ApplyFailedConditionReason, err.Error())
+	} else {
+		setConfigCondition(&config, appsv1alpha1.TrueConditionStatus, appsv1alpha1.AppliedConditionReason, "config applied")
+	}
+
+	if !equality.Semantic.DeepEqual(original, &config.Status) {
+		if err := r.Status().Update(ctx, &config); err != nil {
+			logger.Error(err, "failed to update CFAPIControllersConfig status")
+			return ctrl.Result{}, err
+		}
+	}
+	return ctrl.Result{RequeueAfter: configSecretPollInterval}, nil
+}
+
+// apply hands settings to Apply unless they were already applied
+func (r *ControllersConfigReconciler) apply(settings ControllersSettings) error {
+	if r.applied != nil && reflect.DeepEqual(*r.applied, settings) {
+		return nil
+	}
+	if err := r.Apply(settings); err != nil {
+		return err
+	}
+	r.applied = &settings
+	return nil
+}
+
+func (r *ControllersConfigReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(new(appsv1alpha1.CFAPIControllersConfig)).
+		WithEventFilter(predicate.Funcs{
+			CreateFunc: func(e event.CreateEvent) bool { return e.Meta.GetName() == r.Name },
+			UpdateFunc: func(e event.UpdateEvent) bool {
+				// status updates don't change the settings
+				return e.MetaNew.GetName() == r.Name && e.MetaNew.GetGeneration() != e.MetaOld.GetGeneration()
+			},
+			DeleteFunc:  func(_ event.DeleteEvent) bool { return false },
+			GenericFunc: func(_ event.GenericEvent) bool { return false },
+		}).
+		Complete(r)
+}
+
+// LoadControllersSettings validates the config and reads the UAA client secret
+// and client certificate it references, returning every problem found with it
+// in a single error
+func LoadControllersSettings(ctx context.Context, reader client.Reader, config *appsv1alpha1.CFAPIControllersConfig) (ControllersSettings, error) {
+	spec := config.Spec
+	var problems []string
+
+	if err := validateURL(spec.CFAPIHost); err != nil {
+		problems = append(problems, fmt.Sprintf("spec.cfAPIHost %s", err))
+	}
+	if err := validateURL(spec.UAA.Endpoint); err != nil {
+		problems = append(problems, fmt.Sprintf("spec.uaa.endpoint %s", err))
+	}
+	if spec.UAA.ClientName == "" {
+		problems = append(problems, "spec.uaa.clientName must be set")
+	}
+	for _, message := range validation.IsDNS1123Label(spec.WorkloadsNamespace) {
+		problems = append(problems, fmt.Sprintf("spec.workloadsNamespace %s", message))
+	}
+	tlsConfig := cf.TLSConfig{InsecureSkipVerify: spec.TLS.InsecureSkipVerify}
+	if ca := spec.TLS.CACertificate; ca != "" {
+		tlsConfig.CACertificate = []byte(ca)
+		if !x509.NewCertPool().AppendCertsFromPEM(tlsConfig.CACertificate) {
+			problems = append(problems, "spec.tls.caCertificate must contain a PEM encoded certificate")
+		}
+	}
+	if ref := spec.TLS.ClientCertificateSecretRef; ref != nil {
+		var err error
+		tlsConfig.ClientCertificate, tlsConfig.ClientKey, err = readClientCertificate(ctx, reader, *ref)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("spec.tls.clientCertificateSecretRef %s", err))
+		}
+	}
+
+	cfAPITimeout, err := timeoutOrDefault(spec.Timeouts.CFAPI, cf.DefaultTimeout)
+	if err != nil {
+		problems = append(problems, fmt.Sprintf("spec.timeouts.cfAPI %s", err))
+	}
+	registryTimeout, err := timeoutOrDefault(spec.Timeouts.ImageRegistry, image_registry.DefaultTimeout)
+	if err != nil {
+		problems = append(problems, fmt.Sprintf("spec.timeouts.imageRegistry %s", err))
+	}
+
+	secret, err := readSecretKey(ctx, reader, spec.UAA.ClientSecretRef)
+	if err != nil {
+		problems = append(problems, fmt.Sprintf("spec.uaa.clientSecretRef %s", err))
+	}
+
+	if len(problems) > 0 {
+		return ControllersSettings{}, errors.New(strings.Join(problems, "; "))
+	}
+
+	disabled := make(map[string]bool)
+	for name, enabled := range map[string]*bool{
+		BuildControllerName:        spec.Controllers.Build,
+		ImageControllerName:        spec.Controllers.Image,
+		PeriodicSyncControllerName: spec.Controllers.PeriodicSync,
+		RouteControllerName:        spec.Controllers.Route,
+	} {
+		if enabled != nil && !*enabled {
+			disabled[name] = true
+		}
+	}
+
+	return ControllersSettings{
+		CFAPIHost:            spec.CFAPIHost,
+		UAAEndpoint:          spec.UAA.Endpoint,
+		UAAClientName:        spec.UAA.ClientName,
+		UAAClientSecret:      secret,
+		WorkloadsNamespace:   spec.WorkloadsNamespace,
+		TLS:                  tlsConfig,
+		CFAPITimeout:         cfAPITimeout,
+		ImageRegistryTimeout: registryTimeout,
+		DisabledControllers:  disabled,
+	}, nil
+}
+
+func validateURL(value string) error {
+	if value == "" {
+		return errors.New("must be set")
+	}
+	parsed, err := url.Parse(value)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return fmt.Errorf("must be an http or https URL, got %q", value)
+	}
+	return nil
+}
+
+func timeoutOrDefault(timeout *metav1.Duration, defaultTimeout time.Duration) (time.Duration, error) {
+	if timeout == nil {
+		return defaultTimeout, nil
+	}
+	if timeout.Duration <= 0 {
+		return 0, fmt.Errorf("must be positive, got %s", timeout.Duration)
+	}
+	return timeout.Duration, nil
+}
+
+func readSecretKey(ctx context.Context, reader client.Reader, ref appsv1alpha1.SecretKeyReference) (string, error) {
+	if ref.Namespace == "" || ref.Name == "" || ref.Key == "" {
+		return "", errors.New("must set namespace, name and key")
+	}
+
+	var secret corev1.Secret
+	err := reader.Get(ctx, types.NamespacedName{Namespace: ref.Namespace, Name: ref.Name}, &secret)
+	if err != nil {
+		return "", fmt.Errorf("could not read Secret %s/%s: %w", ref.Namespace, ref.Name, err)
+	}
+	value, ok := secret.Data[ref.Key]
+	if !ok || len(value) == 0 {
+		return "", fmt.Errorf("Secret %s/%s has no key %q", ref.Namespace, ref.Name, ref.Key)
+	}
+	return string(value), nil
+}
+
+// readClientCertificate reads the certificate and key of a kubernetes.io/tls
+// Secret, checking that they belong together
+func readClientCertificate(ctx context.Context, reader client.Reader, ref appsv1alpha1.SecretReference) ([]byte, []byte, error) {
+	var secret corev1.Secret
+	err := reader.Get(ctx, types.NamespacedName{Namespace: ref.Namespace, Name: ref.Name}, &secret)
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not read Secret %s/%s: %w", ref.Namespace, ref.Name, err)
+	}
+
+	certificate, key := secret.Data[corev1.TLSCertKey], secret.Data[corev1.TLSPrivateKeyKey]
+	if _, err := tls.X509KeyPair(certificate, key); err != nil {
+		return nil, nil, fmt.Errorf("Secret %s/%s has no valid %s and %s: %s", ref.Namespace, ref.Name, corev1.TLSCertKey, corev1.TLSPrivateKeyKey, err)
+	}
+	return certificate, key, nil
+}
+
+// setConfigCondition sets the config's Valid condition
+func setConfigCondition(config *appsv1alpha1.CFAPIControllersConfig, status appsv1alpha1.ConditionStatus, reason, message string) {
+	appsv1alpha1.SetCondition(&config.Status.Conditions, appsv1alpha1.Condition{
+		Type:    appsv1alpha1.ValidConditionType,
+		Status:  status,
+		Reason:  reason,
+		Message: message,
+	})
+}
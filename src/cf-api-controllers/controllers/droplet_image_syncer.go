@@ -0,0 +1,118 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-logr/logr"
+	buildv1alpha1 "github.com/pivotal/kpack/pkg/apis/build/v1alpha1"
+	corev1alpha1 "github.com/pivotal/kpack/pkg/apis/core/v1alpha1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"code.cloudfoundry.org/capi-k8s-release/src/cf-api-controllers/cf/model"
+)
+
+//go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 -o fake/cf_droplet_client.go --fake-name CFDropletClient . CfDropletClient
+type CfDropletClient interface {
+	GetDroplet(ctx context.Context, dropletGUID string) (model.Droplet, error)
+	UpdateDroplet(ctx context.Context, dropletGUID string, droplet model.Droplet) error
+}
+
+//go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 -o fake/stack_rebase_handler.go --fake-name StackRebaseHandler . StackRebaseHandler
+type StackRebaseHandler interface {
+	RolloutStackRebase(ctx context.Context, image buildv1alpha1.Image, logger logr.Logger) error
+}
+
+// DropletImageSyncer catches up on image changes the ImageReconciler missed:
+// it points each CF droplet at the latest image of its kpack Image. Stack
+// rebases are handed to StackRebases instead, so that they go through the
+// same rebase target, rollout policy and readiness checks as any other, and
+// are left alone once the ImageReconciler has rolled them out, skipped them,
+// rolled them back or is verifying them.
+type DropletImageSyncer struct {
+	client.Client
+	CFClient     CfDropletClient
+	StackRebases StackRebaseHandler
+}
+
+func (s *DropletImageSyncer) ResourceKind() string {
+	return "Droplet"
+}
+
+func (s *DropletImageSyncer) Diff(ctx context.Context, logger logr.Logger) (SyncDiff, error) {
+	var images buildv1alpha1.ImageList
+	err := s.List(ctx, &images, client.HasLabels{AppGUIDLabel, DropletGUIDLabel})
+	if err != nil {
+		return SyncDiff{}, fmt.Errorf("error listing images from kubernetes API: %w", err)
+	}
+
+	// Existing stays 0: droplets are never deleted, so there is no deletion
+	// budget to measure against
+	var diff SyncDiff
+	for i := range images.Items {
+		image := images.Items[i]
+		latestImage := image.Status.LatestImage
+		if !image.Status.GetCondition(corev1alpha1.ConditionReady).IsTrue() || latestImage == "" {
+			continue
+		}
+		stackUpdate := image.Status.LatestBuildReason == StackUpdateBuildReason
+		if stackUpdate && stackRebaseSettled(&image) {
+			continue
+		}
+
+		dropletGUID := image.Labels[DropletGUIDLabel]
+		if stackUpdate {
+			if err := checkImagePinned(latestImage); err != nil {
+				diff.Failed = append(diff.Failed, SyncFailure{GUID: dropletGUID, Err: err})
+				continue
+			}
+		}
+		droplet, err := s.CFClient.GetDroplet(ctx, dropletGUID)
+		if err != nil {
+			diff.Failed = append(diff.Failed, SyncFailure{GUID: dropletGUID, Err: err})
+			continue
+		}
+		// the ImageReconciler updates the droplet last, so a droplet on the
+		// latest image has nothing left to roll out
+		if droplet.Image == latestImage {
+			continue
+		}
+
+		if stackUpdate {
+			if s.StackRebases == nil {
+				continue
+			}
+			imageLogger := logger.WithValues("image", image.Name)
+			diff.Update = append(diff.Update, SyncChange{
+				GUID: dropletGUID,
+				Apply: func(ctx context.Context) error {
+					return s.StackRebases.RolloutStackRebase(ctx, image, imageLogger)
+				},
+			})
+			continue
+		}
+
+		// for any other build the app must be restarted to pick up the image
+		update := model.Droplet{Image: latestImage}
+		diff.Update = append(diff.Update, SyncChange{
+			GUID: dropletGUID,
+			Apply: func(ctx context.Context) error {
+				return s.CFClient.UpdateDroplet(ctx, dropletGUID, update)
+			},
+		})
+	}
+
+	return diff, nil
+}
+
+// stackRebaseSettled reports whether the ImageReconciler has already decided
+// on the stack rebase to the Image's latest image
+func stackRebaseSettled(image *buildv1alpha1.Image) bool {
+	switch image.Status.LatestImage {
+	case image.Annotations[StackRebaseRolledBackAnnotation],
+		image.Annotations[StackRebaseVerifyingAnnotation],
+		image.Annotations[StackRebaseHandledAnnotation]:
+		return true
+	}
+	return false
+}
@@ -2,6 +2,7 @@
 package fake
 
 import (
+	"context"
 	"sync"
 
 	"code.cloudfoundry.org/capi-k8s-release/src/cf-api-controllers/cf/model"
@@ -9,11 +10,12 @@ import (
 )
 
 type CFBuildUpdater struct {
-	UpdateBuildStub        func(string, model.Build) error
+	UpdateBuildStub        func(context.Context, string, model.Build) error
 	updateBuildMutex       sync.RWMutex
 	updateBuildArgsForCall []struct {
-		arg1 string
-		arg2 model.Build
+		arg1 context.Context
+		arg2 string
+		arg3 model.Build
 	}
 	updateBuildReturns struct {
 		result1 error
@@ -25,17 +27,18 @@ type CFBuildUpdater struct {
 	invocationsMutex sync.RWMutex
 }
 
-func (fake *CFBuildUpdater) UpdateBuild(arg1 string, arg2 model.Build) error {
+func (fake *CFBuildUpdater) UpdateBuild(arg1 context.Context, arg2 string, arg3 model.Build) error {
 	fake.updateBuildMutex.Lock()
 	ret, specificReturn := fake.updateBuildReturnsOnCall[len(fake.updateBuildArgsForCall)]
 	fake.updateBuildArgsForCall = append(fake.updateBuildArgsForCall, struct {
-		arg1 string
-		arg2 model.Build
-	}{arg1, arg2})
-	fake.recordInvocation("UpdateBuild", []interface{}{arg1, arg2})
+		arg1 context.Context
+		arg2 string
+		arg3 model.Build
+	}{arg1, arg2, arg3})
+	fake.recordInvocation("UpdateBuild", []interface{}{arg1, arg2, arg3})
 	fake.updateBuildMutex.Unlock()
 	if fake.UpdateBuildStub != nil {
-		return fake.UpdateBuildStub(arg1, arg2)
+		return fake.UpdateBuildStub(arg1, arg2, arg3)
 	}
 	if specificReturn {
 		return ret.result1
@@ -50,17 +53,17 @@ func (fake *CFBuildUpdater) UpdateBuildCallCount() int {
 	return len(fake.updateBuildArgsForCall)
 }
 
-func (fake *CFBuildUpdater) UpdateBuildCalls(stub func(string, model.Build) error) {
+func (fake *CFBuildUpdater) UpdateBuildCalls(stub func(context.Context, string, model.Build) error) {
 	fake.updateBuildMutex.Lock()
 	defer fake.updateBuildMutex.Unlock()
 	fake.UpdateBuildStub = stub
 }
 
-func (fake *CFBuildUpdater) UpdateBuildArgsForCall(i int) (string, model.Build) {
+func (fake *CFBuildUpdater) UpdateBuildArgsForCall(i int) (context.Context, string, model.Build) {
 	fake.updateBuildMutex.RLock()
 	defer fake.updateBuildMutex.RUnlock()
 	argsForCall := fake.updateBuildArgsForCall[i]
-	return argsForCall.arg1, argsForCall.arg2
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
 }
 
 func (fake *CFBuildUpdater) UpdateBuildReturns(result1 error) {
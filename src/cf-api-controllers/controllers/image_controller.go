@@ -17,29 +17,33 @@ limitations under the License.
 package controllers
 
 import (
-	"code.cloudfoundry.org/capi-k8s-release/src/cf-api-controllers/cf"
-	"code.cloudfoundry.org/capi-k8s-release/src/cf-api-controllers/cf/model"
 	"context"
 	"errors"
+	"time"
 
 	"github.com/go-logr/logr"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
 
 	buildv1alpha1 "github.com/pivotal/kpack/pkg/apis/build/v1alpha1"
 	corev1alpha1 "github.com/pivotal/kpack/pkg/apis/core/v1alpha1"
-	appsv1 "k8s.io/client-go/kubernetes/typed/apps/v1"
+	typedappsv1 "k8s.io/client-go/kubernetes/typed/apps/v1"
+
+	"code.cloudfoundry.org/capi-k8s-release/src/cf-api-controllers/cf"
 )
 
 const AppGUIDLabel = "cloudfoundry.org/app_guid"
 const DropletGUIDLabel = "cloudfoundry.org/droplet_guid"
 
+// StackRebaseHandledAnnotation records the latest image of an Image whose
+// stack rebase has already been rolled out or skipped, so the app isn't
+// counted against the rollout or verified twice
+const StackRebaseHandledAnnotation = "cloudfoundry.org/stack_rebase_handled_image"
+
 var ImageFilterError = errors.New("Received an image event with a non-image runtime.Object")
 
 // ImageReconciler reconciles a Image object
@@ -47,16 +51,35 @@ type ImageReconciler struct {
 	client.Client
 	Log                logr.Logger
 	Scheme             *runtime.Scheme
-	CFClient           *cf.Client
-	AppsClientSet      *appsv1.AppsV1Client
+	CFClient           CfDropletClient
+	AppsClientSet      typedappsv1.StatefulSetsGetter
 	WorkloadsNamespace string
+
+	// Rebaser rolls rebased images out to the app's workloads, defaulting to
+	// updating its StatefulSets in WorkloadsNamespace directly
+	Rebaser Rebaser
+
+	// RolloutPolicy paces rebases across apps. Without one each rebased image
+	// is rolled out as soon as it is built.
+	RolloutPolicy *StackRebaseRolloutPolicy
+
+	// ReadinessWindow is how long a rebased app has to become ready before the
+	// rebase is rolled back. Zero disables the check.
+	ReadinessWindow time.Duration
+
+	Switch  *ControllerSwitch
+	Context context.Context
 }
 
 // +kubebuilder:rbac:groups=kpack.io,resources=images,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=kpack.io,resources=images/status,verbs=get;update;patch
 
 func (r *ImageReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
-	ctx := context.Background()
+	if !r.Switch.Admit(req) {
+		return ctrl.Result{}, nil
+	}
+
+	ctx := reconcileContext(r.Context)
 
 	var image buildv1alpha1.Image
 	err := r.Get(ctx, req.NamespacedName, &image)
@@ -67,56 +90,126 @@ func (r *ImageReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 	logger := r.Log.WithValues("image", req.NamespacedName)
 
 	if image.Status.GetCondition(corev1alpha1.ConditionReady).IsTrue() && image.Status.LatestBuildReason == "STACK" {
-		return r.handleRebasedImage(image, logger)
+		return r.handleRebasedImage(ctx, image, logger)
 	}
 
 	logger.Info("Image status indicates either a failure or non-stack related updated, took no action")
 	return ctrl.Result{}, nil
 }
 
-func (r *ImageReconciler) handleRebasedImage(image buildv1alpha1.Image, logger logr.Logger) (ctrl.Result, error) {
-	labelSelector := metav1.LabelSelector{MatchLabels: map[string]string{AppGUIDLabel: image.ObjectMeta.Labels[AppGUIDLabel]}}
-	statefulsets, err := r.AppsClientSet.StatefulSets(r.WorkloadsNamespace).
-		List(v1.ListOptions{LabelSelector: labels.Set(labelSelector.MatchLabels).String()})
-	if err != nil {
-		logger.Error(err, "Could not find statefulsets for an app")
-		return ctrl.Result{}, err
+// RolloutStackRebase rolls the Image's rebased image out to its app the way
+// Reconcile does, for the DropletImage PeriodicSync to catch up on rebases
+// that were never finished. It does nothing while the controller is off.
+func (r *ImageReconciler) RolloutStackRebase(ctx context.Context, image buildv1alpha1.Image, logger logr.Logger) error {
+	if !r.Switch.Enabled() {
+		return nil
 	}
-	if len(statefulsets.Items) == 0 {
-		logger.WithValues("appGUID", image.ObjectMeta.Labels[AppGUIDLabel]).
-			Info("No statefulsets found for the app")
-		// it's possible to miss statefulsets that have been rebased but are not yet started
-		// an eventually-consistent reconciliation loop might allow us to fix this after-the-fact
-		return ctrl.Result{}, nil
+	_, err := r.handleRebasedImage(ctx, image, logger)
+	return err
+}
+
+func (r *ImageReconciler) handleRebasedImage(ctx context.Context, image buildv1alpha1.Image, logger logr.Logger) (ctrl.Result, error) {
+	rebaser := r.Rebaser
+	if rebaser == nil {
+		rebaser = &StatefulSetRebaser{AppsClientSet: r.AppsClientSet, WorkloadsNamespace: r.WorkloadsNamespace}
 	}
-	if len(statefulsets.Items) > 1 {
-		logger.WithValues("NumberOfStatefulSets", len(statefulsets.Items)).
-			Info("Multiple statefulsets found for the app, requeueing image update event.")
-		return ctrl.Result{Requeue: true}, nil
+
+	appGUID := image.ObjectMeta.Labels[AppGUIDLabel]
+	if err := checkImagePinned(image.Status.LatestImage); err != nil {
+		// a tag could move under the app, and kpack would not fix that by retrying
+		logger.Error(err, "Refusing to roll out rebased image")
+		return ctrl.Result{}, nil
 	}
-	statefulset := statefulsets.Items[0]
 
-	containers := statefulset.Spec.Template.Spec.Containers
-	for i, container := range containers {
-		if container.Name != "opi" {
-			continue
-		}
-		containers[i].Image = image.Status.LatestImage
+	switch image.Status.LatestImage {
+	case image.Annotations[StackRebaseRolledBackAnnotation]:
+		logger.Info("Stack rebase was rolled back, not retrying it")
+		return ctrl.Result{}, nil
+	case image.Annotations[StackRebaseVerifyingAnnotation]:
+		return r.verifyRebase(ctx, &image, rebaser, logger)
+	case image.Annotations[StackRebaseHandledAnnotation]:
+		logger.V(1).Info("Stack rebase already rolled out, skipping")
+		return ctrl.Result{}, nil
+	}
 
-		_, err := r.AppsClientSet.StatefulSets(r.WorkloadsNamespace).Update(&statefulset)
+	if r.RolloutPolicy != nil {
+		decision, wait, err := r.RolloutPolicy.Admit(ctx, appGUID, logger)
 		if err != nil {
-			logger.Error(err, "Could not update statefulset")
 			return ctrl.Result{}, err
 		}
-		logger.WithValues("newImage", image.Status.LatestImage).Info("Successfully updated app's StatefulSet with new image based off new stack")
-		updateDropletRequest := model.Droplet{Image: image.Status.LatestImage}
-		err = r.CFClient.UpdateDroplet(image.GetLabels()[DropletGUIDLabel], updateDropletRequest)
-		if err != nil {
-			logger.Error(err, "Failed to send request to CF API")
-			return ctrl.Result{}, err
+		switch decision {
+		case RolloutDeferred:
+			logger.Info("Stack rebase deferred by the rollout policy", "requeueAfter", wait)
+			return ctrl.Result{RequeueAfter: wait}, nil
+		case RolloutOptedOut:
+			logger.WithValues("appGUID", appGUID).
+				Info("App's org or space opted out of stack rebases, skipping")
+			return ctrl.Result{}, r.markRebaseHandled(ctx, &image)
 		}
 	}
-	return ctrl.Result{}, nil
+
+	found, err := rebaser.Rebase(ctx, appGUID, image.Status.LatestImage, logger)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if !found {
+		logger.WithValues("appGUID", appGUID).
+			Info("No workloads found for the app")
+		// it's possible to miss workloads that have been rebased but are not yet started
+		// a DropletImage PeriodicSync fixes this after-the-fact
+		return ctrl.Result{}, r.markRebaseHandled(ctx, &image)
+	}
+
+	// CC still has the image the droplet ran before the rebase, even when
+	// retrying after a failed droplet update, since that is done last
+	dropletGUID := image.GetLabels()[DropletGUIDLabel]
+	droplet, err := r.CFClient.GetDroplet(ctx, dropletGUID)
+	if err != nil {
+		logger.Error(err, "Failed to fetch droplet from CF API")
+		return ctrl.Result{}, err
+	}
+	updateDropletRequest, err := newRebasedDroplet(ctx, r, &image, droplet.Image)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	err = r.CFClient.UpdateDroplet(ctx, dropletGUID, updateDropletRequest)
+	if err != nil {
+		logger.Error(err, "Failed to send request to CF API")
+		if cf.IsPermanent(err) {
+			// requeueing would only repeat the same rejected request
+			return ctrl.Result{}, r.markRebaseHandled(ctx, &image)
+		}
+		return ctrl.Result{}, err
+	}
+	return r.startVerifyingRebase(ctx, &image, droplet.Image, logger)
+}
+
+// markRebaseHandled annotates the Image when a rollout policy or readiness
+// check is in use, the cases where reconciling the same rebased image again
+// does harm
+func (r *ImageReconciler) markRebaseHandled(ctx context.Context, image *buildv1alpha1.Image) error {
+	if r.RolloutPolicy == nil && r.ReadinessWindow == 0 {
+		return nil
+	}
+	return r.annotateImage(ctx, image, map[string]string{StackRebaseHandledAnnotation: image.Status.LatestImage})
+}
+
+// annotateImage patches the given annotations onto the Image, removing those
+// set to ""
+func (r *ImageReconciler) annotateImage(ctx context.Context, image *buildv1alpha1.Image, annotations map[string]string) error {
+	patch := client.MergeFrom(image.DeepCopy())
+	if image.Annotations == nil {
+		image.Annotations = map[string]string{}
+	}
+	for key, value := range annotations {
+		if value == "" {
+			delete(image.Annotations, key)
+			continue
+		}
+		image.Annotations[key] = value
+	}
+	return r.Patch(ctx, image, patch)
 }
 
 func (r *ImageReconciler) SetupWithManager(mgr ctrl.Manager) error {
@@ -136,6 +229,7 @@ func (r *ImageReconciler) SetupWithManager(mgr ctrl.Manager) error {
 			DeleteFunc:  func(_ event.DeleteEvent) bool { return false },
 			GenericFunc: func(_ event.GenericEvent) bool { return false },
 		}).
+		Watches(r.Switch, new(handler.Funcs)).
 		Complete(r)
 }
 
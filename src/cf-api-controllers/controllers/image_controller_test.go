@@ -27,10 +27,11 @@ var _ = Describe("ImageController", func() {
 		dropletGUID             string
 		receivedApiDropletPatch chan model.Droplet
 		updatedImageStatus      buildv1alpha1.ImageStatus
+		routeDropletGet         func()
 	)
 	const (
-		postStackUpdateImageReference = "post-stack-update-image-reference"
-		preStackUpdateImageReference  = "pre-stack-update-image-reference"
+		postStackUpdateImageReference = "registry.example.org/some-app@sha256:1111111111111111111111111111111111111111111111111111111111111111"
+		preStackUpdateImageReference  = "registry.example.org/some-app@sha256:0000000000000000000000000000000000000000000000000000000000000000"
 	)
 
 	BeforeEach(func() {
@@ -62,7 +63,14 @@ var _ = Describe("ImageController", func() {
 		dropletGUID = fmt.Sprintf("droplet-guid-%d", GinkgoRandomSeed())
 		receivedApiDropletPatch = make(chan model.Droplet)
 
+		routeDropletGet = func() {
+			fakeCFAPIServer.RouteToHandler("GET", "/v3/droplets/"+dropletGUID,
+				ghttp.RespondWithJSONEncoded(200, model.Droplet{Image: preStackUpdateImageReference}),
+			)
+		}
+
 		fakeCFAPIServer.Reset()
+		routeDropletGet()
 		fakeCFAPIServer.AppendHandlers(ghttp.CombineHandlers(
 			ghttp.VerifyRequest("PATCH", "/v3/droplets/"+dropletGUID),
 			ghttp.VerifyHeaderKV("Authorization", "Bearer"),
@@ -128,18 +136,21 @@ var _ = Describe("ImageController", func() {
 					return statefulset.Spec.Template.Spec.Containers[0].Image == postStackUpdateImageReference
 				}, "5s", "100ms").Should(BeTrue())
 
-				Eventually(fakeCFAPIServer.ReceivedRequests, time.Second*15).Should(HaveLen(1))
+				// the droplet's previous image is fetched before it is patched
+				Eventually(fakeCFAPIServer.ReceivedRequests, time.Second*15).Should(HaveLen(2))
 
 				var actualDropletPatch model.Droplet
 				Eventually(receivedApiDropletPatch).Should(Receive(&actualDropletPatch))
 
 				Expect(actualDropletPatch.Image).To(Equal(postStackUpdateImageReference))
+				Expect(actualDropletPatch.Metadata.Annotations).To(HaveKeyWithValue(RebasePreviousImageAnnotation, preStackUpdateImageReference))
 			})
 		})
 
 		When("and the cloud controller responds with an error", func() {
 			BeforeEach(func() {
 				fakeCFAPIServer.Reset()
+				routeDropletGet()
 				fakeCFAPIServer.AppendHandlers(
 					ghttp.RespondWith(500, ""),
 					func(_ http.ResponseWriter, r *http.Request) {
@@ -155,7 +166,7 @@ var _ = Describe("ImageController", func() {
 
 			It("requeues the Image resource and eventually reconciles again", func() {
 				subject = updateImageStatus(subject, &updatedImageStatus)
-				Eventually(fakeCFAPIServer.ReceivedRequests, time.Second*30).Should(HaveLen(2))
+				Eventually(fakeCFAPIServer.ReceivedRequests, time.Second*30).Should(HaveLen(4))
 
 				var actualDropletPatch model.Droplet
 				Eventually(receivedApiDropletPatch).Should(Receive(&actualDropletPatch))
@@ -164,17 +175,15 @@ var _ = Describe("ImageController", func() {
 			})
 		})
 
-		When("there are multiple statefulsets for the app (i.e. during a rolling deployment)", func() {
+		When("there are multiple statefulsets for the app (i.e. one per process type)", func() {
 			var (
-				anotherAppStatefulSet        *appsv1.StatefulSet
-				updatedSourceCodeImageStatus buildv1alpha1.ImageStatus
+				workerStatefulSet *appsv1.StatefulSet
 			)
-			const oldStackNewSrc = "old-stack-new-src"
 
 			BeforeEach(func() {
-				anotherAppStatefulSet = createStatefulSet(&appsv1.StatefulSet{
+				workerStatefulSet = createStatefulSet(&appsv1.StatefulSet{
 					ObjectMeta: metav1.ObjectMeta{
-						Name:      "statefulset-2-name",
+						Name:      "statefulset-worker-name",
 						Namespace: workloadsNamespace,
 						Labels:    map[string]string{"cloudfoundry.org/app_guid": "some-app-guid-123"},
 					},
@@ -190,64 +199,40 @@ var _ = Describe("ImageController", func() {
 								Containers: []corev1.Container{
 									{
 										Name:  "opi",
-										Image: oldStackNewSrc,
+										Image: preStackUpdateImageReference,
 									},
 								},
 							},
 						},
 					},
 				})
-				updatedSourceCodeImageStatus = buildv1alpha1.ImageStatus{
-					Status: corev1alpha1.Status{
-						Conditions: []corev1alpha1.Condition{
-							{
-								Type:   corev1alpha1.ConditionReady,
-								Status: corev1.ConditionTrue,
-							},
-						},
-					},
-					LatestBuildReason: "SRC",
-					LatestImage:       oldStackNewSrc,
-				}
 			})
 
-			It("does not update the statefulsets and requeues the update event", func() {
-				subject = updateImageStatus(subject, &updatedSourceCodeImageStatus)
+			It("updates all of the statefulsets before updating the droplet", func() {
 				subject = updateImageStatus(subject, &updatedImageStatus)
-				Consistently(func() []string {
-					statefulset1 := appsv1.StatefulSet{}
-					statefulset1NamespacedName := types.NamespacedName{
-						Name:      appStatefulSet.ObjectMeta.Name,
-						Namespace: appStatefulSet.ObjectMeta.Namespace,
-					}
-					Expect(k8sClient.Get(context.Background(), statefulset1NamespacedName, &statefulset1)).To(Succeed())
-					statefulset2 := appsv1.StatefulSet{}
-					statefulset2NamespacedName := types.NamespacedName{
-						Name:      anotherAppStatefulSet.ObjectMeta.Name,
-						Namespace: anotherAppStatefulSet.ObjectMeta.Namespace,
-					}
-					Expect(k8sClient.Get(context.Background(), statefulset2NamespacedName, &statefulset2)).To(Succeed())
-					return []string{statefulset1.Spec.Template.Spec.Containers[0].Image, statefulset2.Spec.Template.Spec.Containers[0].Image}
-				}, "5s", "100ms").Should(Equal([]string{preStackUpdateImageReference, oldStackNewSrc}))
-
-				deleteStatefulSet(appStatefulSet)
 
-				Eventually(func() string {
-					statefulset2 := appsv1.StatefulSet{}
-					statefulset2NamespacedName := types.NamespacedName{
-						Name:      anotherAppStatefulSet.ObjectMeta.Name,
-						Namespace: anotherAppStatefulSet.ObjectMeta.Namespace,
+				Eventually(func() []string {
+					var images []string
+					for _, statefulset := range []*appsv1.StatefulSet{appStatefulSet, workerStatefulSet} {
+						var actualStatefulSet appsv1.StatefulSet
+						statefulsetNamespacedName := types.NamespacedName{
+							Name:      statefulset.ObjectMeta.Name,
+							Namespace: statefulset.ObjectMeta.Namespace,
+						}
+						Expect(k8sClient.Get(context.Background(), statefulsetNamespacedName, &actualStatefulSet)).To(Succeed())
+						images = append(images, actualStatefulSet.Spec.Template.Spec.Containers[0].Image)
 					}
-					Expect(k8sClient.Get(context.Background(), statefulset2NamespacedName, &statefulset2)).To(Succeed())
-					return statefulset2.Spec.Template.Spec.Containers[0].Image
-				}, "5s", "100ms").Should(Equal(postStackUpdateImageReference))
+					return images
+				}, "5s", "100ms").Should(Equal([]string{postStackUpdateImageReference, postStackUpdateImageReference}))
 
-				Eventually(fakeCFAPIServer.ReceivedRequests, time.Second*15).Should(HaveLen(1))
+				// the droplet's previous image is fetched before it is patched
+				Eventually(fakeCFAPIServer.ReceivedRequests, time.Second*15).Should(HaveLen(2))
 
 				var actualDropletPatch model.Droplet
 				Eventually(receivedApiDropletPatch).Should(Receive(&actualDropletPatch))
 
 				Expect(actualDropletPatch.Image).To(Equal(postStackUpdateImageReference))
+				Expect(actualDropletPatch.Metadata.Annotations).To(HaveKeyWithValue(RebasePreviousImageAnnotation, preStackUpdateImageReference))
 			})
 		})
 
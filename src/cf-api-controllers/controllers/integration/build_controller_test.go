@@ -0,0 +1,110 @@
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/buildpacks/lifecycle"
+	"github.com/buildpacks/lifecycle/launch"
+	ociv1 "github.com/google/go-containerregistry/pkg/v1"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	buildv1alpha1 "github.com/pivotal/kpack/pkg/apis/build/v1alpha1"
+	corev1alpha1 "github.com/pivotal/kpack/pkg/apis/core/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"code.cloudfoundry.org/capi-k8s-release/src/cf-api-controllers/cf/cftest"
+	"code.cloudfoundry.org/capi-k8s-release/src/cf-api-controllers/cf/model"
+	"code.cloudfoundry.org/capi-k8s-release/src/cf-api-controllers/controllers"
+)
+
+var _ = Describe("BuildReconciler against the CF API", func() {
+	var (
+		buildGUID string
+		build     *buildv1alpha1.Build
+	)
+
+	BeforeEach(func() {
+		raw, err := json.Marshal(lifecycle.BuildMetadata{
+			Processes: []launch.Process{{Type: "web", Command: "some-start-command"}},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		imageConfigFetcher.FetchImageConfigReturns(&ociv1.Config{
+			Labels: map[string]string{lifecycle.BuildMetadataLabel: string(raw)},
+		}, nil)
+
+		buildGUID = fmt.Sprintf("build-guid-%d", GinkgoRandomSeed())
+		cfAPI.AddBuild(buildGUID)
+
+		build = &buildv1alpha1.Build{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        buildGUID,
+				Namespace:   stagingNamespace,
+				Labels:      map[string]string{controllers.BuildGUIDLabel: buildGUID},
+				Annotations: map[string]string{controllers.BuildReasonAnnotation: "CONFIG"},
+			},
+		}
+		Expect(k8sClient.Create(context.Background(), build)).To(Succeed())
+	})
+
+	completeBuild := func(status corev1.ConditionStatus) {
+		build.Status = buildv1alpha1.BuildStatus{
+			Status: corev1alpha1.Status{
+				Conditions: []corev1alpha1.Condition{{Type: corev1alpha1.ConditionSucceeded, Status: status}},
+			},
+			StepStates:  []corev1.ContainerState{{Terminated: &corev1.ContainerStateTerminated{ExitCode: 0}}},
+			LatestImage: "registry.example.org/some-app@sha256:abc",
+		}
+		Expect(k8sClient.Status().Update(context.Background(), build)).To(Succeed())
+	}
+
+	updatedBuild := func() model.Build {
+		build, _ := cfAPI.Build(buildGUID)
+		return build
+	}
+
+	It("marks the CC build as staged with the image and its process types", func() {
+		completeBuild(corev1.ConditionTrue)
+
+		Eventually(updatedBuild, "10s").Should(WithTransform(func(b model.Build) string { return b.State }, Equal(model.BuildStagedState)))
+		Expect(updatedBuild().Lifecycle.Data.Image).To(Equal("registry.example.org/some-app@sha256:abc"))
+		Expect(updatedBuild().Lifecycle.Data.ProcessTypes).To(Equal(map[string]string{"web": "some-start-command"}))
+	})
+
+	When("CC is briefly unavailable", func() {
+		BeforeEach(func() {
+			cfAPI.InjectFault(http.MethodPatch, "/v3/builds/:guid", cftest.Fault{
+				StatusCode: http.StatusServiceUnavailable,
+				Errors:     []model.Error{{Code: 10015, Title: "CF-ServiceUnavailable", Detail: "try again"}},
+				Times:      2,
+			})
+		})
+
+		It("retries until the CC build is updated", func() {
+			completeBuild(corev1.ConditionTrue)
+
+			Eventually(updatedBuild, "10s").Should(WithTransform(func(b model.Build) string { return b.State }, Equal(model.BuildStagedState)))
+			Expect(cfAPI.Requests(http.MethodPatch, "/v3/builds/:guid")).To(HaveLen(3))
+		})
+	})
+
+	When("the CC build no longer exists", func() {
+		BeforeEach(func() {
+			cfAPI.Reset()
+		})
+
+		It("gives up after a single attempt", func() {
+			completeBuild(corev1.ConditionTrue)
+
+			Eventually(func() []cftest.Request {
+				return cfAPI.Requests(http.MethodPatch, "/v3/builds/:guid")
+			}, "10s").Should(HaveLen(1))
+			Consistently(func() []cftest.Request {
+				return cfAPI.Requests(http.MethodPatch, "/v3/builds/:guid")
+			}, "2s").Should(HaveLen(1))
+		})
+	})
+})
@@ -0,0 +1,111 @@
+package integration
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	buildv1alpha1 "github.com/pivotal/kpack/pkg/apis/build/v1alpha1"
+	corev1alpha1 "github.com/pivotal/kpack/pkg/apis/core/v1alpha1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"code.cloudfoundry.org/capi-k8s-release/src/cf-api-controllers/cf/cftest"
+	"code.cloudfoundry.org/capi-k8s-release/src/cf-api-controllers/cf/model"
+	"code.cloudfoundry.org/capi-k8s-release/src/cf-api-controllers/controllers"
+)
+
+var _ = Describe("ImageReconciler against the CF API", func() {
+	const (
+		appGUID         = "some-app-guid"
+		preRebaseImage  = "registry.example.org/some-app@sha256:0000000000000000000000000000000000000000000000000000000000000000"
+		postRebaseImage = "registry.example.org/some-app@sha256:1111111111111111111111111111111111111111111111111111111111111111"
+		statefulSetName = "some-app-web"
+		imageName       = "some-app-image"
+	)
+
+	var (
+		dropletGUID string
+		image       *buildv1alpha1.Image
+	)
+
+	BeforeEach(func() {
+		dropletGUID = fmt.Sprintf("droplet-guid-%d", GinkgoRandomSeed())
+		cfAPI.AddDroplet(dropletGUID, model.Droplet{Image: preRebaseImage})
+
+		labels := map[string]string{controllers.AppGUIDLabel: appGUID}
+		Expect(k8sClient.Create(context.Background(), &appsv1.StatefulSet{
+			ObjectMeta: metav1.ObjectMeta{Name: statefulSetName, Namespace: workloadsNamespace, Labels: labels},
+			Spec: appsv1.StatefulSetSpec{
+				Selector: &metav1.LabelSelector{MatchLabels: labels},
+				Template: corev1.PodTemplateSpec{
+					ObjectMeta: metav1.ObjectMeta{Labels: labels},
+					Spec: corev1.PodSpec{
+						Containers: []corev1.Container{{Name: "opi", Image: preRebaseImage}},
+					},
+				},
+			},
+		})).To(Succeed())
+
+		image = &buildv1alpha1.Image{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      imageName,
+				Namespace: stagingNamespace,
+				Labels:    map[string]string{controllers.AppGUIDLabel: appGUID, controllers.DropletGUIDLabel: dropletGUID},
+			},
+		}
+		Expect(k8sClient.Create(context.Background(), image)).To(Succeed())
+	})
+
+	rebaseImage := func() {
+		image.Status = buildv1alpha1.ImageStatus{
+			Status: corev1alpha1.Status{
+				Conditions: []corev1alpha1.Condition{{Type: corev1alpha1.ConditionReady, Status: corev1.ConditionTrue}},
+			},
+			LatestBuildReason: "STACK",
+			LatestImage:       postRebaseImage,
+		}
+		Expect(k8sClient.Status().Update(context.Background(), image)).To(Succeed())
+	}
+
+	dropletImage := func() string {
+		droplet, _ := cfAPI.Droplet(dropletGUID)
+		return droplet.Image
+	}
+
+	statefulSetImage := func() string {
+		var statefulSet appsv1.StatefulSet
+		Expect(k8sClient.Get(context.Background(), types.NamespacedName{Namespace: workloadsNamespace, Name: statefulSetName}, &statefulSet)).To(Succeed())
+		return statefulSet.Spec.Template.Spec.Containers[0].Image
+	}
+
+	It("rolls the rebased image out and records it on the droplet", func() {
+		rebaseImage()
+
+		Eventually(statefulSetImage, "10s").Should(Equal(postRebaseImage))
+		Eventually(dropletImage, "10s").Should(Equal(postRebaseImage))
+
+		droplet, _ := cfAPI.Droplet(dropletGUID)
+		Expect(droplet.Metadata.Annotations).To(HaveKeyWithValue(controllers.RebasePreviousImageAnnotation, preRebaseImage))
+	})
+
+	When("updating the droplet fails at first", func() {
+		BeforeEach(func() {
+			cfAPI.InjectFault(http.MethodPatch, "/v3/droplets/:guid", cftest.Fault{
+				StatusCode: http.StatusInternalServerError,
+				Times:      1,
+			})
+		})
+
+		It("requeues the Image until the droplet is updated", func() {
+			rebaseImage()
+
+			Eventually(dropletImage, "30s").Should(Equal(postRebaseImage))
+			Expect(len(cfAPI.Requests(http.MethodPatch, "/v3/droplets/:guid"))).To(BeNumerically(">=", 2))
+		})
+	})
+})
@@ -0,0 +1,195 @@
+package integration
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/matt-royal/biloba"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	buildv1alpha1 "github.com/pivotal/kpack/pkg/apis/build/v1alpha1"
+	kpackscheme "github.com/pivotal/kpack/pkg/client/clientset/versioned/scheme"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	clientappsv1 "k8s.io/client-go/kubernetes/typed/apps/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/envtest"
+	"sigs.k8s.io/controller-runtime/pkg/envtest/printer"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+
+	appsv1alpha1 "code.cloudfoundry.org/capi-k8s-release/src/cf-api-controllers/apis/apps.cloudfoundry.org/v1alpha1"
+	"code.cloudfoundry.org/capi-k8s-release/src/cf-api-controllers/cf"
+	"code.cloudfoundry.org/capi-k8s-release/src/cf-api-controllers/cf/auth"
+	"code.cloudfoundry.org/capi-k8s-release/src/cf-api-controllers/cf/cftest"
+	"code.cloudfoundry.org/capi-k8s-release/src/cf-api-controllers/controllers"
+	"code.cloudfoundry.org/capi-k8s-release/src/cf-api-controllers/image_registry/image_registryfakes"
+	networkingv1alpha1 "code.cloudfoundry.org/cf-k8s-networking/routecontroller/apis/networking/v1alpha1"
+)
+
+const (
+	stagingNamespace   = "cf-workloads-staging"
+	workloadsNamespace = "cf-workloads"
+)
+
+// These suites run the controllers against a cftest.Server rather than stubbing
+// individual requests, so that the CF API client, UAA tokens and the
+// controllers' handling of CC's responses are exercised together
+var (
+	testEnv         *envtest.Environment
+	managerStopChan chan struct{}
+	k8sClient       client.Client
+
+	cfAPI              *cftest.Server
+	imageConfigFetcher *image_registryfakes.FakeImageConfigFetcher
+	// routeSwitch turns the RouteReconciler off for specs of the PeriodicSync,
+	// which would otherwise race it to converge the Routes
+	routeSwitch *controllers.ControllerSwitch
+)
+
+func TestIntegration(t *testing.T) {
+	RegisterFailHandler(Fail)
+
+	RunSpecsWithDefaultAndCustomReporters(t,
+		"Controller Integration Suite",
+		append(
+			[]Reporter{printer.NewlineReporter{}},
+			biloba.GoLandReporter()...,
+		),
+	)
+}
+
+var _ = BeforeSuite(func(done Done) {
+	logf.SetLogger(zap.LoggerTo(GinkgoWriter, true))
+
+	By("bootstrapping test environment")
+	testEnv = &envtest.Environment{
+		ErrorIfCRDPathMissing: true,
+		CRDDirectoryPaths: []string{
+			filepath.Join("..", "..", "test", "fixtures"),
+			filepath.Join("..", "..", "config", "crd", "bases"),
+		},
+	}
+
+	config, err := testEnv.Start()
+	Expect(err).NotTo(HaveOccurred())
+
+	Expect(kpackscheme.AddToScheme(scheme.Scheme)).To(Succeed())
+	Expect(networkingv1alpha1.AddToScheme(scheme.Scheme)).To(Succeed())
+	Expect(appsv1alpha1.AddToScheme(scheme.Scheme)).To(Succeed())
+
+	k8sManager, err := ctrl.NewManager(config, ctrl.Options{
+		Scheme:                 scheme.Scheme,
+		HealthProbeBindAddress: "0",
+		MetricsBindAddress:     "0",
+	})
+	Expect(err).NotTo(HaveOccurred())
+
+	By("starting the fake CF API")
+	cfAPI = cftest.NewServer()
+	httpClient, err := cf.NewHTTPClient(cf.TLSConfig{})
+	Expect(err).NotTo(HaveOccurred())
+	httpClient.Timeout = 5 * time.Second
+	uaaClient, err := auth.NewUAAClient(cfAPI.URL, "cf-api-controllers", "some-secret", httpClient)
+	Expect(err).NotTo(HaveOccurred())
+	cfClient := cf.NewClient(cf.NewRestClient(cfAPI.URL, httpClient, uaaClient))
+
+	imageConfigFetcher = new(image_registryfakes.FakeImageConfigFetcher)
+	Expect((&controllers.BuildReconciler{
+		Client:             k8sManager.GetClient(),
+		Log:                ctrl.Log.WithName("controllers").WithName("Build"),
+		Scheme:             k8sManager.GetScheme(),
+		CFClient:           cfClient,
+		ImageConfigFetcher: imageConfigFetcher,
+		UpdateBaseDelay:    100 * time.Millisecond,
+		UpdateMaxDelay:     time.Second,
+	}).SetupWithManager(k8sManager)).To(Succeed())
+
+	clientset, err := clientappsv1.NewForConfig(k8sManager.GetConfig())
+	Expect(err).NotTo(HaveOccurred())
+	imageReconciler := &controllers.ImageReconciler{
+		Client:             k8sManager.GetClient(),
+		AppsClientSet:      clientset,
+		Log:                ctrl.Log.WithName("controllers").WithName("Image"),
+		Scheme:             k8sManager.GetScheme(),
+		CFClient:           cfClient,
+		WorkloadsNamespace: workloadsNamespace,
+	}
+	Expect(imageReconciler.SetupWithManager(k8sManager)).To(Succeed())
+
+	Expect((&controllers.PeriodicSyncReconciler{
+		Client:   k8sManager.GetClient(),
+		Log:      ctrl.Log.WithName("controllers").WithName("PeriodicSync"),
+		Scheme:   k8sManager.GetScheme(),
+		Recorder: k8sManager.GetEventRecorderFor("periodicsync-controller"),
+		Syncers: map[appsv1alpha1.ResourceType]controllers.Syncer{
+			appsv1alpha1.RouteResourceType: &controllers.RouteSyncer{
+				Client:             k8sManager.GetClient(),
+				CFClient:           cfClient,
+				WorkloadsNamespace: workloadsNamespace,
+			},
+			appsv1alpha1.DropletImageResourceType: &controllers.DropletImageSyncer{
+				Client:       k8sManager.GetClient(),
+				CFClient:     cfClient,
+				StackRebases: imageReconciler,
+			},
+		},
+	}).SetupWithManager(k8sManager)).To(Succeed())
+
+	routeSwitch = new(controllers.ControllerSwitch)
+	Expect((&controllers.RouteReconciler{
+		Client:             k8sManager.GetClient(),
+		Log:                ctrl.Log.WithName("controllers").WithName("Route"),
+		Scheme:             k8sManager.GetScheme(),
+		CFClient:           cfClient,
+		WorkloadsNamespace: workloadsNamespace,
+		Switch:             routeSwitch,
+	}).SetupWithManager(k8sManager)).To(Succeed())
+
+	managerStopChan = make(chan struct{})
+	go func() {
+		defer GinkgoRecover()
+		Expect(k8sManager.Start(managerStopChan)).To(Succeed())
+	}()
+
+	k8sClient = k8sManager.GetClient()
+	for _, namespace := range []string{stagingNamespace, workloadsNamespace} {
+		Expect(k8sClient.Create(context.Background(), &corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{Name: namespace},
+		})).To(Succeed())
+	}
+
+	close(done)
+}, 60)
+
+var _ = AfterEach(func() {
+	ctx := context.Background()
+
+	// forgetting CC's state first means the Routes deleted below aren't
+	// recreated by the RouteReconciler
+	cfAPI.Reset()
+
+	Expect(k8sClient.DeleteAllOf(ctx, new(appsv1alpha1.PeriodicSync), client.InNamespace(workloadsNamespace))).To(Succeed())
+	Expect(k8sClient.DeleteAllOf(ctx, new(buildv1alpha1.Build), client.InNamespace(stagingNamespace))).To(Succeed())
+	Expect(k8sClient.DeleteAllOf(ctx, new(buildv1alpha1.Image), client.InNamespace(stagingNamespace))).To(Succeed())
+	Expect(k8sClient.DeleteAllOf(ctx, new(appsv1.StatefulSet), client.InNamespace(workloadsNamespace))).To(Succeed())
+	Expect(k8sClient.DeleteAllOf(ctx, new(networkingv1alpha1.Route), client.InNamespace(workloadsNamespace))).To(Succeed())
+})
+
+var _ = AfterSuite(func() {
+	By("tearing down the fake CF API")
+	cfAPI.Close()
+
+	By("tearing down the controllers")
+	if managerStopChan != nil {
+		close(managerStopChan)
+	}
+
+	By("tearing down the test environment")
+	Expect(testEnv.Stop()).To(Succeed())
+})
@@ -0,0 +1,121 @@
+package integration
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	appsv1alpha1 "code.cloudfoundry.org/capi-k8s-release/src/cf-api-controllers/apis/apps.cloudfoundry.org/v1alpha1"
+	"code.cloudfoundry.org/capi-k8s-release/src/cf-api-controllers/cf/kubernetes"
+	"code.cloudfoundry.org/capi-k8s-release/src/cf-api-controllers/cf/model"
+	networkingv1alpha1 "code.cloudfoundry.org/cf-k8s-networking/routecontroller/apis/networking/v1alpha1"
+)
+
+var _ = Describe("PeriodicSyncReconciler against the CF API", func() {
+	const (
+		routeCount     = 5
+		staleRouteGUID = "stale-route-guid"
+	)
+
+	BeforeEach(func() {
+		routeSwitch.Set(false)
+
+		// a page of 2 makes the client follow CC's next links twice
+		cfAPI.LimitPageSize(2)
+
+		space := model.Space{
+			GUID: "some-space-guid",
+			Name: "some-space",
+			Relationships: map[string]model.Relationship{
+				"organization": {Data: model.RelationshipData{GUID: "some-org-guid"}},
+			},
+		}
+		organization := model.Organization{GUID: "some-org-guid", Name: "some-org"}
+		domain := model.Domain{GUID: "some-domain-guid", Name: "apps.example.com"}
+		cfAPI.AddOrganization(organization)
+		cfAPI.AddSpace(space)
+		cfAPI.AddDomain(domain)
+		for i := 1; i <= routeCount; i++ {
+			cfAPI.AddRoute(model.Route{
+				GUID: fmt.Sprintf("route-guid-%d", i),
+				Host: fmt.Sprintf("host-%d", i),
+				URL:  fmt.Sprintf("host-%d.apps.example.com", i),
+				Relationships: map[string]model.Relationship{
+					"space":  {Data: model.RelationshipData{GUID: space.GUID}},
+					"domain": {Data: model.RelationshipData{GUID: domain.GUID}},
+				},
+			})
+		}
+
+		staleRoute := kubernetes.TranslateRoute(&model.Route{GUID: staleRouteGUID, Host: "stale-host"}, &space, &organization, &domain, workloadsNamespace)
+		Expect(k8sClient.Create(context.Background(), &staleRoute)).To(Succeed())
+
+		Expect(k8sClient.Create(context.Background(), &appsv1alpha1.PeriodicSync{
+			ObjectMeta: metav1.ObjectMeta{Name: "route-sync", Namespace: workloadsNamespace},
+			Spec:       appsv1alpha1.PeriodicSyncSpec{PeriodSeconds: 60},
+		})).To(Succeed())
+	})
+
+	AfterEach(func() {
+		routeSwitch.Set(true)
+	})
+
+	routeNames := func() []string {
+		var routes networkingv1alpha1.RouteList
+		if err := k8sClient.List(context.Background(), &routes, client.InNamespace(workloadsNamespace)); err != nil {
+			return nil
+		}
+		var names []string
+		for _, route := range routes.Items {
+			names = append(names, route.Name)
+		}
+		return names
+	}
+
+	lastSyncResult := func() appsv1alpha1.SyncResult {
+		var periodicSync appsv1alpha1.PeriodicSync
+		err := k8sClient.Get(context.Background(), types.NamespacedName{Namespace: workloadsNamespace, Name: "route-sync"}, &periodicSync)
+		if err != nil || periodicSync.Status.LastSyncTime == nil {
+			return appsv1alpha1.SyncResult{}
+		}
+		return periodicSync.Status.LastSyncResult
+	}
+
+	It("creates a Route for the routes on every page", func() {
+		Eventually(routeNames, "10s").Should(ConsistOf(
+			"route-guid-1", "route-guid-2", "route-guid-3", "route-guid-4", "route-guid-5",
+		))
+		Expect(len(cfAPI.Requests(http.MethodGet, "/v3/routes"))).To(BeNumerically(">=", 3))
+		Expect(cfAPI.Requests(http.MethodGet, "/v3/spaces/:guid")).To(BeEmpty())
+		Expect(cfAPI.Requests(http.MethodGet, "/v3/domains/:guid")).To(BeEmpty())
+	})
+
+	It("takes the domain, space and organization from those CC includes", func() {
+		Eventually(routeNames, "10s").Should(HaveLen(routeCount))
+
+		var route networkingv1alpha1.Route
+		Expect(k8sClient.Get(context.Background(), types.NamespacedName{Namespace: workloadsNamespace, Name: "route-guid-5"}, &route)).To(Succeed())
+		Expect(route.Spec.Host).To(Equal("host-5"))
+		Expect(route.Spec.Domain.Name).To(Equal("apps.example.com"))
+		Expect(route.Labels).To(HaveKeyWithValue(kubernetes.CFOrgGuidLabel, "some-org-guid"))
+		Expect(route.Annotations).To(HaveKeyWithValue(kubernetes.CFSpaceNameAnnotation, "some-space"))
+		Expect(route.Annotations).To(HaveKeyWithValue(kubernetes.CFOrgNameAnnotation, "some-org"))
+	})
+
+	It("deletes the Routes of routes CC no longer has", func() {
+		Eventually(func() bool {
+			var route networkingv1alpha1.Route
+			err := k8sClient.Get(context.Background(), types.NamespacedName{Namespace: workloadsNamespace, Name: staleRouteGUID}, &route)
+			return apierrors.IsNotFound(err)
+		}, "10s").Should(BeTrue())
+
+		Eventually(lastSyncResult, "10s").Should(Equal(appsv1alpha1.SyncResult{Created: routeCount, Deleted: 1}))
+	})
+})
@@ -0,0 +1,139 @@
+package integration
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+
+	"code.cloudfoundry.org/capi-k8s-release/src/cf-api-controllers/cf/cftest"
+	"code.cloudfoundry.org/capi-k8s-release/src/cf-api-controllers/cf/kubernetes"
+	"code.cloudfoundry.org/capi-k8s-release/src/cf-api-controllers/cf/model"
+	networkingv1alpha1 "code.cloudfoundry.org/cf-k8s-networking/routecontroller/apis/networking/v1alpha1"
+)
+
+var _ = Describe("RouteReconciler against the CF API", func() {
+	const routeGUID = "some-route-guid"
+
+	var ccRoute model.Route
+
+	BeforeEach(func() {
+		space := model.Space{
+			GUID: "some-space-guid",
+			Name: "some-space",
+			Relationships: map[string]model.Relationship{
+				"organization": {Data: model.RelationshipData{GUID: "some-org-guid"}},
+			},
+		}
+		organization := model.Organization{GUID: "some-org-guid", Name: "some-org"}
+		domain := model.Domain{GUID: "some-domain-guid", Name: "apps.example.com"}
+		weight := 100
+		ccRoute = model.Route{
+			GUID: routeGUID,
+			Host: "some-host",
+			Path: "/some-path",
+			URL:  "some-host.apps.example.com/some-path",
+			Destinations: []model.Destination{{
+				GUID:   "some-destination-guid",
+				App:    model.DestinationApp{GUID: "some-app-guid", Process: model.DestinationProcess{Type: "web"}},
+				Weight: &weight,
+				Port:   8080,
+			}},
+			Relationships: map[string]model.Relationship{
+				"space":  {Data: model.RelationshipData{GUID: space.GUID}},
+				"domain": {Data: model.RelationshipData{GUID: domain.GUID}},
+			},
+		}
+		cfAPI.AddOrganization(organization)
+		cfAPI.AddSpace(space)
+		cfAPI.AddDomain(domain)
+		cfAPI.AddRoute(ccRoute)
+
+		route := kubernetes.TranslateRoute(&ccRoute, &space, &organization, &domain, workloadsNamespace)
+		Expect(k8sClient.Create(context.Background(), &route)).To(Succeed())
+	})
+
+	getRoute := func() (networkingv1alpha1.Route, error) {
+		var route networkingv1alpha1.Route
+		err := k8sClient.Get(context.Background(), types.NamespacedName{Namespace: workloadsNamespace, Name: routeGUID}, &route)
+		return route, err
+	}
+
+	routeHost := func() string {
+		route, err := getRoute()
+		if err != nil {
+			return ""
+		}
+		return route.Spec.Host
+	}
+
+	driftRoute := func() {
+		Eventually(func() error {
+			route, err := getRoute()
+			if err != nil {
+				return err
+			}
+			route.Spec.Host = "drifted-host"
+			return k8sClient.Update(context.Background(), &route)
+		}, "5s").Should(Succeed())
+	}
+
+	It("reverts a Route that drifted from CC", func() {
+		driftRoute()
+
+		Eventually(routeHost, "10s").Should(Equal("some-host"))
+	})
+
+	It("recreates a Route deleted in k8s that still exists in CC", func() {
+		route, err := getRoute()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(k8sClient.Delete(context.Background(), &route)).To(Succeed())
+
+		Eventually(routeHost, "10s").Should(Equal("some-host"))
+	})
+
+	It("deletes a Route that no longer exists in CC", func() {
+		cfAPI.DeleteRoute(routeGUID)
+		driftRoute()
+
+		Eventually(func() bool {
+			_, err := getRoute()
+			return apierrors.IsNotFound(err)
+		}, "10s").Should(BeTrue())
+	})
+
+	When("CC is briefly unavailable", func() {
+		BeforeEach(func() {
+			cfAPI.InjectFault(http.MethodGet, "/v3/routes/:guid", cftest.Fault{
+				StatusCode: http.StatusServiceUnavailable,
+				Times:      2,
+			})
+		})
+
+		It("retries until the Route matches CC", func() {
+			driftRoute()
+
+			Eventually(routeHost, "10s").Should(Equal("some-host"))
+			Expect(len(cfAPI.Requests(http.MethodGet, "/v3/routes/:guid"))).To(BeNumerically(">=", 3))
+		})
+	})
+
+	When("CC does not respond in time", func() {
+		BeforeEach(func() {
+			cfAPI.InjectFault(http.MethodGet, "/v3/routes/:guid", cftest.Fault{
+				Delay: time.Minute,
+				Times: 1,
+			})
+		})
+
+		It("abandons the request and retries", func() {
+			driftRoute()
+
+			Eventually(routeHost, "20s").Should(Equal("some-host"))
+		})
+	})
+})
@@ -0,0 +1,95 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"code.cloudfoundry.org/capi-k8s-release/src/cf-api-controllers/metrics"
+)
+
+// LRPGroupVersionKind identifies Eirini's LRP resource. It is handled as
+// unstructured so we don't depend on Eirini's Go module for a single field.
+var LRPGroupVersionKind = schema.GroupVersionKind{
+	Group:   "eirini.cloudfoundry.org",
+	Version: "v1",
+	Kind:    "LRP",
+}
+
+// LRPRebaser updates the image of the app's Eirini LRPs, leaving it to Eirini
+// to roll the change out to the StatefulSets it manages
+type LRPRebaser struct {
+	client.Client
+	WorkloadsNamespace string
+}
+
+// +kubebuilder:rbac:groups=eirini.cloudfoundry.org,resources=lrps,verbs=get;list;update
+
+func (l *LRPRebaser) Rebase(ctx context.Context, appGUID, image string, logger logr.Logger) (bool, error) {
+	lrps := &unstructured.UnstructuredList{}
+	lrps.SetGroupVersionKind(LRPGroupVersionKind.GroupVersion().WithKind(LRPGroupVersionKind.Kind + "List"))
+	err := l.List(ctx, lrps, client.InNamespace(l.WorkloadsNamespace), client.MatchingLabels{AppGUIDLabel: appGUID})
+	if err != nil {
+		logger.Error(err, "Could not find LRPs for an app")
+		return false, err
+	}
+	if len(lrps.Items) == 0 {
+		return false, nil
+	}
+
+	// as with StatefulSets, every process type of the app has its own LRP
+	var (
+		updated        []*unstructured.Unstructured
+		previousImages []string
+		errs           []error
+	)
+	for i := range lrps.Items {
+		lrp := &lrps.Items[i]
+		lrpLogger := logger.WithValues("lrp", lrp.GetName())
+
+		previousImage, _, _ := unstructured.NestedString(lrp.Object, "spec", "image")
+		if previousImage == image {
+			lrpLogger.V(1).Info("LRP already runs the new image, skipping")
+			continue
+		}
+
+		err := l.setImage(ctx, lrp, image)
+		if err != nil {
+			metrics.StackRebaseLRPUpdates.WithLabelValues(metrics.ResultFailure).Inc()
+			lrpLogger.Error(err, "Could not update LRP")
+			errs = append(errs, fmt.Errorf("failed to update LRP %s: %w", lrp.GetName(), err))
+			continue
+		}
+		metrics.StackRebaseLRPUpdates.WithLabelValues(metrics.ResultSuccess).Inc()
+		lrpLogger.WithValues("newImage", image).Info("Successfully updated app's LRP with new image based off new stack")
+		updated = append(updated, lrp)
+		previousImages = append(previousImages, previousImage)
+	}
+
+	if len(errs) == 0 {
+		return true, nil
+	}
+
+	logger.WithValues("failed", len(errs), "updated", len(updated)).
+		Info("Failed to update some of the app's LRPs, reverting the others")
+	for i, lrp := range updated {
+		if err := l.setImage(ctx, lrp, previousImages[i]); err != nil {
+			// the requeue will bring it forward to the new image instead
+			logger.WithValues("lrp", lrp.GetName()).Error(err, "Could not revert LRP")
+		}
+	}
+	return true, utilerrors.NewAggregate(errs)
+}
+
+func (l *LRPRebaser) setImage(ctx context.Context, lrp *unstructured.Unstructured, image string) error {
+	err := unstructured.SetNestedField(lrp.Object, image, "spec", "image")
+	if err != nil {
+		return err
+	}
+	return l.Update(ctx, lrp)
+}
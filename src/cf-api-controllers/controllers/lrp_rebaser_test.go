@@ -0,0 +1,75 @@
+package controllers
+
+import (
+	"context"
+
+	logrTesting "github.com/go-logr/logr/testing"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+var _ = Describe("LRPRebaser", func() {
+	const (
+		appGUID                       = "some-app-guid-456"
+		preStackUpdateImageReference  = "pre-stack-update-image-reference"
+		postStackUpdateImageReference = "post-stack-update-image-reference"
+	)
+
+	var (
+		rebaser *LRPRebaser
+	)
+
+	createLRP := func(name, appGUID, image string) {
+		lrp := &unstructured.Unstructured{}
+		lrp.SetGroupVersionKind(LRPGroupVersionKind)
+		lrp.SetName(name)
+		lrp.SetNamespace(workloadsNamespace)
+		lrp.SetLabels(map[string]string{AppGUIDLabel: appGUID})
+		Expect(unstructured.SetNestedField(lrp.Object, image, "spec", "image")).To(Succeed())
+		Expect(k8sClient.Create(context.Background(), lrp)).To(Succeed())
+	}
+
+	lrpImage := func(name string) string {
+		lrp := &unstructured.Unstructured{}
+		lrp.SetGroupVersionKind(LRPGroupVersionKind)
+		Expect(k8sClient.Get(context.Background(), types.NamespacedName{Name: name, Namespace: workloadsNamespace}, lrp)).To(Succeed())
+		image, _, err := unstructured.NestedString(lrp.Object, "spec", "image")
+		Expect(err).NotTo(HaveOccurred())
+		return image
+	}
+
+	BeforeEach(func() {
+		rebaser = &LRPRebaser{
+			Client:             k8sClient,
+			WorkloadsNamespace: workloadsNamespace,
+		}
+	})
+
+	When("the app has an LRP per process type", func() {
+		BeforeEach(func() {
+			createLRP("app-web", appGUID, preStackUpdateImageReference)
+			createLRP("app-worker", appGUID, postStackUpdateImageReference)
+			createLRP("another-app-web", "another-app-guid", preStackUpdateImageReference)
+		})
+
+		It("updates the image of each of the app's LRPs", func() {
+			found, err := rebaser.Rebase(context.Background(), appGUID, postStackUpdateImageReference, logrTesting.NullLogger{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(found).To(BeTrue())
+
+			Expect(lrpImage("app-web")).To(Equal(postStackUpdateImageReference))
+			Expect(lrpImage("app-worker")).To(Equal(postStackUpdateImageReference))
+			Expect(lrpImage("another-app-web")).To(Equal(preStackUpdateImageReference))
+		})
+	})
+
+	When("the app has no LRPs", func() {
+		It("reports that nothing was found", func() {
+			found, err := rebaser.Rebase(context.Background(), appGUID, postStackUpdateImageReference, logrTesting.NullLogger{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(found).To(BeFalse())
+		})
+	})
+})
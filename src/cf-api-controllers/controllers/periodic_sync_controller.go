@@ -20,42 +20,55 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sort"
+	"strings"
 	"time"
 
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-
-	"code.cloudfoundry.org/capi-k8s-release/src/cf-api-controllers/cf/kubernetes"
-
-	"code.cloudfoundry.org/capi-k8s-release/src/cf-api-controllers/cf/model"
-
-	networkingv1alpha1 "code.cloudfoundry.org/cf-k8s-networking/routecontroller/apis/networking/v1alpha1"
+	"k8s.io/client-go/tools/record"
 
 	"github.com/go-logr/logr"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 
 	appsv1alpha1 "code.cloudfoundry.org/capi-k8s-release/src/cf-api-controllers/apis/apps.cloudfoundry.org/v1alpha1"
 	"code.cloudfoundry.org/capi-k8s-release/src/cf-api-controllers/cf"
+	"code.cloudfoundry.org/capi-k8s-release/src/cf-api-controllers/metrics"
 )
 
 //go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 -o fake/controller_runtime_client.go --fake-name ControllerRuntimeClient sigs.k8s.io/controller-runtime/pkg/client.Client
 
+// bounds the number of GUIDs listed in the PeriodicSync status, which could
+// otherwise grow past the etcd object size limit on large foundations
+const maxStatusGUIDs = 50
+
 // PeriodicSyncReconciler reconciles a PeriodicSync object
 type PeriodicSyncReconciler struct {
 	client.Client
-	Log                logr.Logger
-	Scheme             *runtime.Scheme
-	CFClient           cf.ClientInterface
-	WorkloadsNamespace string
+	Log      logr.Logger
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+	// Syncers converge each resource type a PeriodicSync can select
+	Syncers map[appsv1alpha1.ResourceType]Syncer
+
+	Switch  *ControllerSwitch
+	Context context.Context
 }
 
 // +kubebuilder:rbac:groups=apps.cloudfoundry.org,resources=periodicsyncs,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=apps.cloudfoundry.org,resources=periodicsyncs/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups="",resources=events,verbs=create;patch
 
 func (r *PeriodicSyncReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
-	ctx := context.Background()
+	if !r.Switch.Admit(req) {
+		return ctrl.Result{}, nil
+	}
+
+	ctx := reconcileContext(r.Context)
 	_ = r.Log.WithValues("periodicsync", req.NamespacedName)
 
 	var periodicSync appsv1alpha1.PeriodicSync
@@ -69,127 +82,119 @@ func (r *PeriodicSyncReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error
 		return ctrl.Result{}, client.IgnoreNotFound(err)
 	}
 
-	ccRouteList, err := r.CFClient.ListRoutes()
-	if err != nil {
-		r.updateSyncStatusFailure(ctx, &periodicSync, err.Error())
-		return ctrl.Result{}, fmt.Errorf("error listing routes from CF API: %w", err)
-	}
+	startSync(&periodicSync)
 
-	cfRouteSelector, err := metav1.LabelSelectorAsSelector(&metav1.LabelSelector{
-		MatchLabels: map[string]string{kubernetes.KubeManagedByLabel: "cloudfoundry"},
-	})
-	if err != nil {
-		r.updateSyncStatusFailure(ctx, &periodicSync, err.Error())
-		return ctrl.Result{}, fmt.Errorf("error converting label selector: %w", err)
+	resourceType := periodicSync.SyncedResourceType()
+	logger := r.Log.WithValues("request", req.NamespacedName, "resourceType", resourceType)
+	syncer, ok := r.Syncers[resourceType]
+	if !ok {
+		// retrying can't help until the controller is reconfigured
+		err := fmt.Errorf("no syncer is configured for resource type %q", resourceType)
+		logger.Error(err, "unable to sync")
+		return ctrl.Result{}, r.updateSyncStatusFailure(ctx, &periodicSync, err.Error())
 	}
-	var routesInK8s networkingv1alpha1.RouteList
-	err = r.List(ctx, &routesInK8s, &client.ListOptions{Namespace: r.WorkloadsNamespace, LabelSelector: cfRouteSelector})
-	if err != nil {
+	kind := syncer.ResourceKind()
+
+	diff, err := diffRecovering(ctx, syncer, logger)
+	var incompleteListErr *cf.IncompleteListError
+	if err != nil && !errors.As(err, &incompleteListErr) {
 		r.updateSyncStatusFailure(ctx, &periodicSync, err.Error())
-		return ctrl.Result{}, fmt.Errorf("error listing routes from kubernetes API: %w", err)
+		return ctrl.Result{}, err
 	}
-
-	ccRouteMap := make(map[string]*model.Route)
-	for i, ccRoute := range ccRouteList.Resources {
-		ccRouteMap[ccRoute.GUID] = &ccRouteList.Resources[i]
+	// the syncer has already left out deletions if the list was incomplete
+	diffErr := err
+
+	periodicSync.Status.Plan = nil
+	if periodicSync.Spec.DryRun {
+		periodicSync.Status.Plan = planChanges(diff)
+		logger.WithValues(
+			"create", len(diff.Create),
+			"update", len(diff.Update),
+			"delete", len(diff.Delete),
+		).Info(fmt.Sprintf("dry run, not applying changes to %s resources", kind))
 	}
 
-	ccSpaceMap := make(map[string]*model.Space)
-	for i, ccSpace := range ccRouteList.Included.Spaces {
-		ccSpaceMap[ccSpace.GUID] = &ccRouteList.Included.Spaces[i]
+	var deletionThrottledMessage string
+	if budget := periodicSync.Spec.DeletionBudget; budget != nil && diffErr == nil {
+		if exceedsDeletionBudget(budget, len(diff.Delete), diff.Existing) {
+			deletionThrottledMessage = fmt.Sprintf(
+				"refusing to delete %d of %d %s resources, which exceeds the deletion budget (maxCount: %d, maxPercentage: %d)",
+				len(diff.Delete),
+				diff.Existing,
+				kind,
+				budget.MaxCount,
+				budget.MaxPercentage,
+			)
+			logger.Info(deletionThrottledMessage)
+			r.Recorder.Event(&periodicSync, corev1.EventTypeWarning, appsv1alpha1.DeletionThrottledConditionType, deletionThrottledMessage)
+			setPeriodicSyncCondition(&periodicSync, appsv1alpha1.DeletionThrottledConditionType, appsv1alpha1.TrueConditionStatus, appsv1alpha1.DeletionBudgetExceededConditionReason, deletionThrottledMessage)
+			diff.Delete = nil
+		} else {
+			setPeriodicSyncCondition(&periodicSync, appsv1alpha1.DeletionThrottledConditionType, appsv1alpha1.FalseConditionStatus, appsv1alpha1.WithinDeletionBudgetConditionReason, "")
+		}
 	}
 
-	ccDomainMap := make(map[string]*model.Domain)
-	for i, ccDomain := range ccRouteList.Included.Domains {
-		ccDomainMap[ccDomain.GUID] = &ccRouteList.Included.Domains[i]
+	// a dry run stops short of changing anything, once the deletion budget
+	// has been evaluated
+	if periodicSync.Spec.DryRun {
+		diff.Create, diff.Update, diff.Delete = nil, nil, nil
 	}
 
-	k8sRouteMap := make(map[string]networkingv1alpha1.Route)
-	for _, k8sRoute := range routesInK8s.Items {
-		k8sRouteMap[k8sRoute.Name] = k8sRoute
-	}
+	r.reportUnsupported(&periodicSync, resourceType, kind, diff.Unsupported, logger)
 
 	reconciledSuccessfully := true
 
-	var missingInK8s []*model.Route
-	for ccRouteGuid, ccRoute := range ccRouteMap {
-		if k8sRoute, ok := k8sRouteMap[ccRouteGuid]; ok {
-			spaceGUID := ccRoute.Relationships["space"].Data.GUID
-			domainGUID := ccRoute.Relationships["domain"].Data.GUID
-
-			desiredRoute := kubernetes.TranslateRoute(ccRoute, ccSpaceMap[spaceGUID], ccDomainMap[domainGUID], r.WorkloadsNamespace)
-
-			if kubernetes.CompareRoutes(desiredRoute, k8sRoute) {
-				continue
-			}
-
-			k8sRoute.Spec = desiredRoute.Spec
-			err = r.Update(ctx, &k8sRoute)
+	for _, failure := range diff.Failed {
+		reconciledSuccessfully = false
+		recordSyncFailure(&periodicSync, resourceType, failure.GUID)
+		logger.WithValues("guid", failure.GUID).Error(failure.Err, fmt.Sprintf("errored comparing %s resource", kind))
+	}
 
+	for _, action := range []struct {
+		changes []SyncChange
+		verb    string
+		metric  string
+		counter *int32
+	}{
+		{diff.Update, "updating", metrics.SyncActionUpdated, &periodicSync.Status.LastSyncResult.Updated},
+		{diff.Create, "creating", metrics.SyncActionCreated, &periodicSync.Status.LastSyncResult.Created},
+		{diff.Delete, "deleting", metrics.SyncActionDeleted, &periodicSync.Status.LastSyncResult.Deleted},
+	} {
+		for _, change := range action.changes {
+			err = change.Apply(ctx)
 			if err != nil {
 				reconciledSuccessfully = false
-				r.Log.WithValues("request", req.NamespacedName, "route_guid", k8sRoute.Name).Error(err, "errored updating Route resource in k8s")
+				recordSyncFailure(&periodicSync, resourceType, change.GUID)
+				logger.WithValues("guid", change.GUID).Error(err, fmt.Sprintf("errored %s %s resource", action.verb, kind))
 				continue
 			}
 
-			r.Log.WithValues("request", req.NamespacedName, "route_guid", k8sRoute.Name).Info("successfully updated Route resource")
-		} else {
-			// track the set of route GUIDs which need to be created in k8s
-			missingInK8s = append(missingInK8s, ccRoute)
-		}
-	}
-
-	// iterate over all routes to be created and create them
-	for _, ccRoute := range missingInK8s {
-		// fetch additional required information from CC (domain, space)
-		spaceGUID := ccRoute.Relationships["space"].Data.GUID
-		domainGUID := ccRoute.Relationships["domain"].Data.GUID
-
-		newRouteCR := kubernetes.TranslateRoute(ccRoute, ccSpaceMap[spaceGUID], ccDomainMap[domainGUID], r.WorkloadsNamespace)
-
-		err = r.Create(ctx, &newRouteCR)
-		if err != nil {
-			reconciledSuccessfully = false
-			r.Log.WithValues("request", req.NamespacedName, "route_guid", ccRoute.GUID).Error(err, "errored creating Route resource in k8s")
-			continue
+			*action.counter++
+			metrics.PeriodicSyncChanges.WithLabelValues(string(resourceType), action.metric).Inc()
+			logger.WithValues("guid", change.GUID).Info(fmt.Sprintf("successfully %s %s resource", action.metric, kind))
 		}
-
-		r.Log.WithValues("request", req.NamespacedName, "route_guid", ccRoute.GUID).Info("successfully created Route resource")
 	}
 
-	// calculate the set of route GUIDs which need to be deleted in k8s
-	var extraInK8s []string
-	for k8sRouteGuid, _ := range k8sRouteMap {
-		if _, ok := ccRouteMap[k8sRouteGuid]; !ok {
-			extraInK8s = append(extraInK8s, k8sRouteGuid)
-		}
-	}
-
-	// iterate over all routes to be deleted and delete them
-	for _, extraRouteGUID := range extraInK8s {
-		err = r.Delete(ctx, &networkingv1alpha1.Route{
-			ObjectMeta: metav1.ObjectMeta{
-				Name:      extraRouteGUID,
-				Namespace: r.WorkloadsNamespace,
-			},
-		})
-
-		// ignoring "not found" errors because the Route is already gone from k8s
-		if err != nil && !apierrors.IsNotFound(err) {
-			reconciledSuccessfully = false
-			r.Log.WithValues("request", req.NamespacedName, "route_guid", extraRouteGUID).Error(err, "errored deleting Route resource in k8s")
-			continue
-		}
-
-		r.Log.WithValues("request", req.NamespacedName, "route_guid", extraRouteGUID).Info("successfully deleted Route resource")
+	if diffErr != nil {
+		r.updateSyncStatusFailure(ctx, &periodicSync, diffErr.Error())
+		return ctrl.Result{}, diffErr
 	}
 
 	if !reconciledSuccessfully {
-		err := errors.New("failed to reconcile at least one route")
+		err := fmt.Errorf("failed to reconcile at least one %s", strings.ToLower(kind))
 		r.updateSyncStatusFailure(ctx, &periodicSync, err.Error())
 		return ctrl.Result{}, err
 	}
 
+	// a throttled sync is not retried early: the next sync will most likely be
+	// throttled too until an operator intervenes
+	if deletionThrottledMessage != "" {
+		if err := r.updateSyncStatusThrottled(ctx, &periodicSync, deletionThrottledMessage); err != nil {
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{RequeueAfter: time.Duration(periodicSync.Spec.PeriodSeconds) * time.Second}, nil
+	}
+
 	if err := r.updateSyncStatusSuccess(ctx, &periodicSync); err != nil {
 		return ctrl.Result{}, err
 	}
@@ -197,7 +202,30 @@ func (r *PeriodicSyncReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error
 }
 
 func (r *PeriodicSyncReconciler) updateSyncStatusSuccess(ctx context.Context, periodicSync *appsv1alpha1.PeriodicSync) error {
-	setPeriodicSyncStatus(periodicSync, appsv1alpha1.TrueConditionStatus, appsv1alpha1.CompletedConditionReason, "")
+	if plan := periodicSync.Status.Plan; plan != nil {
+		message := fmt.Sprintf(
+			"dry run: would create %d, update %d and delete %d resources",
+			plan.Create.Count,
+			plan.Update.Count,
+			plan.Delete.Count,
+		)
+		setPeriodicSyncStatus(periodicSync, appsv1alpha1.TrueConditionStatus, appsv1alpha1.DryRunConditionReason, message)
+	} else {
+		setPeriodicSyncStatus(periodicSync, appsv1alpha1.TrueConditionStatus, appsv1alpha1.CompletedConditionReason, "")
+	}
+	finishSync(periodicSync)
+	periodicSync.Status.LastSuccessfulSyncTime = periodicSync.Status.LastSyncTime
+
+	if err := r.Status().Update(ctx, periodicSync); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r *PeriodicSyncReconciler) updateSyncStatusThrottled(ctx context.Context, periodicSync *appsv1alpha1.PeriodicSync, throttledMessage string) error {
+	setPeriodicSyncStatus(periodicSync, appsv1alpha1.FalseConditionStatus, appsv1alpha1.DeletionBudgetExceededConditionReason, throttledMessage)
+	finishSync(periodicSync)
 
 	if err := r.Status().Update(ctx, periodicSync); err != nil {
 		return err
@@ -208,6 +236,7 @@ func (r *PeriodicSyncReconciler) updateSyncStatusSuccess(ctx context.Context, pe
 
 func (r *PeriodicSyncReconciler) updateSyncStatusFailure(ctx context.Context, periodicSync *appsv1alpha1.PeriodicSync, failureMessage string) error {
 	setPeriodicSyncStatus(periodicSync, appsv1alpha1.FalseConditionStatus, appsv1alpha1.FailedConditionReason, failureMessage)
+	finishSync(periodicSync)
 
 	if err := r.Status().Update(ctx, periodicSync); err != nil {
 		return err
@@ -216,20 +245,120 @@ func (r *PeriodicSyncReconciler) updateSyncStatusFailure(ctx context.Context, pe
 	return nil
 }
 
+// diffRecovering turns a panic in the syncer, e.g. on a malformed CF API
+// response, into a failed sync rather than letting it crash the manager
+func diffRecovering(ctx context.Context, syncer Syncer, logger logr.Logger) (diff SyncDiff, err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			diff, err = SyncDiff{}, fmt.Errorf("%s syncer panicked: %v", syncer.ResourceKind(), recovered)
+		}
+	}()
+	return syncer.Diff(ctx, logger)
+}
+
+// startSync resets the per-sync status fields. LastSyncTime keeps full
+// precision in memory so finishSync can derive the duration from it.
+func startSync(periodicSync *appsv1alpha1.PeriodicSync) {
+	now := metav1.Now()
+	periodicSync.Status.ObservedGeneration = periodicSync.Generation
+	periodicSync.Status.LastSyncTime = &now
+	periodicSync.Status.LastSyncResult = appsv1alpha1.SyncResult{}
+	periodicSync.Status.FailedGUIDs = nil
+	periodicSync.Status.UnsupportedGUIDs = nil
+}
+
+func finishSync(periodicSync *appsv1alpha1.PeriodicSync) {
+	if periodicSync.Status.LastSyncTime == nil {
+		return
+	}
+	periodicSync.Status.LastSyncDuration = &metav1.Duration{
+		Duration: time.Since(periodicSync.Status.LastSyncTime.Time),
+	}
+}
+
+func recordSyncFailure(periodicSync *appsv1alpha1.PeriodicSync, resourceType appsv1alpha1.ResourceType, guid string) {
+	periodicSync.Status.LastSyncResult.Failed++
+	metrics.PeriodicSyncChanges.WithLabelValues(string(resourceType), metrics.SyncActionFailed).Inc()
+	if len(periodicSync.Status.FailedGUIDs) < maxStatusGUIDs {
+		periodicSync.Status.FailedGUIDs = append(periodicSync.Status.FailedGUIDs, guid)
+	}
+}
+
+// reportUnsupported records the resources k8s can't fully express on the
+// status, and summarises them in a single event so as not to emit one per
+// resource every sync
+func (r *PeriodicSyncReconciler) reportUnsupported(periodicSync *appsv1alpha1.PeriodicSync, resourceType appsv1alpha1.ResourceType, kind string, unsupported []SyncFailure, logger logr.Logger) {
+	if len(unsupported) == 0 {
+		return
+	}
+	for _, resource := range unsupported {
+		periodicSync.Status.LastSyncResult.Unsupported++
+		metrics.PeriodicSyncChanges.WithLabelValues(string(resourceType), metrics.SyncActionUnsupported).Inc()
+		if len(periodicSync.Status.UnsupportedGUIDs) < maxStatusGUIDs {
+			periodicSync.Status.UnsupportedGUIDs = append(periodicSync.Status.UnsupportedGUIDs, resource.GUID)
+		}
+		logger.WithValues("guid", resource.GUID).Info(fmt.Sprintf("%s resource can't be fully synced", kind), "reason", resource.Err.Error())
+	}
+	message := fmt.Sprintf("%d %s resource(s) can't be fully synced, e.g. %s: %v", len(unsupported), kind, unsupported[0].GUID, unsupported[0].Err)
+	r.Recorder.Event(periodicSync, corev1.EventTypeWarning, appsv1alpha1.UnsupportedResourcesEventReason, message)
+}
+
 func setPeriodicSyncStatus(periodicSync *appsv1alpha1.PeriodicSync, status appsv1alpha1.ConditionStatus, reason, message string) {
-	periodicSync.Status.Conditions = []appsv1alpha1.Condition{
-		{
-			Type:               appsv1alpha1.SyncedConditionType,
-			Status:             status,
-			LastTransitionTime: metav1.Now(),
-			Reason:             reason,
-			Message:            message,
-		},
+	setPeriodicSyncCondition(periodicSync, appsv1alpha1.SyncedConditionType, status, reason, message)
+}
+
+// setPeriodicSyncCondition sets the PeriodicSync's condition of the given type
+func setPeriodicSyncCondition(periodicSync *appsv1alpha1.PeriodicSync, conditionType string, status appsv1alpha1.ConditionStatus, reason, message string) {
+	appsv1alpha1.SetCondition(&periodicSync.Status.Conditions, appsv1alpha1.Condition{
+		Type:    conditionType,
+		Status:  status,
+		Reason:  reason,
+		Message: message,
+	})
+}
+
+// planChanges records the changes a sync would make, listing at most
+// maxStatusGUIDs resources per action
+func planChanges(diff SyncDiff) *appsv1alpha1.PlannedChanges {
+	return &appsv1alpha1.PlannedChanges{
+		Create: newPlannedAction(diff.Create),
+		Update: newPlannedAction(diff.Update),
+		Delete: newPlannedAction(diff.Delete),
+	}
+}
+
+func newPlannedAction(changes []SyncChange) appsv1alpha1.PlannedAction {
+	guids := make([]string, 0, len(changes))
+	for _, change := range changes {
+		guids = append(guids, change.GUID)
+	}
+	sort.Strings(guids)
+
+	action := appsv1alpha1.PlannedAction{Count: int32(len(guids))}
+	if len(guids) > maxStatusGUIDs {
+		guids = guids[:maxStatusGUIDs]
+	}
+	if len(guids) > 0 {
+		action.GUIDs = guids
+	}
+	return action
+}
+
+// exceedsDeletionBudget reports whether deleting the given number of
+// resources out of total would go over either limit of the budget
+func exceedsDeletionBudget(budget *appsv1alpha1.DeletionBudget, deletions, total int) bool {
+	if budget.MaxCount > 0 && deletions > int(budget.MaxCount) {
+		return true
+	}
+	if budget.MaxPercentage > 0 && deletions*100 > int(budget.MaxPercentage)*total {
+		return true
 	}
+	return false
 }
 
 func (r *PeriodicSyncReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&appsv1alpha1.PeriodicSync{}).
+		Watches(r.Switch, new(handler.Funcs)).
 		Complete(r)
 }
@@ -0,0 +1,128 @@
+package controllers
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-logr/logr"
+	buildv1alpha1 "github.com/pivotal/kpack/pkg/apis/build/v1alpha1"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	"code.cloudfoundry.org/capi-k8s-release/src/cf-api-controllers/cf"
+	"code.cloudfoundry.org/capi-k8s-release/src/cf-api-controllers/cf/model"
+	"code.cloudfoundry.org/capi-k8s-release/src/cf-api-controllers/metrics"
+)
+
+// Image annotations tracking a rebase while the app is checked for readiness.
+// Removing StackRebaseRolledBackAnnotation lets the rebase be retried.
+const (
+	StackRebaseVerifyingAnnotation     = "cloudfoundry.org/stack_rebase_verifying_image"
+	StackRebasePreviousImageAnnotation = "cloudfoundry.org/stack_rebase_previous_image"
+	StackRebaseStartedAtAnnotation     = "cloudfoundry.org/stack_rebase_started_at"
+	StackRebaseRolledBackAnnotation    = "cloudfoundry.org/stack_rebase_rolled_back_image"
+)
+
+// RebaseRolledBackAnnotation is set on a droplet whose rebased image was
+// rolled back, recording that image
+const RebaseRolledBackAnnotation = "stack-rebase.cloudfoundry.org/rolled-back-image"
+
+// readinessPollInterval is how often a rebased app is checked for readiness
+const readinessPollInterval = 10 * time.Second
+
+// startVerifyingRebase marks a rebase that was rolled out to the app's
+// workloads and CC, so that the app is checked for readiness until either it
+// becomes ready or the readiness window passes
+func (r *ImageReconciler) startVerifyingRebase(ctx context.Context, image *buildv1alpha1.Image, previousImage string, logger logr.Logger) (ctrl.Result, error) {
+	if r.ReadinessWindow == 0 {
+		return ctrl.Result{}, r.markRebaseHandled(ctx, image)
+	}
+	if previousImage == "" || previousImage == image.Status.LatestImage {
+		logger.Info("Previous image of the droplet is unknown, the rebase can't be rolled back")
+		return ctrl.Result{}, r.markRebaseHandled(ctx, image)
+	}
+
+	err := r.annotateImage(ctx, image, map[string]string{
+		StackRebaseHandledAnnotation:       image.Status.LatestImage,
+		StackRebaseVerifyingAnnotation:     image.Status.LatestImage,
+		StackRebasePreviousImageAnnotation: previousImage,
+		StackRebaseStartedAtAnnotation:     time.Now().UTC().Format(time.RFC3339),
+	})
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{RequeueAfter: readinessPollInterval}, nil
+}
+
+// verifyRebase checks whether the app became ready on its rebased image,
+// rolling the app and its droplet back to the previous image once the
+// readiness window has passed without that happening
+func (r *ImageReconciler) verifyRebase(ctx context.Context, image *buildv1alpha1.Image, rebaser Rebaser, logger logr.Logger) (ctrl.Result, error) {
+	appGUID := image.Labels[AppGUIDLabel]
+
+	startedAt, err := time.Parse(time.RFC3339, image.Annotations[StackRebaseStartedAtAnnotation])
+	if err != nil {
+		logger.Error(err, "Invalid stack rebase start time, no longer checking the app for readiness")
+		return ctrl.Result{}, r.finishVerifyingRebase(ctx, image, nil)
+	}
+
+	ready, err := appStatefulSetsReady(r.AppsClientSet, r.WorkloadsNamespace, appGUID)
+	if err != nil {
+		logger.Error(err, "Could not check readiness of the app's statefulsets")
+		return ctrl.Result{}, err
+	}
+	if ready {
+		logger.WithValues("appGUID", appGUID).Info("App is ready on its rebased image")
+		return ctrl.Result{}, r.finishVerifyingRebase(ctx, image, nil)
+	}
+
+	remaining := r.ReadinessWindow - time.Since(startedAt)
+	if remaining > 0 {
+		if remaining > readinessPollInterval {
+			remaining = readinessPollInterval
+		}
+		return ctrl.Result{RequeueAfter: remaining}, nil
+	}
+
+	previousImage := image.Annotations[StackRebasePreviousImageAnnotation]
+	logger.WithValues("appGUID", appGUID, "previousImage", previousImage, "readinessWindow", r.ReadinessWindow).
+		Info("App did not become ready on its rebased image, rolling it back")
+
+	if _, err := rebaser.Rebase(ctx, appGUID, previousImage, logger); err != nil {
+		metrics.StackRebaseRollbacks.WithLabelValues(metrics.ResultFailure).Inc()
+		return ctrl.Result{}, err
+	}
+
+	dropletGUID := image.Labels[DropletGUIDLabel]
+	err = r.CFClient.UpdateDroplet(ctx, dropletGUID, model.Droplet{
+		Image: previousImage,
+		Metadata: &model.Metadata{Annotations: map[string]string{
+			RebaseRolledBackAnnotation: image.Status.LatestImage,
+		}},
+	})
+	if err != nil {
+		logger.Error(err, "Failed to roll back droplet in CF API")
+		if !cf.IsPermanent(err) {
+			metrics.StackRebaseRollbacks.WithLabelValues(metrics.ResultFailure).Inc()
+			return ctrl.Result{}, err
+		}
+	}
+
+	metrics.StackRebaseRollbacks.WithLabelValues(metrics.ResultSuccess).Inc()
+	return ctrl.Result{}, r.finishVerifyingRebase(ctx, image, map[string]string{
+		StackRebaseRolledBackAnnotation: image.Status.LatestImage,
+	})
+}
+
+// finishVerifyingRebase drops the readiness check's annotations from the
+// Image along with setting the given ones
+func (r *ImageReconciler) finishVerifyingRebase(ctx context.Context, image *buildv1alpha1.Image, annotations map[string]string) error {
+	patch := map[string]string{
+		StackRebaseVerifyingAnnotation:     "",
+		StackRebasePreviousImageAnnotation: "",
+		StackRebaseStartedAtAnnotation:     "",
+	}
+	for key, value := range annotations {
+		patch[key] = value
+	}
+	return r.annotateImage(ctx, image, patch)
+}
@@ -0,0 +1,87 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	buildv1alpha1 "github.com/pivotal/kpack/pkg/apis/build/v1alpha1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"code.cloudfoundry.org/capi-k8s-release/src/cf-api-controllers/cf/model"
+)
+
+// droplet annotations recording a stack rebase, so operators can audit which
+// image an app ran before a stack update and roll it back
+const (
+	RebasePreviousImageAnnotation = "stack-rebase.cloudfoundry.org/previous-image"
+	RebaseStackAnnotation         = "stack-rebase.cloudfoundry.org/stack"
+	RebaseRunImageAnnotation      = "stack-rebase.cloudfoundry.org/run-image"
+	RebaseBuildAnnotation         = "stack-rebase.cloudfoundry.org/kpack-build"
+	RebasedAtAnnotation           = "stack-rebase.cloudfoundry.org/rebased-at"
+)
+
+// ImageNotPinnedError is returned for a rebased image that isn't referenced by
+// digest, which CC must not be pointed at since a tag can move
+type ImageNotPinnedError struct {
+	Image string
+	Err   error
+}
+
+func (e *ImageNotPinnedError) Error() string {
+	return fmt.Sprintf("rebased image %q is not pinned by digest: %s", e.Image, e.Err)
+}
+
+func (e *ImageNotPinnedError) Unwrap() error {
+	return e.Err
+}
+
+func checkImagePinned(image string) error {
+	if _, err := name.NewDigest(image); err != nil {
+		return &ImageNotPinnedError{Image: image, Err: err}
+	}
+	return nil
+}
+
+// newRebasedDroplet builds the droplet update recording a stack rebase of the
+// Image: the digest-pinned image along with the droplet's previous image and
+// the stack and run image of the rebase as droplet annotations. The run image
+// is read from the Image's latest Build, and left out if that is gone.
+func newRebasedDroplet(ctx context.Context, reader client.Reader, image *buildv1alpha1.Image, previousImage string) (model.Droplet, error) {
+	latestImage := image.Status.LatestImage
+	if err := checkImagePinned(latestImage); err != nil {
+		return model.Droplet{}, err
+	}
+
+	annotations := map[string]string{
+		RebasedAtAnnotation: time.Now().UTC().Format(time.RFC3339),
+	}
+	// a retried update must not record the droplet's new image as its previous one
+	if previousImage != "" && previousImage != latestImage {
+		annotations[RebasePreviousImageAnnotation] = previousImage
+	}
+	if stack := image.Status.LatestStack; stack != "" {
+		annotations[RebaseStackAnnotation] = stack
+	}
+
+	if buildName := image.Status.LatestBuildRef; buildName != "" {
+		annotations[RebaseBuildAnnotation] = buildName
+
+		var build buildv1alpha1.Build
+		err := reader.Get(ctx, types.NamespacedName{Namespace: image.Namespace, Name: buildName}, &build)
+		if err != nil && !apierrors.IsNotFound(err) {
+			return model.Droplet{}, fmt.Errorf("failed to fetch latest build of image: %w", err)
+		}
+		if runImage := build.Status.Stack.RunImage; runImage != "" {
+			annotations[RebaseRunImageAnnotation] = runImage
+		}
+	}
+
+	return model.Droplet{
+		Image:    latestImage,
+		Metadata: &model.Metadata{Annotations: annotations},
+	}, nil
+}
@@ -0,0 +1,152 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-logr/logr"
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	typedappsv1 "k8s.io/client-go/kubernetes/typed/apps/v1"
+
+	"code.cloudfoundry.org/capi-k8s-release/src/cf-api-controllers/metrics"
+)
+
+// rebase targets that can be selected through config
+const (
+	StatefulSetRebaseTarget = "statefulset"
+	LRPRebaseTarget         = "lrp"
+)
+
+// Rebaser rolls an app's rebased image out to the workloads running it
+type Rebaser interface {
+	// Rebase points every workload of the app at the image, reporting whether
+	// the app had any. It must not leave some workloads rebased and others not.
+	Rebase(ctx context.Context, appGUID, image string, logger logr.Logger) (bool, error)
+}
+
+// StatefulSetRebaser updates the app container of the StatefulSets Eirini
+// created for the app
+type StatefulSetRebaser struct {
+	AppsClientSet      typedappsv1.StatefulSetsGetter
+	WorkloadsNamespace string
+}
+
+func (s *StatefulSetRebaser) Rebase(_ context.Context, appGUID, image string, logger logr.Logger) (bool, error) {
+	statefulsets, err := s.AppsClientSet.StatefulSets(s.WorkloadsNamespace).
+		List(metav1.ListOptions{LabelSelector: labels.Set{AppGUIDLabel: appGUID}.String()})
+	if err != nil {
+		logger.Error(err, "Could not find statefulsets for an app")
+		return false, err
+	}
+	if len(statefulsets.Items) == 0 {
+		return false, nil
+	}
+
+	// every process type of the app has its own StatefulSet, all of which must
+	// run the rebased image before CC is told about it
+	return true, s.updateStatefulSets(statefulsets.Items, image, logger)
+}
+
+// updateStatefulSets points the app container of each StatefulSet at the
+// rebased image, skipping those already running it. If any update fails, the
+// ones updated here are reverted so the app isn't left half rebased, and the
+// returned error requeues the Image to try them all again.
+func (s *StatefulSetRebaser) updateStatefulSets(statefulsets []appsv1.StatefulSet, latestImage string, logger logr.Logger) error {
+	var (
+		updated        []*appsv1.StatefulSet
+		previousImages []string
+		errs           []error
+	)
+	for i := range statefulsets {
+		statefulset := &statefulsets[i]
+		statefulsetLogger := logger.WithValues("statefulset", statefulset.Name)
+
+		previousImage := opiContainerImage(statefulset)
+		if !setOPIContainerImage(statefulset, latestImage) {
+			statefulsetLogger.V(1).Info("StatefulSet already runs the new image, skipping")
+			continue
+		}
+
+		updatedStatefulSet, err := s.AppsClientSet.StatefulSets(s.WorkloadsNamespace).Update(statefulset)
+		if err != nil {
+			metrics.StackRebaseStatefulSetUpdates.WithLabelValues(metrics.ResultFailure).Inc()
+			statefulsetLogger.Error(err, "Could not update statefulset")
+			errs = append(errs, fmt.Errorf("failed to update statefulset %s: %w", statefulset.Name, err))
+			continue
+		}
+		metrics.StackRebaseStatefulSetUpdates.WithLabelValues(metrics.ResultSuccess).Inc()
+		statefulsetLogger.WithValues("newImage", latestImage).Info("Successfully updated app's StatefulSet with new image based off new stack")
+		updated = append(updated, updatedStatefulSet)
+		previousImages = append(previousImages, previousImage)
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+
+	logger.WithValues("failed", len(errs), "updated", len(updated)).
+		Info("Failed to update some of the app's StatefulSets, reverting the others")
+	for i, statefulset := range updated {
+		setOPIContainerImage(statefulset, previousImages[i])
+		_, err := s.AppsClientSet.StatefulSets(s.WorkloadsNamespace).Update(statefulset)
+		if err != nil {
+			// the requeue will bring it forward to the new image instead
+			logger.WithValues("statefulset", statefulset.Name).Error(err, "Could not revert statefulset")
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// appStatefulSetsReady reports whether every StatefulSet of the app has rolled
+// out its latest spec to ready pods
+func appStatefulSetsReady(appsClientSet typedappsv1.StatefulSetsGetter, namespace, appGUID string) (bool, error) {
+	statefulsets, err := appsClientSet.StatefulSets(namespace).
+		List(metav1.ListOptions{LabelSelector: labels.Set{AppGUIDLabel: appGUID}.String()})
+	if err != nil {
+		return false, err
+	}
+
+	for _, statefulset := range statefulsets.Items {
+		if !statefulSetReady(statefulset) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func statefulSetReady(statefulset appsv1.StatefulSet) bool {
+	replicas := int32(1)
+	if statefulset.Spec.Replicas != nil {
+		replicas = *statefulset.Spec.Replicas
+	}
+	return statefulset.Status.ObservedGeneration >= statefulset.Generation &&
+		statefulset.Status.UpdatedReplicas == replicas &&
+		statefulset.Status.ReadyReplicas == replicas
+}
+
+// opiContainerImage returns the image of the app container of the StatefulSet
+func opiContainerImage(statefulset *appsv1.StatefulSet) string {
+	for _, container := range statefulset.Spec.Template.Spec.Containers {
+		if container.Name == "opi" {
+			return container.Image
+		}
+	}
+	return ""
+}
+
+// setOPIContainerImage points the app container of the StatefulSet at the
+// given image, reporting whether that changed anything
+func setOPIContainerImage(statefulset *appsv1.StatefulSet, image string) bool {
+	changed := false
+	containers := statefulset.Spec.Template.Spec.Containers
+	for i := range containers {
+		if containers[i].Name == "opi" && containers[i].Image != image {
+			containers[i].Image = image
+			changed = true
+		}
+	}
+	return changed
+}
@@ -0,0 +1,14 @@
+package controllers
+
+import "context"
+
+// reconcileContext is the context of a reconcile. It is the reconciler's
+// Context, which main cancels on manager shutdown so that requests to the CF
+// API and image registries in flight are abandoned, or a context that is never
+// cancelled when there is none.
+func reconcileContext(ctx context.Context) context.Context {
+	if ctx == nil {
+		return context.Background()
+	}
+	return ctx
+}
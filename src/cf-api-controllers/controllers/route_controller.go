@@ -0,0 +1,153 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"errors"
+	"reflect"
+
+	"github.com/go-logr/logr"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	"code.cloudfoundry.org/capi-k8s-release/src/cf-api-controllers/cf"
+	"code.cloudfoundry.org/capi-k8s-release/src/cf-api-controllers/cf/kubernetes"
+	networkingv1alpha1 "code.cloudfoundry.org/cf-k8s-networking/routecontroller/apis/networking/v1alpha1"
+)
+
+// RouteReconciler converges a single CF-managed Route with CC whenever it
+// changes or is deleted in k8s, so a drifted Route doesn't have to wait for
+// the next Route PeriodicSync, which remains responsible for Routes that
+// were never created in k8s
+type RouteReconciler struct {
+	client.Client
+	Log                logr.Logger
+	Scheme             *runtime.Scheme
+	CFClient           cf.ClientInterface
+	WorkloadsNamespace string
+	Switch             *ControllerSwitch
+	Context            context.Context
+}
+
+func (r *RouteReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
+	if !r.Switch.Admit(req) {
+		return ctrl.Result{}, nil
+	}
+
+	ctx := reconcileContext(r.Context)
+	logger := r.Log.WithValues("route", req.NamespacedName)
+
+	ccRoute, err := r.CFClient.GetRoute(ctx, req.Name)
+	if errors.Is(err, cf.ErrNotFound) {
+		return ctrl.Result{}, r.deleteRoute(ctx, req, logger)
+	}
+	if err != nil {
+		logger.Error(err, "failed to fetch route from CF API")
+		return ctrl.Result{}, err
+	}
+	if err := kubernetes.CheckRouteSupported(&ccRoute.Route); err != nil {
+		logger.Info("route can't be fully expressed as a Route", "reason", err.Error())
+	}
+	if !kubernetes.SupportsRoute(&ccRoute.Route) {
+		// the Route PeriodicSync reports it; deleting the Route would take
+		// down whatever routing was set up for it
+		return ctrl.Result{}, nil
+	}
+
+	space, organization, domain, err := newRouteRelations(r.CFClient, ccRoute.Included).resolve(ctx, &ccRoute.Route)
+	if err != nil {
+		logger.Error(err, "failed to resolve space and domain of route from CF API")
+		return ctrl.Result{}, err
+	}
+	desiredRoute := kubernetes.TranslateRoute(&ccRoute.Route, space, organization, domain, r.WorkloadsNamespace)
+
+	var actualRoute networkingv1alpha1.Route
+	err = r.Get(ctx, req.NamespacedName, &actualRoute)
+	if apierrors.IsNotFound(err) {
+		logger.Info("recreating Route that still exists in CF API")
+		return ctrl.Result{}, r.Create(ctx, &desiredRoute)
+	}
+	if err != nil {
+		logger.Error(err, "failed to fetch Route resource from cache")
+		return ctrl.Result{}, err
+	}
+
+	if kubernetes.CompareRoutes(desiredRoute, actualRoute) {
+		return ctrl.Result{}, nil
+	}
+
+	logger.Info("updating Route to match CF API")
+	kubernetes.ApplyRoute(desiredRoute, &actualRoute)
+	return ctrl.Result{}, r.Update(ctx, &actualRoute)
+}
+
+func (r *RouteReconciler) deleteRoute(ctx context.Context, req ctrl.Request, logger logr.Logger) error {
+	extraRoute := &networkingv1alpha1.Route{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      req.Name,
+			Namespace: req.Namespace,
+		},
+	}
+	// ignoring "not found" errors because the Route is already gone from k8s
+	err := r.Delete(ctx, extraRoute)
+	if err != nil && !apierrors.IsNotFound(err) {
+		logger.Error(err, "failed to delete Route that no longer exists in CF API")
+		return err
+	}
+	return nil
+}
+
+func (r *RouteReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(new(networkingv1alpha1.Route)).
+		WithEventFilter(r.EventFilter()).
+		Watches(r.Switch, new(handler.Funcs)).
+		Complete(r)
+}
+
+// EventFilter admits the events that may have taken a CF-managed Route away
+// from CC, each of which costs a request to CC
+func (r *RouteReconciler) EventFilter() predicate.Predicate {
+	return predicate.Funcs{
+		// every existing Route is "created" when the controller starts, which
+		// would fetch each of them from CC; the Route PeriodicSync covers those
+		CreateFunc: func(_ event.CreateEvent) bool { return false },
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			// routecontroller writing the status leaves the generation as it is
+			return r.routeFilter(e.MetaNew) &&
+				(e.MetaNew.GetGeneration() != e.MetaOld.GetGeneration() ||
+					!reflect.DeepEqual(e.MetaNew.GetLabels(), e.MetaOld.GetLabels()) ||
+					!reflect.DeepEqual(e.MetaNew.GetAnnotations(), e.MetaOld.GetAnnotations()))
+		},
+		DeleteFunc: func(e event.DeleteEvent) bool {
+			return r.routeFilter(e.Meta)
+		},
+		GenericFunc: func(_ event.GenericEvent) bool { return false },
+	}
+}
+
+func (r *RouteReconciler) routeFilter(meta metav1.Object) bool {
+	return meta.GetNamespace() == r.WorkloadsNamespace &&
+		meta.GetLabels()[kubernetes.KubeManagedByLabel] == "cloudfoundry"
+}
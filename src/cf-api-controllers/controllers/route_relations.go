@@ -0,0 +1,104 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	"code.cloudfoundry.org/capi-k8s-release/src/cf-api-controllers/cf"
+	"code.cloudfoundry.org/capi-k8s-release/src/cf-api-controllers/cf/model"
+)
+
+// routeRelations resolves the spaces, organizations and domains of CF routes.
+// It starts from those CC included alongside the routes and fetches any it
+// left out individually, each at most once.
+type routeRelations struct {
+	cfClient      cf.ClientInterface
+	spaces        map[string]*model.Space
+	organizations map[string]*model.Organization
+	domains       map[string]*model.Domain
+	// errs remembers the spaces and domains that failed to be fetched, so
+	// that the other routes in them fail without fetching them again
+	errs map[string]error
+}
+
+func newRouteRelations(cfClient cf.ClientInterface, included model.RouteListIncluded) *routeRelations {
+	r := &routeRelations{
+		cfClient:      cfClient,
+		spaces:        make(map[string]*model.Space),
+		organizations: make(map[string]*model.Organization),
+		domains:       make(map[string]*model.Domain),
+		errs:          make(map[string]error),
+	}
+	for i := range included.Spaces {
+		r.spaces[included.Spaces[i].GUID] = &included.Spaces[i]
+	}
+	for i := range included.Organizations {
+		r.organizations[included.Organizations[i].GUID] = &included.Organizations[i]
+	}
+	for i := range included.Domains {
+		r.domains[included.Domains[i].GUID] = &included.Domains[i]
+	}
+	return r
+}
+
+// resolve returns the space, organization and domain of route. The
+// organization is nil when CC didn't include it, since it only names the
+// Route's org.
+func (r *routeRelations) resolve(ctx context.Context, route *model.Route) (*model.Space, *model.Organization, *model.Domain, error) {
+	space, err := r.space(ctx, route.Relationships["space"].Data.GUID)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	domain, err := r.domain(ctx, route.Relationships["domain"].Data.GUID)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	organization := r.organizations[space.Relationships["organization"].Data.GUID]
+	return space, organization, domain, nil
+}
+
+func (r *routeRelations) space(ctx context.Context, guid string) (*model.Space, error) {
+	if guid == "" {
+		return nil, fmt.Errorf("CF API did not relate the route to a space")
+	}
+	if space, ok := r.spaces[guid]; ok {
+		return space, nil
+	}
+	key := "space/" + guid
+	if err, ok := r.errs[key]; ok {
+		return nil, err
+	}
+
+	response, err := r.cfClient.GetSpace(ctx, guid)
+	if err != nil {
+		r.errs[key] = fmt.Errorf("failed to resolve space %q: %w", guid, err)
+		return nil, r.errs[key]
+	}
+	space := &response.Space
+	r.spaces[guid] = space
+	for i := range response.Included.Organizations {
+		r.organizations[response.Included.Organizations[i].GUID] = &response.Included.Organizations[i]
+	}
+	return space, nil
+}
+
+func (r *routeRelations) domain(ctx context.Context, guid string) (*model.Domain, error) {
+	if guid == "" {
+		return nil, fmt.Errorf("CF API did not relate the route to a domain")
+	}
+	if domain, ok := r.domains[guid]; ok {
+		return domain, nil
+	}
+	key := "domain/" + guid
+	if err, ok := r.errs[key]; ok {
+		return nil, err
+	}
+
+	domain, err := r.cfClient.GetDomain(ctx, guid)
+	if err != nil {
+		r.errs[key] = fmt.Errorf("failed to resolve domain %q: %w", guid, err)
+		return nil, r.errs[key]
+	}
+	r.domains[guid] = &domain
+	return &domain, nil
+}
@@ -0,0 +1,145 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/go-logr/logr"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"code.cloudfoundry.org/capi-k8s-release/src/cf-api-controllers/cf"
+	"code.cloudfoundry.org/capi-k8s-release/src/cf-api-controllers/cf/kubernetes"
+	"code.cloudfoundry.org/capi-k8s-release/src/cf-api-controllers/cf/model"
+	networkingv1alpha1 "code.cloudfoundry.org/cf-k8s-networking/routecontroller/apis/networking/v1alpha1"
+)
+
+// RouteSyncer syncs CF routes to Route resources in the workloads namespace
+type RouteSyncer struct {
+	client.Client
+	CFClient           cf.ClientInterface
+	WorkloadsNamespace string
+}
+
+func (s *RouteSyncer) ResourceKind() string {
+	return "Route"
+}
+
+func (s *RouteSyncer) Diff(ctx context.Context, logger logr.Logger) (SyncDiff, error) {
+	ccRouteList, err := s.CFClient.ListRoutes(ctx)
+	var incompleteListErr *cf.IncompleteListError
+	if err != nil && !errors.As(err, &incompleteListErr) {
+		return SyncDiff{}, fmt.Errorf("error listing routes from CF API: %w", err)
+	}
+	if incompleteListErr != nil {
+		// anything missing from a partial list may still exist in CC, so we
+		// converge what we received but must not delete anything this cycle
+		logger.Error(err, "received incomplete route list from CF API, skipping deletion of Route resources")
+	}
+
+	cfRouteSelector, err := metav1.LabelSelectorAsSelector(&metav1.LabelSelector{
+		MatchLabels: map[string]string{kubernetes.KubeManagedByLabel: "cloudfoundry"},
+	})
+	if err != nil {
+		return SyncDiff{}, fmt.Errorf("error converting label selector: %w", err)
+	}
+	var routesInK8s networkingv1alpha1.RouteList
+	err = s.List(ctx, &routesInK8s, &client.ListOptions{Namespace: s.WorkloadsNamespace, LabelSelector: cfRouteSelector})
+	if err != nil {
+		return SyncDiff{}, fmt.Errorf("error listing routes from kubernetes API: %w", err)
+	}
+
+	ccRouteMap := make(map[string]*model.Route)
+	for i, ccRoute := range ccRouteList.Resources {
+		ccRouteMap[ccRoute.GUID] = &ccRouteList.Resources[i]
+	}
+
+	// CC may leave out a space or domain that routes refer to, e.g. one
+	// created while it was listing, so those are fetched individually
+	relations := newRouteRelations(s.CFClient, ccRouteList.Included)
+
+	k8sRouteMap := make(map[string]networkingv1alpha1.Route)
+	for _, k8sRoute := range routesInK8s.Items {
+		k8sRouteMap[k8sRoute.Name] = k8sRoute
+	}
+
+	diff := SyncDiff{Existing: len(k8sRouteMap)}
+	for ccRouteGuid, ccRoute := range ccRouteMap {
+		// the networking Route API has no place for the protocol or port of a
+		// route, nor the protocol of a destination. A route it can't route at
+		// all keeps any Route it has, since deleting that would take down
+		// whatever routing was set up for it; any other is synced without the
+		// parts it can't express.
+		if err := kubernetes.CheckRouteSupported(ccRoute); err != nil {
+			diff.Unsupported = append(diff.Unsupported, SyncFailure{GUID: ccRouteGuid, Err: err})
+		}
+		if !kubernetes.SupportsRoute(ccRoute) {
+			continue
+		}
+
+		space, organization, domain, err := relations.resolve(ctx, ccRoute)
+		if err != nil {
+			// the route still exists in CC, so its Route is left as it is
+			diff.Failed = append(diff.Failed, SyncFailure{GUID: ccRouteGuid, Err: err})
+			continue
+		}
+		desiredRoute := kubernetes.TranslateRoute(ccRoute, space, organization, domain, s.WorkloadsNamespace)
+
+		if k8sRoute, ok := k8sRouteMap[ccRouteGuid]; ok {
+			if kubernetes.CompareRoutes(desiredRoute, k8sRoute) {
+				continue
+			}
+
+			// track the set of routes which need to be updated in k8s
+			kubernetes.ApplyRoute(desiredRoute, &k8sRoute)
+			diff.Update = append(diff.Update, SyncChange{
+				GUID: ccRouteGuid,
+				Apply: func(ctx context.Context) error {
+					return s.Update(ctx, &k8sRoute)
+				},
+			})
+		} else {
+			// track the set of routes which need to be created in k8s
+			diff.Create = append(diff.Create, SyncChange{
+				GUID: ccRouteGuid,
+				Apply: func(ctx context.Context) error {
+					return s.Create(ctx, &desiredRoute)
+				},
+			})
+		}
+	}
+
+	// calculate the set of routes which need to be deleted in k8s
+	if incompleteListErr == nil {
+		for k8sRouteGuid := range k8sRouteMap {
+			if _, ok := ccRouteMap[k8sRouteGuid]; ok {
+				continue
+			}
+
+			extraRoute := &networkingv1alpha1.Route{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      k8sRouteGuid,
+					Namespace: s.WorkloadsNamespace,
+				},
+			}
+			diff.Delete = append(diff.Delete, SyncChange{
+				GUID: k8sRouteGuid,
+				Apply: func(ctx context.Context) error {
+					// ignoring "not found" errors because the Route is already gone from k8s
+					err := s.Delete(ctx, extraRoute)
+					if apierrors.IsNotFound(err) {
+						return nil
+					}
+					return err
+				},
+			})
+		}
+	}
+
+	if incompleteListErr != nil {
+		return diff, fmt.Errorf("error listing routes from CF API: %w", incompleteListErr)
+	}
+	return diff, nil
+}
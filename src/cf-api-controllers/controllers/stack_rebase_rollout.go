@@ -0,0 +1,212 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	typedappsv1 "k8s.io/client-go/kubernetes/typed/apps/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	appsv1alpha1 "code.cloudfoundry.org/capi-k8s-release/src/cf-api-controllers/apis/apps.cloudfoundry.org/v1alpha1"
+	"code.cloudfoundry.org/capi-k8s-release/src/cf-api-controllers/cf/model"
+)
+
+//go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 -o fake/cf_app_fetcher.go --fake-name CFAppFetcher . CfAppFetcher
+type CfAppFetcher interface {
+	GetApp(ctx context.Context, appGUID string) (model.AppResponse, error)
+}
+
+// rolloutPollInterval is how soon an Image held back by a paused rollout or a
+// full batch is reconciled again
+const rolloutPollInterval = 15 * time.Second
+
+type RolloutDecision int
+
+const (
+	// RolloutAdmitted means the app may be restarted onto its rebased image now
+	RolloutAdmitted RolloutDecision = iota
+	// RolloutOptedOut means the app's org or space opted out of stack rebases
+	RolloutOptedOut
+	// RolloutDeferred means the app has to wait for a later batch
+	RolloutDeferred
+)
+
+// StackRebaseRolloutPolicy paces restarts onto rebased images according to the
+// StackRebaseRollout called Name. Without that resource every app is admitted
+// right away.
+type StackRebaseRolloutPolicy struct {
+	client.Client
+	CFClient           CfAppFetcher
+	AppsClientSet      typedappsv1.StatefulSetsGetter
+	WorkloadsNamespace string
+	Name               types.NamespacedName
+}
+
+// +kubebuilder:rbac:groups=apps.cloudfoundry.org,resources=stackrebaserollouts,verbs=get;list;watch
+// +kubebuilder:rbac:groups=apps.cloudfoundry.org,resources=stackrebaserollouts/status,verbs=get;update;patch
+
+// Admit decides whether the app can be restarted onto its rebased image,
+// recording the decision on the rollout's status. A deferred app should be
+// reconciled again after the returned duration.
+func (p *StackRebaseRolloutPolicy) Admit(ctx context.Context, appGUID string, logger logr.Logger) (RolloutDecision, time.Duration, error) {
+	var rollout appsv1alpha1.StackRebaseRollout
+	err := p.Get(ctx, p.Name, &rollout)
+	if apierrors.IsNotFound(err) {
+		logger.V(1).Info("No StackRebaseRollout found, rebasing without a rollout policy", "rollout", p.Name)
+		return RolloutAdmitted, 0, nil
+	}
+	if err != nil {
+		logger.Error(err, "Failed to fetch StackRebaseRollout", "rollout", p.Name)
+		return RolloutDeferred, 0, err
+	}
+	original := rollout.Status.DeepCopy()
+	status := &rollout.Status
+	status.ObservedGeneration = rollout.Generation
+
+	// a retry of an app whose restart was already admitted, e.g. after a
+	// failed StatefulSet update, doesn't take another slot in the batch
+	if containsString(status.RestartingAppGUIDs, appGUID) {
+		return RolloutAdmitted, 0, nil
+	}
+
+	decision, wait, err := p.decide(ctx, &rollout, appGUID)
+	if err != nil {
+		logger.Error(err, "Failed to apply StackRebaseRollout", "rollout", p.Name)
+		return RolloutDeferred, 0, err
+	}
+
+	if !equality.Semantic.DeepEqual(original, status) {
+		if err := p.Status().Update(ctx, &rollout); err != nil {
+			logger.Error(err, "Failed to update StackRebaseRollout status", "rollout", p.Name)
+			return RolloutDeferred, 0, err
+		}
+	}
+	return decision, wait, nil
+}
+
+func (p *StackRebaseRolloutPolicy) decide(ctx context.Context, rollout *appsv1alpha1.StackRebaseRollout, appGUID string) (RolloutDecision, time.Duration, error) {
+	spec := rollout.Spec
+	status := &rollout.Status
+
+	if spec.Paused {
+		setRolloutCondition(rollout, appsv1alpha1.FalseConditionStatus, appsv1alpha1.PausedConditionReason, "rollout is paused")
+		return RolloutDeferred, rolloutPollInterval, nil
+	}
+
+	optedOut, err := p.optedOut(ctx, appGUID)
+	if err != nil {
+		return RolloutDeferred, 0, err
+	}
+	if optedOut {
+		status.OptedOut++
+		return RolloutOptedOut, 0, nil
+	}
+
+	if err := p.finishRestarts(rollout); err != nil {
+		return RolloutDeferred, 0, err
+	}
+
+	if status.BatchRestarts >= spec.MaxConcurrentRestarts {
+		message := fmt.Sprintf("waiting for %d app(s) of the current batch to restart", len(status.RestartingAppGUIDs))
+		setRolloutCondition(rollout, appsv1alpha1.TrueConditionStatus, appsv1alpha1.BatchFullConditionReason, message)
+		return RolloutDeferred, rolloutPollInterval, nil
+	}
+
+	if status.BatchRestarts == 0 && status.LastBatchFinishTime != nil {
+		pause := time.Duration(spec.PauseBetweenBatchesSeconds) * time.Second
+		if remaining := pause - time.Since(status.LastBatchFinishTime.Time); remaining > 0 {
+			message := fmt.Sprintf("pausing %s between batches", pause)
+			setRolloutCondition(rollout, appsv1alpha1.TrueConditionStatus, appsv1alpha1.BetweenBatchesConditionReason, message)
+			return RolloutDeferred, remaining, nil
+		}
+	}
+
+	if status.BatchRestarts == 0 {
+		now := metav1.Now()
+		status.BatchStartTime = &now
+	}
+	status.BatchRestarts++
+	status.RestartingAppGUIDs = append(status.RestartingAppGUIDs, appGUID)
+	status.Rebased++
+	status.LastRebasedAppGUID = appGUID
+	message := fmt.Sprintf("restarted %d of at most %d app(s) in the current batch", status.BatchRestarts, spec.MaxConcurrentRestarts)
+	setRolloutCondition(rollout, appsv1alpha1.TrueConditionStatus, appsv1alpha1.ProgressingConditionReason, message)
+	return RolloutAdmitted, 0, nil
+}
+
+// optedOut reports whether the app's space or org carries the opt-out label
+func (p *StackRebaseRolloutPolicy) optedOut(ctx context.Context, appGUID string) (bool, error) {
+	app, err := p.CFClient.GetApp(ctx, appGUID)
+	if err != nil {
+		return false, err
+	}
+
+	spaceGUID := app.Relationships["space"].Data.GUID
+	for _, space := range app.Included.Spaces {
+		if space.GUID != spaceGUID {
+			continue
+		}
+		if space.Metadata.Labels[appsv1alpha1.StackRebaseOptOutLabel] == "true" {
+			return true, nil
+		}
+
+		orgGUID := space.Relationships["organization"].Data.GUID
+		for _, org := range app.Included.Organizations {
+			if org.GUID == orgGUID && org.Metadata.Labels[appsv1alpha1.StackRebaseOptOutLabel] == "true" {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// finishRestarts drops the apps whose StatefulSets are all ready from the
+// current batch, finishing the batch once it is full and none are left
+func (p *StackRebaseRolloutPolicy) finishRestarts(rollout *appsv1alpha1.StackRebaseRollout) error {
+	status := &rollout.Status
+
+	var restarting []string
+	for _, appGUID := range status.RestartingAppGUIDs {
+		ready, err := appStatefulSetsReady(p.AppsClientSet, p.WorkloadsNamespace, appGUID)
+		if err != nil {
+			return err
+		}
+		if !ready {
+			restarting = append(restarting, appGUID)
+		}
+	}
+	status.RestartingAppGUIDs = restarting
+
+	if len(restarting) == 0 && status.BatchRestarts >= rollout.Spec.MaxConcurrentRestarts {
+		now := metav1.Now()
+		status.LastBatchFinishTime = &now
+		status.BatchStartTime = nil
+		status.BatchRestarts = 0
+	}
+	return nil
+}
+
+// setRolloutCondition sets the rollout's RollingOut condition
+func setRolloutCondition(rollout *appsv1alpha1.StackRebaseRollout, status appsv1alpha1.ConditionStatus, reason, message string) {
+	appsv1alpha1.SetCondition(&rollout.Status.Conditions, appsv1alpha1.Condition{
+		Type:    appsv1alpha1.RollingOutConditionType,
+		Status:  status,
+		Reason:  reason,
+		Message: message,
+	})
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -25,6 +25,7 @@ import (
 
 	buildv1alpha1 "github.com/pivotal/kpack/pkg/apis/build/v1alpha1"
 	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"sigs.k8s.io/controller-runtime/pkg/envtest/printer"
 
 	"github.com/matt-royal/biloba"
@@ -125,15 +126,13 @@ var _ = BeforeSuite(func(done Done) {
 	mockImageConfigFetcher = image_registryfakes.FakeImageConfigFetcher{}
 
 	fakeCFAPIServer = ghttp.NewServer()
-	cfClient = *cf.NewClient(fakeCFAPIServer.URL(), &cf.RestClient{
-		Client: &http.Client{
-			Transport: &http.Transport{
-				TLSClientConfig: &tls.Config{
-					InsecureSkipVerify: true,
-				},
+	cfClient = *cf.NewClient(cf.NewRestClient(fakeCFAPIServer.URL(), &http.Client{
+		Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{
+				InsecureSkipVerify: true,
 			},
 		},
-	}, &mockUAAClient)
+	}, &mockUAAClient))
 
 	// start controller with manager
 	// TODO: refactor to remove mocks since this is an integration test
@@ -160,11 +159,17 @@ var _ = BeforeSuite(func(done Done) {
 
 	fakeCFClient = new(cffakes.FakeClientInterface)
 	err = (&PeriodicSyncReconciler{
-		Client:             k8sManager.GetClient(),
-		Log:                ctrl.Log.WithName("controllers").WithName("PeriodicSync"),
-		Scheme:             k8sManager.GetScheme(),
-		CFClient:           fakeCFClient,
-		WorkloadsNamespace: workloadsNamespace,
+		Client:   k8sManager.GetClient(),
+		Log:      ctrl.Log.WithName("controllers").WithName("PeriodicSync"),
+		Scheme:   k8sManager.GetScheme(),
+		Recorder: k8sManager.GetEventRecorderFor("periodicsync-controller"),
+		Syncers: map[appsv1alpha1.ResourceType]Syncer{
+			appsv1alpha1.RouteResourceType: &RouteSyncer{
+				Client:             k8sManager.GetClient(),
+				CFClient:           fakeCFClient,
+				WorkloadsNamespace: workloadsNamespace,
+			},
+		},
 	}).SetupWithManager(k8sManager)
 	Expect(err).ToNot(HaveOccurred())
 
@@ -214,6 +219,13 @@ var _ = AfterEach(func() {
 		Expect(k8sClient.DeleteAllOf(ctx, new(v1alpha1.PeriodicSync), client.InNamespace(workloadsNamespace))).To(Succeed())
 	}
 
+	lrpList := new(unstructured.UnstructuredList)
+	lrpList.SetGroupVersionKind(LRPGroupVersionKind.GroupVersion().WithKind("LRPList"))
+	Expect(k8sClient.List(ctx, lrpList, client.InNamespace(workloadsNamespace))).To(Succeed())
+	for i := range lrpList.Items {
+		Expect(k8sClient.Delete(ctx, &lrpList.Items[i])).To(Succeed())
+	}
+
 	rList := new(networkingv1alpha1.RouteList)
 	Expect(k8sClient.List(ctx, rList, client.InNamespace(workloadsNamespace))).To(Succeed())
 	if len(rList.Items) > 0 {
@@ -0,0 +1,53 @@
+package controllers
+
+import (
+	"context"
+
+	"github.com/go-logr/logr"
+)
+
+// Syncer compares one type of resource in the CF API against its
+// representation in k8s, for the PeriodicSyncReconciler to converge. The
+// reconciler owns everything common to all resource types: dry runs, the
+// deletion budget, status and metrics.
+type Syncer interface {
+	// ResourceKind names the synced k8s resource in messages, e.g. "Route"
+	ResourceKind() string
+
+	// Diff computes the changes needed to make k8s match the CF API. When the
+	// CF API could only be read in part, it returns the changes found so far
+	// with no deletions, alongside an error wrapping *cf.IncompleteListError.
+	Diff(ctx context.Context, logger logr.Logger) (SyncDiff, error)
+}
+
+// SyncDiff is the set of changes a Syncer found
+type SyncDiff struct {
+	Create []SyncChange
+	Update []SyncChange
+	Delete []SyncChange
+
+	// Failed lists the resources that could not be compared, which fail the
+	// sync without stopping the other changes from being applied
+	Failed []SyncFailure
+
+	// Unsupported lists the resources k8s can't fully express, with what it
+	// can't. They are synced as far as they can be, or left as they are,
+	// without failing the sync.
+	Unsupported []SyncFailure
+
+	// Existing is the number of synced resources in k8s, which the deletion
+	// budget's percentage is relative to
+	Existing int
+}
+
+// SyncChange applies a single change to the resource identified by GUID
+type SyncChange struct {
+	GUID  string
+	Apply func(ctx context.Context) error
+}
+
+// SyncFailure records why the resource identified by GUID could not be synced
+type SyncFailure struct {
+	GUID string
+	Err  error
+}
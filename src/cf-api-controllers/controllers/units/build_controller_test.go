@@ -2,7 +2,11 @@ package units_test
 
 import (
 	"context"
+	"errors"
+	"net/http"
+	"time"
 
+	"code.cloudfoundry.org/capi-k8s-release/src/cf-api-controllers/cf"
 	"code.cloudfoundry.org/capi-k8s-release/src/cf-api-controllers/cf/model"
 
 	. "github.com/onsi/gomega"
@@ -15,6 +19,7 @@ import (
 
 	corev1alpha1 "github.com/pivotal/kpack/pkg/apis/core/v1alpha1"
 
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	. "code.cloudfoundry.org/capi-k8s-release/src/cf-api-controllers/controllers"
@@ -113,7 +118,7 @@ var _ = Describe("BuildController", func() {
 
 				Expect(cfBuildUpdater.UpdateBuildCallCount()).To(Equal(1))
 
-				actualBuildGUID, updateBuildRequest := cfBuildUpdater.UpdateBuildArgsForCall(0)
+				_, actualBuildGUID, updateBuildRequest := cfBuildUpdater.UpdateBuildArgsForCall(0)
 				Expect(actualBuildGUID).To(Equal(buildGUID))
 				Expect(updateBuildRequest).To(Equal(model.Build{
 					State: "STAGED",
@@ -128,6 +133,283 @@ var _ = Describe("BuildController", func() {
 					},
 				}))
 			})
+
+			When("the build and image record its buildpacks and stack", func() {
+				BeforeEach(func() {
+					build.Status.BuildMetadata = buildv1alpha1.BuildpackMetadataList{
+						{Id: "paketo-buildpacks/node-engine", Version: "0.0.1", Homepage: "https://github.com/paketo-buildpacks/node-engine"},
+						{Id: "paketo-buildpacks/npm", Version: "0.0.2"},
+					}
+					build.Status.Stack = buildv1alpha1.BuildStack{
+						ID:       "io.buildpacks.stacks.bionic",
+						RunImage: "run-image@sha256:123",
+					}
+
+					imageConfigFetcher.FetchImageConfigReturns(&v1.Config{
+						Labels: map[string]string{
+							lifecycle.BuildMetadataLabel: `{"processes": [{"type": "web", "command": "npm start"}],` +
+								`"buildpacks": [{"id": "paketo-buildpacks/node-engine", "version": "0.0.1"}, {"id": "paketo-buildpacks/npm", "version": "0.0.2"}]}`,
+							lifecycle.StackIDLabel: "some-other-stack",
+						},
+					}, nil)
+				})
+
+				It("sends them to CC", func() {
+					_, err := reconciler.Reconcile(request)
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(cfBuildUpdater.UpdateBuildCallCount()).To(Equal(1))
+					_, _, updateBuildRequest := cfBuildUpdater.UpdateBuildArgsForCall(0)
+					Expect(updateBuildRequest.Lifecycle.Data).To(Equal(model.LifecycleData{
+						Image:        latestImage,
+						ProcessTypes: map[string]string{"web": "npm start"},
+						Buildpacks: []model.Buildpack{
+							{ID: "paketo-buildpacks/node-engine", Version: "0.0.1", Homepage: "https://github.com/paketo-buildpacks/node-engine"},
+							{ID: "paketo-buildpacks/npm", Version: "0.0.2"},
+						},
+						Stack:    "io.buildpacks.stacks.bionic",
+						RunImage: "run-image@sha256:123",
+					}))
+				})
+
+				When("the Build status has no stack", func() {
+					BeforeEach(func() {
+						build.Status.Stack = buildv1alpha1.BuildStack{}
+						imageConfigFetcher.FetchImageConfigReturns(&v1.Config{
+							Labels: map[string]string{
+								lifecycle.BuildMetadataLabel: `{"processes": []}`,
+								lifecycle.StackIDLabel:       "io.buildpacks.stacks.bionic",
+								lifecycle.LayerMetadataLabel: `{"runImage": {"reference": "run-image@sha256:456"}}`,
+							},
+						}, nil)
+					})
+
+					It("sends the stack recorded in the image", func() {
+						_, err := reconciler.Reconcile(request)
+						Expect(err).NotTo(HaveOccurred())
+
+						_, _, updateBuildRequest := cfBuildUpdater.UpdateBuildArgsForCall(0)
+						Expect(updateBuildRequest.Lifecycle.Data.Stack).To(Equal("io.buildpacks.stacks.bionic"))
+						Expect(updateBuildRequest.Lifecycle.Data.RunImage).To(Equal("run-image@sha256:456"))
+						Expect(updateBuildRequest.Lifecycle.Data.Buildpacks).To(HaveLen(2))
+					})
+				})
+			})
+
+			When("updating the build in CC fails", func() {
+				BeforeEach(func() {
+					reconciler.MaxUpdateAttempts = 3
+					reconciler.UpdateBaseDelay = 2 * time.Second
+					cfBuildUpdater.UpdateBuildReturns(errors.New("cc is down"))
+				})
+
+				It("records the attempt on the Build and requeues with backoff", func() {
+					result, err := reconciler.Reconcile(request)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(result).To(Equal(ctrl.Result{RequeueAfter: 2 * time.Second}))
+
+					Expect(client.PatchCallCount()).To(Equal(1))
+					_, patchedObject, _, _ := client.PatchArgsForCall(0)
+					Expect(patchedObject.(*buildv1alpha1.Build).Annotations).To(HaveKeyWithValue(BuildUpdateAttemptsAnnotation, "1"))
+				})
+
+				When("the build has already been retried", func() {
+					BeforeEach(func() {
+						build.Annotations = map[string]string{BuildUpdateAttemptsAnnotation: "1"}
+					})
+
+					It("doubles the delay", func() {
+						result, err := reconciler.Reconcile(request)
+						Expect(err).NotTo(HaveOccurred())
+						Expect(result).To(Equal(ctrl.Result{RequeueAfter: 4 * time.Second}))
+
+						_, patchedObject, _, _ := client.PatchArgsForCall(0)
+						Expect(patchedObject.(*buildv1alpha1.Build).Annotations).To(HaveKeyWithValue(BuildUpdateAttemptsAnnotation, "2"))
+					})
+				})
+
+				When("this is the last attempt", func() {
+					BeforeEach(func() {
+						build.Annotations = map[string]string{BuildUpdateAttemptsAnnotation: "2"}
+						cfBuildUpdater.UpdateBuildReturnsOnCall(1, nil)
+					})
+
+					It("marks the CC build as failed and stops requeueing", func() {
+						result, err := reconciler.Reconcile(request)
+						Expect(err).NotTo(HaveOccurred())
+						Expect(result).To(Equal(ctrl.Result{}))
+
+						Expect(cfBuildUpdater.UpdateBuildCallCount()).To(Equal(2))
+						_, actualBuildGUID, updateBuildRequest := cfBuildUpdater.UpdateBuildArgsForCall(1)
+						Expect(actualBuildGUID).To(Equal(buildGUID))
+						Expect(updateBuildRequest).To(Equal(model.Build{
+							State: model.BuildFailedState,
+							Error: "Failed to update build in CF API after 3 attempts: cc is down",
+						}))
+					})
+				})
+
+				When("the attempts have already been exhausted", func() {
+					BeforeEach(func() {
+						build.Annotations = map[string]string{BuildUpdateAttemptsAnnotation: "3"}
+					})
+
+					It("does not contact CC again", func() {
+						result, err := reconciler.Reconcile(request)
+						Expect(err).NotTo(HaveOccurred())
+						Expect(result).To(Equal(ctrl.Result{}))
+
+						Expect(cfBuildUpdater.UpdateBuildCallCount()).To(Equal(0))
+						Expect(client.PatchCallCount()).To(Equal(0))
+					})
+				})
+
+				When("CC rejects the update permanently", func() {
+					BeforeEach(func() {
+						cfBuildUpdater.UpdateBuildReturnsOnCall(0, &cf.APIError{StatusCode: http.StatusUnprocessableEntity})
+						cfBuildUpdater.UpdateBuildReturnsOnCall(1, nil)
+					})
+
+					It("marks the CC build as failed without retrying", func() {
+						result, err := reconciler.Reconcile(request)
+						Expect(err).NotTo(HaveOccurred())
+						Expect(result).To(Equal(ctrl.Result{}))
+						Expect(client.PatchCallCount()).To(Equal(0))
+
+						Expect(cfBuildUpdater.UpdateBuildCallCount()).To(Equal(2))
+						_, _, updateBuildRequest := cfBuildUpdater.UpdateBuildArgsForCall(1)
+						Expect(updateBuildRequest.State).To(Equal(model.BuildFailedState))
+						Expect(updateBuildRequest.Error).To(Equal("Failed to update build in CF API: received status 422"))
+					})
+				})
+
+				When("the CC build no longer exists", func() {
+					BeforeEach(func() {
+						cfBuildUpdater.UpdateBuildReturns(&cf.APIError{StatusCode: http.StatusNotFound})
+					})
+
+					It("gives up on the build", func() {
+						result, err := reconciler.Reconcile(request)
+						Expect(err).NotTo(HaveOccurred())
+						Expect(result).To(Equal(ctrl.Result{}))
+						Expect(client.PatchCallCount()).To(Equal(0))
+						Expect(cfBuildUpdater.UpdateBuildCallCount()).To(Equal(1))
+					})
+				})
+
+				When("recording the attempt fails", func() {
+					BeforeEach(func() {
+						client.PatchReturns(errors.New("patch failed"))
+					})
+
+					It("returns the error", func() {
+						_, err := reconciler.Reconcile(request)
+						Expect(err).To(MatchError("patch failed"))
+					})
+				})
+			})
+		})
+
+		When("a build fails during a step", func() {
+			var (
+				reconciler     *BuildReconciler
+				client         *fake.ControllerRuntimeClient
+				cfBuildUpdater *fake.CFBuildUpdater
+				logFetcher     *fake.BuildLogFetcher
+				request        ctrl.Request
+			)
+
+			const (
+				buildNamespace = "cf-workloads-staging"
+				buildName      = "some-build"
+				buildGUID      = "build-guid"
+				podName        = "some-build-pod"
+			)
+
+			BeforeEach(func() {
+				client = new(fake.ControllerRuntimeClient)
+				cfBuildUpdater = new(fake.CFBuildUpdater)
+				logFetcher = new(fake.BuildLogFetcher)
+				logFetcher.FetchStepLogsReturns("ERROR: No buildpack groups passed detection.", nil)
+
+				reconciler = &BuildReconciler{
+					Client:     client,
+					CFClient:   cfBuildUpdater,
+					LogFetcher: logFetcher,
+					Log:        logrTesting.NullLogger{},
+				}
+				request = ctrl.Request{
+					NamespacedName: types.NamespacedName{
+						Namespace: buildNamespace,
+						Name:      buildName,
+					},
+				}
+				build := buildv1alpha1.Build{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      buildName,
+						Namespace: buildNamespace,
+						Labels: map[string]string{
+							BuildGUIDLabel: buildGUID,
+						},
+					},
+					Status: buildv1alpha1.BuildStatus{
+						PodName:        podName,
+						StepsCompleted: []string{"prepare", "detect"},
+						StepStates: []corev1.ContainerState{
+							{Terminated: &corev1.ContainerStateTerminated{ExitCode: 0}},
+							{Terminated: &corev1.ContainerStateTerminated{ExitCode: 100, Reason: "Error", Message: "detect failed"}},
+						},
+						Status: corev1alpha1.Status{
+							Conditions: corev1alpha1.Conditions{
+								{Type: "Succeeded", Status: "False"},
+							},
+						},
+					},
+				}
+
+				client.GetCalls(func(ctx context.Context, name types.NamespacedName, object runtime.Object) error {
+					ptr := object.(*buildv1alpha1.Build)
+					*ptr = build
+					return nil
+				})
+			})
+
+			It("reports the failed step and the end of its output to CC", func() {
+				_, err := reconciler.Reconcile(request)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(logFetcher.FetchStepLogsCallCount()).To(Equal(1))
+				namespace, pod, step := logFetcher.FetchStepLogsArgsForCall(0)
+				Expect(namespace).To(Equal(buildNamespace))
+				Expect(pod).To(Equal(podName))
+				Expect(step).To(Equal("detect"))
+
+				Expect(cfBuildUpdater.UpdateBuildCallCount()).To(Equal(1))
+				_, actualBuildGUID, updateBuildRequest := cfBuildUpdater.UpdateBuildArgsForCall(0)
+				Expect(actualBuildGUID).To(Equal(buildGUID))
+				Expect(updateBuildRequest.State).To(Equal("FAILED"))
+				Expect(updateBuildRequest.Error).To(Equal(
+					"Kpack build failed during container execution: Step 'detect' failure reason: 'Error', message: 'detect failed'." +
+						" Last lines of step output:\nERROR: No buildpack groups passed detection.",
+				))
+			})
+
+			When("the step output cannot be fetched", func() {
+				BeforeEach(func() {
+					logFetcher.FetchStepLogsReturns("", errors.New("pod is gone"))
+				})
+
+				It("still reports the failed step to CC", func() {
+					_, err := reconciler.Reconcile(request)
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(cfBuildUpdater.UpdateBuildCallCount()).To(Equal(1))
+					_, _, updateBuildRequest := cfBuildUpdater.UpdateBuildArgsForCall(0)
+					Expect(updateBuildRequest.Error).To(Equal(
+						"Kpack build failed during container execution: Step 'detect' failure reason: 'Error', message: 'detect failed'.",
+					))
+				})
+			})
 		})
 	})
 })
@@ -0,0 +1,255 @@
+package units_test
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	appsv1alpha1 "code.cloudfoundry.org/capi-k8s-release/src/cf-api-controllers/apis/apps.cloudfoundry.org/v1alpha1"
+	"code.cloudfoundry.org/capi-k8s-release/src/cf-api-controllers/cf"
+	. "code.cloudfoundry.org/capi-k8s-release/src/cf-api-controllers/controllers"
+	"code.cloudfoundry.org/capi-k8s-release/src/cf-api-controllers/controllers/fake"
+	"code.cloudfoundry.org/capi-k8s-release/src/cf-api-controllers/image_registry"
+	logrTesting "github.com/go-logr/logr/testing"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/workqueue"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+var _ = Describe("ControllersConfigReconciler", func() {
+	var (
+		reconciler *ControllersConfigReconciler
+		client     *fake.ControllerRuntimeClient
+		apiReader  *fake.ControllerRuntimeClient
+		config     *appsv1alpha1.CFAPIControllersConfig
+		secret     *corev1.Secret
+		applied    []ControllersSettings
+		applyErr   error
+		result     ctrl.Result
+		err        error
+	)
+
+	BeforeEach(func() {
+		client = new(fake.ControllerRuntimeClient)
+		client.StatusReturns(client)
+		apiReader = new(fake.ControllerRuntimeClient)
+
+		disabled := false
+		config = &appsv1alpha1.CFAPIControllersConfig{
+			ObjectMeta: metav1.ObjectMeta{Name: "cf-api-controllers", Generation: 3},
+			Spec: appsv1alpha1.CFAPIControllersConfigSpec{
+				CFAPIHost: "https://capi.cf-system.svc.cluster.local",
+				UAA: appsv1alpha1.UAAClientConfig{
+					Endpoint:   "https://uaa.cf-system.svc.cluster.local:8443",
+					ClientName: "cf_api_controllers",
+					ClientSecretRef: appsv1alpha1.SecretKeyReference{
+						Namespace: "cf-system",
+						Name:      "uaa-client",
+						Key:       "password",
+					},
+				},
+				WorkloadsNamespace: "cf-workloads",
+				TLS:                appsv1alpha1.TLSConfig{InsecureSkipVerify: true},
+				Controllers:        appsv1alpha1.ControllersEnabled{Route: &disabled},
+			},
+		}
+		client.GetCalls(func(_ context.Context, _ types.NamespacedName, object runtime.Object) error {
+			if config == nil {
+				return apierrors.NewNotFound(schema.GroupResource{}, "cf-api-controllers")
+			}
+			ptr := object.(*appsv1alpha1.CFAPIControllersConfig)
+			*ptr = *config.DeepCopy()
+			return nil
+		})
+
+		secret = &corev1.Secret{Data: map[string][]byte{"password": []byte("some-secret")}}
+		apiReader.GetCalls(func(_ context.Context, name types.NamespacedName, object runtime.Object) error {
+			Expect(name).To(Equal(types.NamespacedName{Namespace: "cf-system", Name: "uaa-client"}))
+			if secret == nil {
+				return apierrors.NewNotFound(schema.GroupResource{}, name.Name)
+			}
+			ptr := object.(*corev1.Secret)
+			*ptr = *secret.DeepCopy()
+			return nil
+		})
+
+		applied = nil
+		applyErr = nil
+		reconciler = &ControllersConfigReconciler{
+			Client:    client,
+			Log:       logrTesting.NullLogger{},
+			APIReader: apiReader,
+			Name:      "cf-api-controllers",
+			Apply: func(settings ControllersSettings) error {
+				applied = append(applied, settings)
+				return applyErr
+			},
+		}
+	})
+
+	JustBeforeEach(func() {
+		result, err = reconciler.Reconcile(ctrl.Request{NamespacedName: types.NamespacedName{Name: "cf-api-controllers"}})
+	})
+
+	validCondition := func() appsv1alpha1.Condition {
+		Expect(client.UpdateCallCount()).To(Equal(1))
+		_, updatedObject, _ := client.UpdateArgsForCall(0)
+		status := updatedObject.(*appsv1alpha1.CFAPIControllersConfig).Status
+		Expect(status.ObservedGeneration).To(Equal(int64(3)))
+		Expect(status.Conditions).To(HaveLen(1))
+		Expect(status.Conditions[0].Type).To(Equal(appsv1alpha1.ValidConditionType))
+		return status.Conditions[0]
+	}
+
+	When("the config is valid", func() {
+		It("applies its settings with the UAA client secret", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(applied).To(Equal([]ControllersSettings{{
+				CFAPIHost:            "https://capi.cf-system.svc.cluster.local",
+				UAAEndpoint:          "https://uaa.cf-system.svc.cluster.local:8443",
+				UAAClientName:        "cf_api_controllers",
+				UAAClientSecret:      "some-secret",
+				WorkloadsNamespace:   "cf-workloads",
+				TLS:                  cf.TLSConfig{InsecureSkipVerify: true},
+				CFAPITimeout:         cf.DefaultTimeout,
+				ImageRegistryTimeout: image_registry.DefaultTimeout,
+				DisabledControllers:  map[string]bool{RouteControllerName: true},
+			}}))
+
+			condition := validCondition()
+			Expect(condition.Status).To(Equal(appsv1alpha1.TrueConditionStatus))
+			Expect(condition.Reason).To(Equal(appsv1alpha1.AppliedConditionReason))
+		})
+
+		It("checks the UAA client secret again later", func() {
+			Expect(result.RequeueAfter).To(BeNumerically(">", 0))
+		})
+
+		It("doesn't apply the same settings twice", func() {
+			_, err := reconciler.Reconcile(ctrl.Request{NamespacedName: types.NamespacedName{Name: "cf-api-controllers"}})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(applied).To(HaveLen(1))
+		})
+	})
+
+	When("the config is invalid", func() {
+		BeforeEach(func() {
+			config.Spec.CFAPIHost = "capi.cf-system.svc.cluster.local"
+			config.Spec.WorkloadsNamespace = ""
+			config.Spec.TLS.CACertificate = "not a certificate"
+			config.Spec.Timeouts.CFAPI = &metav1.Duration{Duration: -time.Second}
+		})
+
+		It("reports every problem without applying it", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(applied).To(BeEmpty())
+
+			condition := validCondition()
+			Expect(condition.Status).To(Equal(appsv1alpha1.FalseConditionStatus))
+			Expect(condition.Reason).To(Equal(appsv1alpha1.InvalidConditionReason))
+			Expect(condition.Message).To(ContainSubstring(`spec.cfAPIHost must be an http or https URL, got "capi.cf-system.svc.cluster.local"`))
+			Expect(condition.Message).To(ContainSubstring("spec.workloadsNamespace"))
+			Expect(condition.Message).To(ContainSubstring("spec.tls.caCertificate must contain a PEM encoded certificate"))
+			Expect(condition.Message).To(ContainSubstring("spec.timeouts.cfAPI must be positive, got -1s"))
+		})
+	})
+
+	When("the UAA client's Secret does not exist", func() {
+		BeforeEach(func() {
+			secret = nil
+		})
+
+		It("reports the config as invalid", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(applied).To(BeEmpty())
+
+			condition := validCondition()
+			Expect(condition.Status).To(Equal(appsv1alpha1.FalseConditionStatus))
+			Expect(condition.Message).To(HavePrefix("spec.uaa.clientSecretRef could not read Secret cf-system/uaa-client"))
+		})
+	})
+
+	When("the UAA client's Secret lacks the key", func() {
+		BeforeEach(func() {
+			secret.Data = map[string][]byte{"other": []byte("some-secret")}
+		})
+
+		It("reports the config as invalid", func() {
+			condition := validCondition()
+			Expect(condition.Message).To(Equal(`spec.uaa.clientSecretRef Secret cf-system/uaa-client has no key "password"`))
+		})
+	})
+
+	When("the settings can't be applied", func() {
+		BeforeEach(func() {
+			applyErr = errors.New("bad settings")
+		})
+
+		It("reports the failure", func() {
+			Expect(err).NotTo(HaveOccurred())
+
+			condition := validCondition()
+			Expect(condition.Status).To(Equal(appsv1alpha1.FalseConditionStatus))
+			Expect(condition.Reason).To(Equal(appsv1alpha1.ApplyFailedConditionReason))
+			Expect(condition.Message).To(Equal("bad settings"))
+		})
+	})
+
+	When("the config does not exist", func() {
+		BeforeEach(func() {
+			config = nil
+		})
+
+		It("keeps the current settings", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(applied).To(BeEmpty())
+			Expect(client.UpdateCallCount()).To(Equal(0))
+		})
+	})
+})
+
+var _ = Describe("ControllerSwitch", func() {
+	It("is on unless set off", func() {
+		var nilSwitch *ControllerSwitch
+		Expect(nilSwitch.Enabled()).To(BeTrue())
+
+		controllerSwitch := new(ControllerSwitch)
+		Expect(controllerSwitch.Enabled()).To(BeTrue())
+
+		controllerSwitch.Set(false)
+		Expect(controllerSwitch.Enabled()).To(BeFalse())
+
+		controllerSwitch.Set(true)
+		Expect(controllerSwitch.Enabled()).To(BeTrue())
+	})
+
+	It("queues the requests dropped while it was off once it is back on", func() {
+		queue := workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter())
+		defer queue.ShutDown()
+		request := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "some-namespace", Name: "some-name"}}
+
+		controllerSwitch := new(ControllerSwitch)
+		Expect(controllerSwitch.Start(nil, queue)).To(Succeed())
+		Expect(controllerSwitch.Admit(request)).To(BeTrue())
+
+		controllerSwitch.Set(false)
+		Expect(controllerSwitch.Admit(request)).To(BeFalse())
+		Expect(controllerSwitch.Admit(request)).To(BeFalse())
+		Expect(queue.Len()).To(Equal(0))
+
+		controllerSwitch.Set(true)
+		Expect(queue.Len()).To(Equal(1))
+		queued, _ := queue.Get()
+		Expect(queued).To(Equal(request))
+
+		controllerSwitch.Set(true)
+		Expect(queue.Len()).To(Equal(0))
+	})
+})
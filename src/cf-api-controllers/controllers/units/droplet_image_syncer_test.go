@@ -0,0 +1,235 @@
+package units_test
+
+import (
+	"context"
+	"errors"
+
+	"code.cloudfoundry.org/capi-k8s-release/src/cf-api-controllers/cf/model"
+	. "code.cloudfoundry.org/capi-k8s-release/src/cf-api-controllers/controllers"
+	"code.cloudfoundry.org/capi-k8s-release/src/cf-api-controllers/controllers/fake"
+	logrTesting "github.com/go-logr/logr/testing"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	buildv1alpha1 "github.com/pivotal/kpack/pkg/apis/build/v1alpha1"
+	corev1alpha1 "github.com/pivotal/kpack/pkg/apis/core/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrlClient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+var _ = Describe("DropletImageSyncer", func() {
+	const (
+		workloadsNamespace = "cf-workloads-fake"
+		appGUID            = "some-app-guid"
+		dropletGUID        = "some-droplet-guid"
+		latestImage        = "registry.example.org/some-app@sha256:1111111111111111111111111111111111111111111111111111111111111111"
+		oldImage           = "registry.example.org/some-app@sha256:0000000000000000000000000000000000000000000000000000000000000000"
+	)
+
+	var (
+		syncer       *DropletImageSyncer
+		client       *fake.ControllerRuntimeClient
+		cfClient     *fake.CFDropletClient
+		stackRebases *fake.StackRebaseHandler
+		image        buildv1alpha1.Image
+	)
+
+	BeforeEach(func() {
+		client = new(fake.ControllerRuntimeClient)
+		cfClient = new(fake.CFDropletClient)
+		cfClient.GetDropletReturns(model.Droplet{Image: oldImage}, nil)
+		stackRebases = new(fake.StackRebaseHandler)
+
+		image = buildv1alpha1.Image{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   "some-image",
+				Labels: map[string]string{AppGUIDLabel: appGUID, DropletGUIDLabel: dropletGUID},
+			},
+			Status: buildv1alpha1.ImageStatus{
+				Status: corev1alpha1.Status{
+					Conditions: []corev1alpha1.Condition{{
+						Type:   corev1alpha1.ConditionReady,
+						Status: corev1.ConditionTrue,
+					}},
+				},
+				LatestBuildReason: StackUpdateBuildReason,
+				LatestImage:       latestImage,
+			},
+		}
+		client.ListCalls(func(_ context.Context, object runtime.Object, _ ...ctrlClient.ListOption) error {
+			ptr := object.(*buildv1alpha1.ImageList)
+			*ptr = buildv1alpha1.ImageList{Items: []buildv1alpha1.Image{image}}
+			return nil
+		})
+	})
+
+	JustBeforeEach(func() {
+		syncer = &DropletImageSyncer{
+			Client:       client,
+			CFClient:     cfClient,
+			StackRebases: stackRebases,
+		}
+	})
+
+	When("the droplet is behind a stack update", func() {
+		It("hands the rebase to the ImageReconciler", func() {
+			diff, err := syncer.Diff(context.Background(), logrTesting.NullLogger{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(diff.Create).To(BeEmpty())
+			Expect(diff.Delete).To(BeEmpty())
+			Expect(diff.Update).To(HaveLen(1))
+			Expect(diff.Update[0].GUID).To(Equal(dropletGUID))
+
+			Expect(diff.Update[0].Apply(context.Background())).To(Succeed())
+
+			Expect(stackRebases.RolloutStackRebaseCallCount()).To(Equal(1))
+			_, rebasedImage, _ := stackRebases.RolloutStackRebaseArgsForCall(0)
+			Expect(rebasedImage.Name).To(Equal("some-image"))
+			Expect(rebasedImage.Status.LatestImage).To(Equal(latestImage))
+			Expect(cfClient.UpdateDropletCallCount()).To(Equal(0))
+		})
+
+		When("the ImageReconciler fails to roll it out", func() {
+			BeforeEach(func() {
+				stackRebases.RolloutStackRebaseReturns(errors.New("rollout failed"))
+			})
+
+			It("reports the failure", func() {
+				diff, err := syncer.Diff(context.Background(), logrTesting.NullLogger{})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(diff.Update).To(HaveLen(1))
+				Expect(diff.Update[0].Apply(context.Background())).To(MatchError("rollout failed"))
+			})
+		})
+	})
+
+	When("the latest build was not a stack update", func() {
+		BeforeEach(func() {
+			image.Status.LatestBuildReason = "COMMIT"
+		})
+
+		It("points the droplet at the latest image without restarting the app", func() {
+			diff, err := syncer.Diff(context.Background(), logrTesting.NullLogger{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(diff.Update).To(HaveLen(1))
+			Expect(diff.Update[0].GUID).To(Equal(dropletGUID))
+
+			Expect(diff.Update[0].Apply(context.Background())).To(Succeed())
+
+			Expect(cfClient.UpdateDropletCallCount()).To(Equal(1))
+			_, guid, droplet := cfClient.UpdateDropletArgsForCall(0)
+			Expect(guid).To(Equal(dropletGUID))
+			Expect(droplet).To(Equal(model.Droplet{Image: latestImage}))
+			Expect(stackRebases.RolloutStackRebaseCallCount()).To(Equal(0))
+		})
+	})
+
+	When("the droplet already has the latest image", func() {
+		BeforeEach(func() {
+			cfClient.GetDropletReturns(model.Droplet{Image: latestImage}, nil)
+		})
+
+		It("finds nothing to change", func() {
+			diff, err := syncer.Diff(context.Background(), logrTesting.NullLogger{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(diff.Update).To(BeEmpty())
+			// droplets are never deleted, so they don't count towards a deletion budget
+			Expect(diff.Existing).To(BeZero())
+		})
+	})
+
+	When("the rebase to the latest image was rolled back", func() {
+		BeforeEach(func() {
+			image.Annotations = map[string]string{StackRebaseRolledBackAnnotation: latestImage}
+		})
+
+		It("leaves the app on the previous image", func() {
+			diff, err := syncer.Diff(context.Background(), logrTesting.NullLogger{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(diff.Update).To(BeEmpty())
+			Expect(cfClient.GetDropletCallCount()).To(Equal(0))
+		})
+	})
+
+	When("the ImageReconciler already handled the rebase", func() {
+		BeforeEach(func() {
+			// e.g. because the app's space opted out of stack rebases
+			image.Annotations = map[string]string{StackRebaseHandledAnnotation: latestImage}
+		})
+
+		It("leaves it alone", func() {
+			diff, err := syncer.Diff(context.Background(), logrTesting.NullLogger{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(diff.Update).To(BeEmpty())
+			Expect(cfClient.GetDropletCallCount()).To(Equal(0))
+		})
+	})
+
+	When("the ImageReconciler is verifying the rebase", func() {
+		BeforeEach(func() {
+			image.Annotations = map[string]string{StackRebaseVerifyingAnnotation: latestImage}
+		})
+
+		It("leaves it alone", func() {
+			diff, err := syncer.Diff(context.Background(), logrTesting.NullLogger{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(diff.Update).To(BeEmpty())
+			Expect(cfClient.GetDropletCallCount()).To(Equal(0))
+		})
+	})
+
+	When("the image is not ready", func() {
+		BeforeEach(func() {
+			image.Status.Conditions[0].Status = corev1.ConditionFalse
+		})
+
+		It("skips it", func() {
+			diff, err := syncer.Diff(context.Background(), logrTesting.NullLogger{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(diff.Update).To(BeEmpty())
+			Expect(cfClient.GetDropletCallCount()).To(Equal(0))
+		})
+	})
+
+	When("the droplet cannot be fetched", func() {
+		BeforeEach(func() {
+			cfClient.GetDropletReturns(model.Droplet{}, errors.New("droplet fetch failed"))
+		})
+
+		It("reports the droplet as failed", func() {
+			diff, err := syncer.Diff(context.Background(), logrTesting.NullLogger{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(diff.Update).To(BeEmpty())
+			Expect(diff.Failed).To(ConsistOf(SyncFailure{GUID: dropletGUID, Err: errors.New("droplet fetch failed")}))
+		})
+	})
+
+	When("the rebased image is not pinned by digest", func() {
+		BeforeEach(func() {
+			image.Status.LatestImage = "registry.example.org/some-app:latest"
+		})
+
+		It("reports the droplet as failed without rolling it out", func() {
+			diff, err := syncer.Diff(context.Background(), logrTesting.NullLogger{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(diff.Update).To(BeEmpty())
+			Expect(diff.Failed).To(HaveLen(1))
+			Expect(diff.Failed[0].GUID).To(Equal(dropletGUID))
+			var notPinned *ImageNotPinnedError
+			Expect(errors.As(diff.Failed[0].Err, &notPinned)).To(BeTrue())
+			Expect(cfClient.GetDropletCallCount()).To(Equal(0))
+		})
+	})
+
+	When("listing images fails", func() {
+		BeforeEach(func() {
+			client.ListReturns(errors.New("list failed"))
+		})
+
+		It("errors", func() {
+			_, err := syncer.Diff(context.Background(), logrTesting.NullLogger{})
+			Expect(err).To(MatchError("error listing images from kubernetes API: list failed"))
+		})
+	})
+})
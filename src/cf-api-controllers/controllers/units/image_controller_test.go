@@ -0,0 +1,544 @@
+package units_test
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	appsv1alpha1 "code.cloudfoundry.org/capi-k8s-release/src/cf-api-controllers/apis/apps.cloudfoundry.org/v1alpha1"
+	"code.cloudfoundry.org/capi-k8s-release/src/cf-api-controllers/cf/model"
+	. "code.cloudfoundry.org/capi-k8s-release/src/cf-api-controllers/controllers"
+	"code.cloudfoundry.org/capi-k8s-release/src/cf-api-controllers/controllers/fake"
+	logrTesting "github.com/go-logr/logr/testing"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	buildv1alpha1 "github.com/pivotal/kpack/pkg/apis/build/v1alpha1"
+	corev1alpha1 "github.com/pivotal/kpack/pkg/apis/core/v1alpha1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+var _ = Describe("ImageReconciler", func() {
+	const (
+		workloadsNamespace = "cf-workloads-fake"
+		appGUID            = "some-app-guid"
+		dropletGUID        = "some-droplet-guid"
+		latestImage        = "registry.example.org/some-app@sha256:1111111111111111111111111111111111111111111111111111111111111111"
+		oldImage           = "registry.example.org/some-app@sha256:0000000000000000000000000000000000000000000000000000000000000000"
+	)
+
+	var (
+		reconciler    *ImageReconciler
+		client        *fake.ControllerRuntimeClient
+		cfClient      *fake.CFDropletClient
+		clientset     *k8sfake.Clientset
+		request       ctrl.Request
+		runningImages map[string]string
+		failUpdateOf  string
+		image         buildv1alpha1.Image
+		policy        *StackRebaseRolloutPolicy
+		window        time.Duration
+		ready         bool
+	)
+
+	BeforeEach(func() {
+		client = new(fake.ControllerRuntimeClient)
+		cfClient = new(fake.CFDropletClient)
+		runningImages = map[string]string{"app-web": oldImage, "app-worker": oldImage}
+		failUpdateOf = ""
+
+		image = buildv1alpha1.Image{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   "some-image",
+				Labels: map[string]string{AppGUIDLabel: appGUID, DropletGUIDLabel: dropletGUID},
+			},
+			Status: buildv1alpha1.ImageStatus{
+				Status: corev1alpha1.Status{
+					Conditions: []corev1alpha1.Condition{{
+						Type:   corev1alpha1.ConditionReady,
+						Status: corev1.ConditionTrue,
+					}},
+				},
+				LatestBuildReason: StackUpdateBuildReason,
+				LatestImage:       latestImage,
+			},
+		}
+		policy = nil
+		window = 0
+		ready = false
+		client.GetCalls(func(_ context.Context, _ types.NamespacedName, object runtime.Object) error {
+			ptr := object.(*buildv1alpha1.Image)
+			*ptr = *image.DeepCopy()
+			return nil
+		})
+		cfClient.GetDropletReturns(model.Droplet{Image: oldImage}, nil)
+		request = ctrl.Request{NamespacedName: types.NamespacedName{Name: "some-image"}}
+	})
+
+	JustBeforeEach(func() {
+		var objects []runtime.Object
+		for name, runningImage := range runningImages {
+			statefulset := &appsv1.StatefulSet{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      name,
+					Namespace: workloadsNamespace,
+					Labels:    map[string]string{AppGUIDLabel: appGUID},
+				},
+				Spec: appsv1.StatefulSetSpec{
+					Template: corev1.PodTemplateSpec{
+						Spec: corev1.PodSpec{
+							Containers: []corev1.Container{{Name: "opi", Image: runningImage}},
+						},
+					},
+				},
+			}
+			if ready {
+				statefulset.Status = appsv1.StatefulSetStatus{UpdatedReplicas: 1, ReadyReplicas: 1}
+			}
+			objects = append(objects, statefulset)
+		}
+		clientset = k8sfake.NewSimpleClientset(objects...)
+		clientset.PrependReactor("update", "statefulsets", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			statefulset := action.(k8stesting.UpdateAction).GetObject().(*appsv1.StatefulSet)
+			if statefulset.Name == failUpdateOf && statefulset.Spec.Template.Spec.Containers[0].Image == latestImage {
+				return true, nil, errors.New("update failed")
+			}
+			return false, nil, nil
+		})
+
+		reconciler = &ImageReconciler{
+			Client:             client,
+			Log:                logrTesting.NullLogger{},
+			CFClient:           cfClient,
+			AppsClientSet:      clientset.AppsV1(),
+			WorkloadsNamespace: workloadsNamespace,
+			RolloutPolicy:      policy,
+			ReadinessWindow:    window,
+		}
+	})
+
+	runningImage := func(name string) string {
+		statefulset, err := clientset.AppsV1().StatefulSets(workloadsNamespace).Get(name, metav1.GetOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		return statefulset.Spec.Template.Spec.Containers[0].Image
+	}
+
+	updateCount := func() int {
+		count := 0
+		for _, action := range clientset.Actions() {
+			if action.GetVerb() == "update" {
+				count++
+			}
+		}
+		return count
+	}
+
+	When("the app has a StatefulSet per process type", func() {
+		It("updates all of them before updating the droplet in CC", func() {
+			result, err := reconciler.Reconcile(request)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result).To(Equal(ctrl.Result{}))
+
+			Expect(runningImage("app-web")).To(Equal(latestImage))
+			Expect(runningImage("app-worker")).To(Equal(latestImage))
+
+			Expect(cfClient.GetDropletCallCount()).To(Equal(1))
+			_, requestedGUID := cfClient.GetDropletArgsForCall(0)
+			Expect(requestedGUID).To(Equal(dropletGUID))
+			Expect(cfClient.UpdateDropletCallCount()).To(Equal(1))
+			_, guid, droplet := cfClient.UpdateDropletArgsForCall(0)
+			Expect(guid).To(Equal(dropletGUID))
+			Expect(droplet.Image).To(Equal(latestImage))
+			Expect(droplet.Metadata.Annotations).To(HaveKeyWithValue(RebasePreviousImageAnnotation, oldImage))
+			Expect(droplet.Metadata.Annotations).To(HaveKey(RebasedAtAnnotation))
+		})
+	})
+
+	When("the Image records the stack and build of the rebase", func() {
+		BeforeEach(func() {
+			image.Namespace = "cf-workloads-staging"
+			image.Status.LatestStack = "io.buildpacks.stacks.bionic"
+			image.Status.LatestBuildRef = "some-image-build-2"
+			client.GetCalls(func(_ context.Context, name types.NamespacedName, object runtime.Object) error {
+				switch ptr := object.(type) {
+				case *buildv1alpha1.Image:
+					*ptr = *image.DeepCopy()
+				case *buildv1alpha1.Build:
+					Expect(name).To(Equal(types.NamespacedName{Namespace: "cf-workloads-staging", Name: "some-image-build-2"}))
+					ptr.Status.Stack = buildv1alpha1.BuildStack{
+						ID:       "io.buildpacks.stacks.bionic",
+						RunImage: "registry.example.org/run@sha256:2222222222222222222222222222222222222222222222222222222222222222",
+					}
+				}
+				return nil
+			})
+		})
+
+		It("annotates the droplet with them", func() {
+			_, err := reconciler.Reconcile(request)
+			Expect(err).NotTo(HaveOccurred())
+
+			_, _, droplet := cfClient.UpdateDropletArgsForCall(0)
+			Expect(droplet.Metadata.Annotations).To(HaveKeyWithValue(RebaseStackAnnotation, "io.buildpacks.stacks.bionic"))
+			Expect(droplet.Metadata.Annotations).To(HaveKeyWithValue(RebaseBuildAnnotation, "some-image-build-2"))
+			Expect(droplet.Metadata.Annotations).To(HaveKeyWithValue(
+				RebaseRunImageAnnotation,
+				"registry.example.org/run@sha256:2222222222222222222222222222222222222222222222222222222222222222",
+			))
+		})
+	})
+
+	When("CC already has the new image after an earlier attempt", func() {
+		BeforeEach(func() {
+			cfClient.GetDropletReturns(model.Droplet{Image: latestImage}, nil)
+		})
+
+		It("does not record it as the previous image", func() {
+			_, err := reconciler.Reconcile(request)
+			Expect(err).NotTo(HaveOccurred())
+
+			_, _, droplet := cfClient.UpdateDropletArgsForCall(0)
+			Expect(droplet.Metadata.Annotations).NotTo(HaveKey(RebasePreviousImageAnnotation))
+		})
+	})
+
+	When("the rebased image is not pinned by digest", func() {
+		BeforeEach(func() {
+			image.Status.LatestImage = "registry.example.org/some-app:latest"
+		})
+
+		It("refuses to roll it out", func() {
+			result, err := reconciler.Reconcile(request)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result).To(Equal(ctrl.Result{}))
+
+			Expect(updateCount()).To(Equal(0))
+			Expect(cfClient.UpdateDropletCallCount()).To(Equal(0))
+		})
+	})
+
+	When("the droplet can't be fetched from CC", func() {
+		BeforeEach(func() {
+			cfClient.GetDropletReturns(model.Droplet{}, errors.New("cc is down"))
+		})
+
+		It("returns the error to be retried", func() {
+			_, err := reconciler.Reconcile(request)
+			Expect(err).To(MatchError("cc is down"))
+			Expect(cfClient.UpdateDropletCallCount()).To(Equal(0))
+		})
+	})
+
+	When("some StatefulSets already run the new image", func() {
+		BeforeEach(func() {
+			runningImages["app-worker"] = latestImage
+		})
+
+		It("only updates the others", func() {
+			_, err := reconciler.Reconcile(request)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(updateCount()).To(Equal(1))
+			Expect(runningImage("app-web")).To(Equal(latestImage))
+			Expect(cfClient.UpdateDropletCallCount()).To(Equal(1))
+		})
+	})
+
+	When("updating one of the StatefulSets fails", func() {
+		BeforeEach(func() {
+			runningImages = map[string]string{"app-a": oldImage, "app-b": oldImage, "app-c": oldImage}
+			failUpdateOf = "app-b"
+		})
+
+		It("reverts the others, reports the failure and requeues without updating CC", func() {
+			_, err := reconciler.Reconcile(request)
+			Expect(err).To(MatchError(ContainSubstring("failed to update statefulset app-b: update failed")))
+
+			Expect(runningImage("app-a")).To(Equal(oldImage))
+			Expect(runningImage("app-b")).To(Equal(oldImage))
+			Expect(runningImage("app-c")).To(Equal(oldImage))
+			Expect(cfClient.UpdateDropletCallCount()).To(Equal(0))
+		})
+	})
+
+	When("the app has no StatefulSets", func() {
+		BeforeEach(func() {
+			runningImages = map[string]string{}
+		})
+
+		It("leaves it to the DropletImage PeriodicSync", func() {
+			result, err := reconciler.Reconcile(request)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result).To(Equal(ctrl.Result{}))
+			Expect(cfClient.UpdateDropletCallCount()).To(Equal(0))
+		})
+	})
+
+	Describe("RolloutStackRebase", func() {
+		It("rolls the rebase out like Reconcile", func() {
+			Expect(reconciler.RolloutStackRebase(context.Background(), image, logrTesting.NullLogger{})).To(Succeed())
+
+			Expect(runningImage("app-web")).To(Equal(latestImage))
+			Expect(runningImage("app-worker")).To(Equal(latestImage))
+			Expect(cfClient.UpdateDropletCallCount()).To(Equal(1))
+		})
+
+		When("the controller is switched off", func() {
+			JustBeforeEach(func() {
+				reconciler.Switch = new(ControllerSwitch)
+				reconciler.Switch.Set(false)
+			})
+
+			It("rolls nothing out", func() {
+				Expect(reconciler.RolloutStackRebase(context.Background(), image, logrTesting.NullLogger{})).To(Succeed())
+
+				Expect(updateCount()).To(Equal(0))
+				Expect(cfClient.UpdateDropletCallCount()).To(Equal(0))
+			})
+		})
+	})
+
+	When("a rollout policy is configured", func() {
+		var (
+			rollout    appsv1alpha1.StackRebaseRollout
+			ccApp      model.AppResponse
+			appFetcher *fake.CFAppFetcher
+		)
+
+		BeforeEach(func() {
+			rollout = appsv1alpha1.StackRebaseRollout{
+				ObjectMeta: metav1.ObjectMeta{Name: "some-rollout", Namespace: "cf-system"},
+				Spec:       appsv1alpha1.StackRebaseRolloutSpec{MaxConcurrentRestarts: 1},
+			}
+			client.GetCalls(func(_ context.Context, _ types.NamespacedName, object runtime.Object) error {
+				switch ptr := object.(type) {
+				case *buildv1alpha1.Image:
+					*ptr = *image.DeepCopy()
+				case *appsv1alpha1.StackRebaseRollout:
+					*ptr = *rollout.DeepCopy()
+				}
+				return nil
+			})
+			client.StatusReturns(client)
+
+			ccApp = model.AppResponse{
+				App: model.App{
+					GUID: appGUID,
+					Relationships: map[string]model.Relationship{
+						"space": {Data: model.RelationshipData{GUID: "space-guid"}},
+					},
+				},
+				Included: model.AppIncluded{
+					Spaces: []model.Space{{GUID: "space-guid"}},
+				},
+			}
+			appFetcher = new(fake.CFAppFetcher)
+			appFetcher.GetAppCalls(func(context.Context, string) (model.AppResponse, error) {
+				return ccApp, nil
+			})
+			policy = &StackRebaseRolloutPolicy{
+				Client:             client,
+				CFClient:           appFetcher,
+				AppsClientSet:      k8sfake.NewSimpleClientset().AppsV1(),
+				WorkloadsNamespace: workloadsNamespace,
+				Name:               types.NamespacedName{Namespace: "cf-system", Name: "some-rollout"},
+			}
+		})
+
+		patchedAnnotations := func() map[string]string {
+			Expect(client.PatchCallCount()).To(Equal(1))
+			_, patchedObject, _, _ := client.PatchArgsForCall(0)
+			return patchedObject.(*buildv1alpha1.Image).Annotations
+		}
+
+		It("rebases an admitted app and marks the Image as handled", func() {
+			result, err := reconciler.Reconcile(request)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result).To(Equal(ctrl.Result{}))
+
+			Expect(runningImage("app-web")).To(Equal(latestImage))
+			Expect(cfClient.UpdateDropletCallCount()).To(Equal(1))
+			Expect(patchedAnnotations()).To(HaveKeyWithValue(StackRebaseHandledAnnotation, latestImage))
+		})
+
+		When("the rollout is paused", func() {
+			BeforeEach(func() {
+				rollout.Spec.Paused = true
+			})
+
+			It("requeues without restarting the app", func() {
+				result, err := reconciler.Reconcile(request)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(result.RequeueAfter).To(BeNumerically(">", 0))
+
+				Expect(updateCount()).To(Equal(0))
+				Expect(cfClient.UpdateDropletCallCount()).To(Equal(0))
+				Expect(client.PatchCallCount()).To(Equal(0))
+			})
+		})
+
+		When("the app's space opted out", func() {
+			BeforeEach(func() {
+				ccApp.Included.Spaces[0].Metadata.Labels = map[string]string{appsv1alpha1.StackRebaseOptOutLabel: "true"}
+			})
+
+			It("marks the Image as handled without restarting the app", func() {
+				_, err := reconciler.Reconcile(request)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(updateCount()).To(Equal(0))
+				Expect(cfClient.UpdateDropletCallCount()).To(Equal(0))
+				Expect(patchedAnnotations()).To(HaveKeyWithValue(StackRebaseHandledAnnotation, latestImage))
+			})
+		})
+
+		When("the rebased image was already handled", func() {
+			BeforeEach(func() {
+				image.Annotations = map[string]string{StackRebaseHandledAnnotation: latestImage}
+			})
+
+			It("does nothing", func() {
+				_, err := reconciler.Reconcile(request)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(appFetcher.GetAppCallCount()).To(Equal(0))
+				Expect(updateCount()).To(Equal(0))
+				Expect(cfClient.UpdateDropletCallCount()).To(Equal(0))
+			})
+		})
+	})
+
+	When("a readiness window is configured", func() {
+		BeforeEach(func() {
+			window = 5 * time.Minute
+		})
+
+		patchedAnnotations := func() map[string]string {
+			Expect(client.PatchCallCount()).To(Equal(1))
+			_, patchedObject, _, _ := client.PatchArgsForCall(0)
+			return patchedObject.(*buildv1alpha1.Image).Annotations
+		}
+
+		It("starts checking the rebased app for readiness", func() {
+			result, err := reconciler.Reconcile(request)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.RequeueAfter).To(BeNumerically(">", 0))
+
+			Expect(runningImage("app-web")).To(Equal(latestImage))
+			Expect(cfClient.UpdateDropletCallCount()).To(Equal(1))
+
+			annotations := patchedAnnotations()
+			Expect(annotations).To(HaveKeyWithValue(StackRebaseVerifyingAnnotation, latestImage))
+			Expect(annotations).To(HaveKeyWithValue(StackRebasePreviousImageAnnotation, oldImage))
+			Expect(annotations).To(HaveKey(StackRebaseStartedAtAnnotation))
+		})
+
+		When("the rebase is being checked", func() {
+			var startedAt time.Time
+
+			BeforeEach(func() {
+				startedAt = time.Now()
+				runningImages = map[string]string{"app-web": latestImage, "app-worker": latestImage}
+			})
+
+			JustBeforeEach(func() {
+				image.Annotations = map[string]string{
+					StackRebaseHandledAnnotation:       latestImage,
+					StackRebaseVerifyingAnnotation:     latestImage,
+					StackRebasePreviousImageAnnotation: oldImage,
+					StackRebaseStartedAtAnnotation:     startedAt.UTC().Format(time.RFC3339),
+				}
+			})
+
+			When("the app became ready", func() {
+				BeforeEach(func() {
+					ready = true
+				})
+
+				It("stops checking it", func() {
+					result, err := reconciler.Reconcile(request)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(result).To(Equal(ctrl.Result{}))
+
+					annotations := patchedAnnotations()
+					Expect(annotations).To(HaveKeyWithValue(StackRebaseHandledAnnotation, latestImage))
+					Expect(annotations).NotTo(HaveKey(StackRebaseVerifyingAnnotation))
+					Expect(annotations).NotTo(HaveKey(StackRebasePreviousImageAnnotation))
+					Expect(annotations).NotTo(HaveKey(StackRebaseStartedAtAnnotation))
+					Expect(updateCount()).To(Equal(0))
+					Expect(cfClient.UpdateDropletCallCount()).To(Equal(0))
+				})
+			})
+
+			When("the app is not ready yet within the window", func() {
+				It("checks again later", func() {
+					result, err := reconciler.Reconcile(request)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(result.RequeueAfter).To(BeNumerically(">", 0))
+
+					Expect(client.PatchCallCount()).To(Equal(0))
+					Expect(updateCount()).To(Equal(0))
+					Expect(cfClient.UpdateDropletCallCount()).To(Equal(0))
+				})
+			})
+
+			When("the app did not become ready within the window", func() {
+				BeforeEach(func() {
+					startedAt = time.Now().Add(-10 * time.Minute)
+				})
+
+				It("rolls the app and its droplet back and stops retrying the rebase", func() {
+					result, err := reconciler.Reconcile(request)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(result).To(Equal(ctrl.Result{}))
+
+					Expect(runningImage("app-web")).To(Equal(oldImage))
+					Expect(runningImage("app-worker")).To(Equal(oldImage))
+
+					Expect(cfClient.UpdateDropletCallCount()).To(Equal(1))
+					_, guid, droplet := cfClient.UpdateDropletArgsForCall(0)
+					Expect(guid).To(Equal(dropletGUID))
+					Expect(droplet.Image).To(Equal(oldImage))
+					Expect(droplet.Metadata.Annotations).To(HaveKeyWithValue(RebaseRolledBackAnnotation, latestImage))
+
+					annotations := patchedAnnotations()
+					Expect(annotations).To(HaveKeyWithValue(StackRebaseRolledBackAnnotation, latestImage))
+					Expect(annotations).NotTo(HaveKey(StackRebaseVerifyingAnnotation))
+				})
+
+				When("reverting the droplet in CC fails", func() {
+					BeforeEach(func() {
+						cfClient.UpdateDropletReturns(errors.New("cc is down"))
+					})
+
+					It("returns the error to retry the rollback", func() {
+						_, err := reconciler.Reconcile(request)
+						Expect(err).To(MatchError("cc is down"))
+						Expect(client.PatchCallCount()).To(Equal(0))
+					})
+				})
+			})
+		})
+
+		When("the rebase was rolled back", func() {
+			BeforeEach(func() {
+				image.Annotations = map[string]string{StackRebaseRolledBackAnnotation: latestImage}
+			})
+
+			It("does not retry it", func() {
+				result, err := reconciler.Reconcile(request)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(result).To(Equal(ctrl.Result{}))
+
+				Expect(updateCount()).To(Equal(0))
+				Expect(cfClient.UpdateDropletCallCount()).To(Equal(0))
+			})
+		})
+	})
+})
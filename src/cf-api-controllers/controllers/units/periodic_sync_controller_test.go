@@ -7,11 +7,15 @@ import (
 	"time"
 
 	appsv1alpha1 "code.cloudfoundry.org/capi-k8s-release/src/cf-api-controllers/apis/apps.cloudfoundry.org/v1alpha1"
+	"code.cloudfoundry.org/capi-k8s-release/src/cf-api-controllers/cf"
 	"code.cloudfoundry.org/capi-k8s-release/src/cf-api-controllers/cf/cffakes"
+	"code.cloudfoundry.org/capi-k8s-release/src/cf-api-controllers/cf/kubernetes"
 	"code.cloudfoundry.org/capi-k8s-release/src/cf-api-controllers/cf/model"
+	"code.cloudfoundry.org/capi-k8s-release/src/cf-api-controllers/metrics"
 	networkingv1alpha1 "code.cloudfoundry.org/cf-k8s-networking/routecontroller/apis/networking/v1alpha1"
 	. "github.com/onsi/gomega"
 	"github.com/onsi/gomega/gstruct"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 
 	logrTesting "github.com/go-logr/logr/testing"
 
@@ -19,8 +23,10 @@ import (
 	"code.cloudfoundry.org/capi-k8s-release/src/cf-api-controllers/controllers/fake"
 	"github.com/go-logr/logr"
 	. "github.com/onsi/ginkgo"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	ctrlClient "sigs.k8s.io/controller-runtime/pkg/client"
 )
@@ -48,11 +54,16 @@ var _ = Describe("PeriodicSyncController", func() {
 			logger = logrTesting.NullLogger{}
 
 			reconciler = &PeriodicSyncReconciler{
-				Client:             client,
-				Log:                logger,
-				Scheme:             nil,
-				CFClient:           cfClient,
-				WorkloadsNamespace: workloadsNamespace,
+				Client: client,
+				Log:    logger,
+				Scheme: nil,
+				Syncers: map[appsv1alpha1.ResourceType]Syncer{
+					appsv1alpha1.RouteResourceType: &RouteSyncer{
+						Client:             client,
+						CFClient:           cfClient,
+						WorkloadsNamespace: workloadsNamespace,
+					},
+				},
 			}
 			request = ctrl.Request{
 				NamespacedName: types.NamespacedName{
@@ -61,6 +72,9 @@ var _ = Describe("PeriodicSyncController", func() {
 				},
 			}
 			periodicSync := appsv1alpha1.PeriodicSync{
+				ObjectMeta: metav1.ObjectMeta{
+					Generation: 3,
+				},
 				Spec: appsv1alpha1.PeriodicSyncSpec{
 					PeriodSeconds: syncPeriodSeconds,
 				},
@@ -82,6 +96,50 @@ var _ = Describe("PeriodicSyncController", func() {
 				Expect(err).NotTo(HaveOccurred())
 				Expect(result).To(Equal(ctrl.Result{RequeueAfter: syncPeriodSeconds * time.Second}))
 			})
+
+			It("records the sync's timings and results on the PeriodicSync's Status", func() {
+				_, err := reconciler.Reconcile(request)
+				Expect(err).NotTo(HaveOccurred())
+
+				_, syncObject, _ := client.UpdateArgsForCall(0)
+				status := syncObject.(*appsv1alpha1.PeriodicSync).Status
+				Expect(status.ObservedGeneration).To(Equal(int64(3)))
+				Expect(status.LastSyncTime).NotTo(BeNil())
+				Expect(status.LastSuccessfulSyncTime).To(Equal(status.LastSyncTime))
+				Expect(status.LastSyncDuration).NotTo(BeNil())
+				Expect(status.LastSyncResult).To(Equal(appsv1alpha1.SyncResult{}))
+				Expect(status.FailedGUIDs).To(BeEmpty())
+			})
+		})
+
+		Context("when no syncer is configured for the resource type", func() {
+			BeforeEach(func() {
+				client.GetCalls(func(ctx context.Context, name types.NamespacedName, object runtime.Object) error {
+					ptr := object.(*appsv1alpha1.PeriodicSync)
+					*ptr = appsv1alpha1.PeriodicSync{
+						Spec: appsv1alpha1.PeriodicSyncSpec{
+							PeriodSeconds: syncPeriodSeconds,
+							ResourceType:  appsv1alpha1.DropletImageResourceType,
+						},
+					}
+					return nil
+				})
+			})
+
+			It("updates the Synced condition without requeueing", func() {
+				result, err := reconciler.Reconcile(request)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(result).To(Equal(ctrl.Result{}))
+				Expect(cfClient.ListRoutesCallCount()).To(Equal(0))
+
+				_, syncObject, _ := client.UpdateArgsForCall(0)
+				conditions := syncObject.(*appsv1alpha1.PeriodicSync).Status.Conditions
+				Expect(conditions).To(ConsistOf(gstruct.MatchFields(gstruct.IgnoreExtras, gstruct.Fields{
+					"Status":  Equal(appsv1alpha1.FalseConditionStatus),
+					"Reason":  Equal(appsv1alpha1.FailedConditionReason),
+					"Message": Equal(`no syncer is configured for resource type "DropletImage"`),
+				})))
+			})
 		})
 
 		Context("when it fails to fetch the period sync", func() {
@@ -125,7 +183,39 @@ var _ = Describe("PeriodicSyncController", func() {
 				Expect(conditions).To(ConsistOf(gstruct.MatchFields(gstruct.IgnoreExtras, gstruct.Fields{
 					"Status":  Equal(appsv1alpha1.FalseConditionStatus),
 					"Reason":  Equal(appsv1alpha1.FailedConditionReason),
-					"Message": Equal(errMsg),
+					"Message": Equal("error listing routes from CF API: " + errMsg),
+				})))
+			})
+		})
+
+		Context("when the cf route list is incomplete", func() {
+			BeforeEach(func() {
+				cfClient.ListRoutesReturns(model.RouteList{}, &cf.IncompleteListError{
+					PagesFetched: 1,
+					Err:          errors.New("page 2 went missing"),
+				})
+
+				client.ListCalls(func(_ context.Context, object runtime.Object, _ ...ctrlClient.ListOption) error {
+					ptr := object.(*networkingv1alpha1.RouteList)
+					*ptr = networkingv1alpha1.RouteList{
+						Items: []networkingv1alpha1.Route{{}},
+					}
+					return nil
+				})
+			})
+
+			It("does not delete any routes and updates the Synced condition on the PeriodicSync's Status", func() {
+				_, err := reconciler.Reconcile(request)
+				Expect(err).To(MatchError(ContainSubstring("page 2 went missing")))
+
+				Expect(client.DeleteCallCount()).To(Equal(0))
+
+				_, syncObject, _ := client.UpdateArgsForCall(0)
+				conditions := syncObject.(*appsv1alpha1.PeriodicSync).Status.Conditions
+				Expect(conditions).To(ConsistOf(gstruct.MatchFields(gstruct.IgnoreExtras, gstruct.Fields{
+					"Status":  Equal(appsv1alpha1.FalseConditionStatus),
+					"Reason":  Equal(appsv1alpha1.FailedConditionReason),
+					"Message": ContainSubstring("page 2 went missing"),
 				})))
 			})
 		})
@@ -148,7 +238,7 @@ var _ = Describe("PeriodicSyncController", func() {
 				Expect(conditions).To(ConsistOf(gstruct.MatchFields(gstruct.IgnoreExtras, gstruct.Fields{
 					"Status":  Equal(appsv1alpha1.FalseConditionStatus),
 					"Reason":  Equal(appsv1alpha1.FailedConditionReason),
-					"Message": Equal(errMsg),
+					"Message": Equal("error listing routes from kubernetes API: " + errMsg),
 				})))
 			})
 		})
@@ -201,6 +291,381 @@ var _ = Describe("PeriodicSyncController", func() {
 			})
 		})
 
+		Context("when the sync is a dry run", func() {
+			BeforeEach(func() {
+				client.GetCalls(func(ctx context.Context, name types.NamespacedName, object runtime.Object) error {
+					ptr := object.(*appsv1alpha1.PeriodicSync)
+					*ptr = appsv1alpha1.PeriodicSync{
+						Spec: appsv1alpha1.PeriodicSyncSpec{
+							PeriodSeconds: syncPeriodSeconds,
+							DryRun:        true,
+						},
+					}
+					return nil
+				})
+
+				cfClient.ListRoutesReturns(model.RouteList{
+					Resources: []model.Route{
+						{
+							GUID: "route-guid-missing-in-k8s",
+							Relationships: map[string]model.Relationship{
+								"space":  {Data: model.RelationshipData{GUID: "space-guid"}},
+								"domain": {Data: model.RelationshipData{GUID: "domain-guid"}},
+							},
+						},
+						{
+							GUID: "route-guid-out-of-date",
+							Host: "new-host",
+							Relationships: map[string]model.Relationship{
+								"space":  {Data: model.RelationshipData{GUID: "space-guid"}},
+								"domain": {Data: model.RelationshipData{GUID: "domain-guid"}},
+							},
+						},
+					},
+					Included: model.RouteListIncluded{
+						Spaces: []model.Space{{
+							GUID: "space-guid",
+							Relationships: map[string]model.Relationship{
+								"organization": {Data: model.RelationshipData{GUID: "org-guid"}},
+							},
+						}},
+						Domains: []model.Domain{{GUID: "domain-guid"}},
+					},
+				}, nil)
+
+				client.ListCalls(func(_ context.Context, object runtime.Object, _ ...ctrlClient.ListOption) error {
+					ptr := object.(*networkingv1alpha1.RouteList)
+					*ptr = networkingv1alpha1.RouteList{
+						Items: []networkingv1alpha1.Route{
+							{
+								ObjectMeta: metav1.ObjectMeta{Name: "route-guid-out-of-date"},
+								Spec:       networkingv1alpha1.RouteSpec{Host: "old-host"},
+							},
+							{ObjectMeta: metav1.ObjectMeta{Name: "route-guid-extra-in-k8s"}},
+						},
+					}
+					return nil
+				})
+			})
+
+			It("records the planned changes on the status without applying them", func() {
+				result, err := reconciler.Reconcile(request)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(result).To(Equal(ctrl.Result{RequeueAfter: syncPeriodSeconds * time.Second}))
+
+				Expect(client.CreateCallCount()).To(Equal(0))
+				Expect(client.UpdateCallCount()).To(Equal(1), "only the status should be updated")
+				Expect(client.DeleteCallCount()).To(Equal(0))
+
+				_, syncObject, _ := client.UpdateArgsForCall(0)
+				status := syncObject.(*appsv1alpha1.PeriodicSync).Status
+				Expect(status.Plan).To(Equal(&appsv1alpha1.PlannedChanges{
+					Create: appsv1alpha1.PlannedAction{Count: 1, GUIDs: []string{"route-guid-missing-in-k8s"}},
+					Update: appsv1alpha1.PlannedAction{Count: 1, GUIDs: []string{"route-guid-out-of-date"}},
+					Delete: appsv1alpha1.PlannedAction{Count: 1, GUIDs: []string{"route-guid-extra-in-k8s"}},
+				}))
+				Expect(status.Conditions).To(ConsistOf(gstruct.MatchFields(gstruct.IgnoreExtras, gstruct.Fields{
+					"Type":    Equal(appsv1alpha1.SyncedConditionType),
+					"Status":  Equal(appsv1alpha1.TrueConditionStatus),
+					"Reason":  Equal(appsv1alpha1.DryRunConditionReason),
+					"Message": Equal("dry run: would create 1, update 1 and delete 1 resources"),
+				})))
+			})
+		})
+
+		Context("when a deletion budget is configured", func() {
+			var recorder *record.FakeRecorder
+
+			BeforeEach(func() {
+				recorder = record.NewFakeRecorder(10)
+				reconciler.Recorder = recorder
+
+				client.ListCalls(func(_ context.Context, object runtime.Object, _ ...ctrlClient.ListOption) error {
+					ptr := object.(*networkingv1alpha1.RouteList)
+					*ptr = networkingv1alpha1.RouteList{
+						Items: []networkingv1alpha1.Route{
+							{ObjectMeta: metav1.ObjectMeta{Name: "route-guid-1"}},
+							{ObjectMeta: metav1.ObjectMeta{Name: "route-guid-2"}},
+							{ObjectMeta: metav1.ObjectMeta{Name: "route-guid-3"}},
+							{ObjectMeta: metav1.ObjectMeta{Name: "route-guid-4"}},
+						},
+					}
+					return nil
+				})
+			})
+
+			setBudget := func(budget appsv1alpha1.DeletionBudget) {
+				client.GetCalls(func(ctx context.Context, name types.NamespacedName, object runtime.Object) error {
+					ptr := object.(*appsv1alpha1.PeriodicSync)
+					*ptr = appsv1alpha1.PeriodicSync{
+						Spec: appsv1alpha1.PeriodicSyncSpec{
+							PeriodSeconds:  syncPeriodSeconds,
+							DeletionBudget: &budget,
+						},
+					}
+					return nil
+				})
+			}
+
+			When("the deletions exceed the maximum count", func() {
+				BeforeEach(func() {
+					setBudget(appsv1alpha1.DeletionBudget{MaxCount: 3})
+				})
+
+				It("deletes nothing, records the DeletionThrottled condition and emits an event", func() {
+					result, err := reconciler.Reconcile(request)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(result).To(Equal(ctrl.Result{RequeueAfter: syncPeriodSeconds * time.Second}))
+
+					Expect(client.DeleteCallCount()).To(Equal(0))
+
+					_, syncObject, _ := client.UpdateArgsForCall(0)
+					conditions := syncObject.(*appsv1alpha1.PeriodicSync).Status.Conditions
+					Expect(conditions).To(ConsistOf(
+						gstruct.MatchFields(gstruct.IgnoreExtras, gstruct.Fields{
+							"Type":    Equal(appsv1alpha1.DeletionThrottledConditionType),
+							"Status":  Equal(appsv1alpha1.TrueConditionStatus),
+							"Reason":  Equal(appsv1alpha1.DeletionBudgetExceededConditionReason),
+							"Message": ContainSubstring("refusing to delete 4 of 4 Route resources"),
+						}),
+						gstruct.MatchFields(gstruct.IgnoreExtras, gstruct.Fields{
+							"Type":   Equal(appsv1alpha1.SyncedConditionType),
+							"Status": Equal(appsv1alpha1.FalseConditionStatus),
+							"Reason": Equal(appsv1alpha1.DeletionBudgetExceededConditionReason),
+						}),
+					))
+
+					Expect(recorder.Events).To(Receive(ContainSubstring("Warning DeletionThrottled refusing to delete 4 of 4")))
+				})
+			})
+
+			When("the deletions exceed the maximum percentage", func() {
+				BeforeEach(func() {
+					setBudget(appsv1alpha1.DeletionBudget{MaxPercentage: 50})
+				})
+
+				It("deletes nothing", func() {
+					_, err := reconciler.Reconcile(request)
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(client.DeleteCallCount()).To(Equal(0))
+					Expect(recorder.Events).To(HaveLen(1))
+				})
+			})
+
+			When("the deletions are within the budget", func() {
+				BeforeEach(func() {
+					setBudget(appsv1alpha1.DeletionBudget{MaxCount: 4, MaxPercentage: 100})
+				})
+
+				It("deletes the extra routes and marks the DeletionThrottled condition false", func() {
+					_, err := reconciler.Reconcile(request)
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(client.DeleteCallCount()).To(Equal(4))
+					Expect(recorder.Events).To(BeEmpty())
+
+					_, syncObject, _ := client.UpdateArgsForCall(0)
+					status := syncObject.(*appsv1alpha1.PeriodicSync).Status
+					Expect(status.LastSyncResult).To(Equal(appsv1alpha1.SyncResult{Deleted: 4}))
+					conditions := status.Conditions
+					Expect(conditions).To(ContainElement(gstruct.MatchFields(gstruct.IgnoreExtras, gstruct.Fields{
+						"Type":   Equal(appsv1alpha1.DeletionThrottledConditionType),
+						"Status": Equal(appsv1alpha1.FalseConditionStatus),
+					})))
+				})
+			})
+		})
+
+		Context("when CC has a TCP route", func() {
+			var recorder *record.FakeRecorder
+
+			BeforeEach(func() {
+				recorder = record.NewFakeRecorder(10)
+				reconciler.Recorder = recorder
+
+				port := 1024
+				cfClient.ListRoutesReturns(model.RouteList{
+					Resources: []model.Route{{
+						GUID:     "tcp-route-guid",
+						Protocol: model.RouteProtocolTCP,
+						Port:     &port,
+						Relationships: map[string]model.Relationship{
+							"space":  {Data: model.RelationshipData{GUID: "space-guid"}},
+							"domain": {Data: model.RelationshipData{GUID: "tcp-domain-guid"}},
+						},
+					}},
+				}, nil)
+				client.ListCalls(func(_ context.Context, object runtime.Object, _ ...ctrlClient.ListOption) error {
+					ptr := object.(*networkingv1alpha1.RouteList)
+					*ptr = networkingv1alpha1.RouteList{
+						Items: []networkingv1alpha1.Route{{ObjectMeta: metav1.ObjectMeta{Name: "tcp-route-guid"}}},
+					}
+					return nil
+				})
+			})
+
+			It("keeps the Route created for it without syncing it", func() {
+				_, err := reconciler.Reconcile(request)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(client.CreateCallCount()).To(Equal(0))
+				Expect(client.DeleteCallCount()).To(Equal(0))
+				Expect(cfClient.GetSpaceCallCount()).To(Equal(0))
+			})
+
+			It("reports the route as unsupported on the status and in an event", func() {
+				_, err := reconciler.Reconcile(request)
+				Expect(err).NotTo(HaveOccurred())
+
+				_, syncObject, _ := client.UpdateArgsForCall(0)
+				status := syncObject.(*appsv1alpha1.PeriodicSync).Status
+				Expect(status.LastSyncResult).To(Equal(appsv1alpha1.SyncResult{Unsupported: 1}))
+				Expect(status.UnsupportedGUIDs).To(ConsistOf("tcp-route-guid"))
+				Expect(status.FailedGUIDs).To(BeEmpty())
+
+				Expect(recorder.Events).To(Receive(Equal(
+					"Warning UnsupportedResources 1 Route resource(s) can't be fully synced, e.g. tcp-route-guid: the Route API can't express protocol tcp on port 1024",
+				)))
+			})
+		})
+
+		Context("when CC has a route with an HTTP/2 destination", func() {
+			var recorder *record.FakeRecorder
+
+			BeforeEach(func() {
+				recorder = record.NewFakeRecorder(10)
+				reconciler.Recorder = recorder
+
+				cfClient.ListRoutesReturns(model.RouteList{
+					Resources: []model.Route{{
+						GUID:     "route-guid",
+						Protocol: model.RouteProtocolHTTP,
+						Destinations: []model.Destination{{
+							GUID:     "destination-guid",
+							Port:     8080,
+							Protocol: model.DestinationProtocolHTTP2,
+						}},
+						Relationships: map[string]model.Relationship{
+							"space":  {Data: model.RelationshipData{GUID: "space-guid"}},
+							"domain": {Data: model.RelationshipData{GUID: "domain-guid"}},
+						},
+					}},
+					Included: model.RouteListIncluded{
+						Spaces:  []model.Space{{GUID: "space-guid"}},
+						Domains: []model.Domain{{GUID: "domain-guid", Name: "example.com"}},
+					},
+				}, nil)
+			})
+
+			It("syncs the route and reports the destination's protocol as unsupported", func() {
+				_, err := reconciler.Reconcile(request)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(client.CreateCallCount()).To(Equal(1))
+				_, createdObject, _ := client.CreateArgsForCall(0)
+				Expect(createdObject.(*networkingv1alpha1.Route).Spec.Destinations).To(HaveLen(1))
+
+				_, syncObject, _ := client.UpdateArgsForCall(0)
+				status := syncObject.(*appsv1alpha1.PeriodicSync).Status
+				Expect(status.LastSyncResult).To(Equal(appsv1alpha1.SyncResult{Created: 1, Unsupported: 1}))
+				Expect(status.UnsupportedGUIDs).To(ConsistOf("route-guid"))
+				Expect(recorder.Events).To(Receive(ContainSubstring("protocol http2 of destination destination-guid")))
+			})
+		})
+
+		Context("when CC leaves out the space of a route", func() {
+			BeforeEach(func() {
+				cfClient.ListRoutesReturns(model.RouteList{
+					Resources: []model.Route{{
+						GUID: "route-guid",
+						Host: "some-host",
+						Relationships: map[string]model.Relationship{
+							"space":  {Data: model.RelationshipData{GUID: "space-guid"}},
+							"domain": {Data: model.RelationshipData{GUID: "domain-guid"}},
+						},
+					}},
+					Included: model.RouteListIncluded{
+						Domains: []model.Domain{{GUID: "domain-guid", Name: "example.com"}},
+					},
+				}, nil)
+				cfClient.GetSpaceReturns(model.SpaceResponse{
+					Space: model.Space{
+						GUID: "space-guid",
+						Name: "some-space",
+						Relationships: map[string]model.Relationship{
+							"organization": {Data: model.RelationshipData{GUID: "org-guid"}},
+						},
+					},
+					Included: model.SpaceIncluded{
+						Organizations: []model.Organization{{GUID: "org-guid", Name: "some-org"}},
+					},
+				}, nil)
+			})
+
+			It("fetches the space and creates the Route", func() {
+				_, err := reconciler.Reconcile(request)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(cfClient.GetSpaceCallCount()).To(Equal(1))
+				_, requestedGUID := cfClient.GetSpaceArgsForCall(0)
+				Expect(requestedGUID).To(Equal("space-guid"))
+
+				Expect(client.CreateCallCount()).To(Equal(1))
+				_, createdObject, _ := client.CreateArgsForCall(0)
+				createdRoute := createdObject.(*networkingv1alpha1.Route)
+				Expect(createdRoute.Labels).To(HaveKeyWithValue(kubernetes.CFSpaceGuidLabel, "space-guid"))
+				Expect(createdRoute.Annotations).To(HaveKeyWithValue(kubernetes.CFOrgNameAnnotation, "some-org"))
+			})
+
+			When("the space can't be fetched either", func() {
+				BeforeEach(func() {
+					cfClient.GetSpaceReturns(model.SpaceResponse{}, errors.New("cc is down"))
+					client.ListCalls(func(_ context.Context, object runtime.Object, _ ...ctrlClient.ListOption) error {
+						ptr := object.(*networkingv1alpha1.RouteList)
+						*ptr = networkingv1alpha1.RouteList{
+							Items: []networkingv1alpha1.Route{{ObjectMeta: metav1.ObjectMeta{Name: "route-guid"}}},
+						}
+						return nil
+					})
+				})
+
+				It("leaves the Route alone and records the route as failed", func() {
+					_, err := reconciler.Reconcile(request)
+					Expect(err).To(MatchError("failed to reconcile at least one route"))
+
+					Expect(client.CreateCallCount()).To(Equal(0))
+					Expect(client.UpdateCallCount()).To(Equal(1))
+					Expect(client.DeleteCallCount()).To(Equal(0))
+
+					_, syncObject, _ := client.UpdateArgsForCall(0)
+					status := syncObject.(*appsv1alpha1.PeriodicSync).Status
+					Expect(status.LastSyncResult).To(Equal(appsv1alpha1.SyncResult{Failed: 1}))
+					Expect(status.FailedGUIDs).To(ConsistOf("route-guid"))
+				})
+			})
+		})
+
+		Context("when the syncer panics", func() {
+			BeforeEach(func() {
+				cfClient.ListRoutesCalls(func(context.Context) (model.RouteList, error) {
+					panic("malformed response")
+				})
+			})
+
+			It("fails the sync instead of crashing", func() {
+				_, err := reconciler.Reconcile(request)
+				Expect(err).To(MatchError("Route syncer panicked: malformed response"))
+
+				_, syncObject, _ := client.UpdateArgsForCall(0)
+				conditions := syncObject.(*appsv1alpha1.PeriodicSync).Status.Conditions
+				Expect(conditions).To(ConsistOf(gstruct.MatchFields(gstruct.IgnoreExtras, gstruct.Fields{
+					"Status":  Equal(appsv1alpha1.FalseConditionStatus),
+					"Message": Equal("Route syncer panicked: malformed response"),
+				})))
+			})
+		})
+
 		Context("when it fails to delete routes", func() {
 			var (
 				errMsg = "error deleting k8s route o no"
@@ -209,7 +674,7 @@ var _ = Describe("PeriodicSyncController", func() {
 				client.ListCalls(func(_ context.Context, object runtime.Object, _ ...ctrlClient.ListOption) error {
 					ptr := object.(*networkingv1alpha1.RouteList)
 					*ptr = networkingv1alpha1.RouteList{
-						Items: []networkingv1alpha1.Route{{}},
+						Items: []networkingv1alpha1.Route{{ObjectMeta: metav1.ObjectMeta{Name: "route-guid-1"}}},
 					}
 					return nil
 				})
@@ -229,6 +694,28 @@ var _ = Describe("PeriodicSyncController", func() {
 					"Message": Equal("failed to reconcile at least one route"),
 				})))
 			})
+
+			It("counts the failure in the route sync metrics", func() {
+				counter := metrics.PeriodicSyncChanges.WithLabelValues(string(appsv1alpha1.RouteResourceType), metrics.SyncActionFailed)
+				before := testutil.ToFloat64(counter)
+
+				_, err := reconciler.Reconcile(request)
+				Expect(err).To(HaveOccurred())
+
+				Expect(testutil.ToFloat64(counter)).To(Equal(before + 1))
+			})
+
+			It("records the failed route on the PeriodicSync's Status", func() {
+				_, err := reconciler.Reconcile(request)
+				Expect(err).To(HaveOccurred())
+
+				_, syncObject, _ := client.UpdateArgsForCall(0)
+				status := syncObject.(*appsv1alpha1.PeriodicSync).Status
+				Expect(status.LastSyncResult).To(Equal(appsv1alpha1.SyncResult{Failed: 1}))
+				Expect(status.FailedGUIDs).To(ConsistOf("route-guid-1"))
+				Expect(status.LastSyncTime).NotTo(BeNil())
+				Expect(status.LastSuccessfulSyncTime).To(BeNil())
+			})
 		})
 	})
 })
@@ -0,0 +1,335 @@
+package units_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"code.cloudfoundry.org/capi-k8s-release/src/cf-api-controllers/cf"
+	"code.cloudfoundry.org/capi-k8s-release/src/cf-api-controllers/cf/cffakes"
+	"code.cloudfoundry.org/capi-k8s-release/src/cf-api-controllers/cf/kubernetes"
+	"code.cloudfoundry.org/capi-k8s-release/src/cf-api-controllers/cf/model"
+	. "code.cloudfoundry.org/capi-k8s-release/src/cf-api-controllers/controllers"
+	"code.cloudfoundry.org/capi-k8s-release/src/cf-api-controllers/controllers/fake"
+	networkingv1alpha1 "code.cloudfoundry.org/cf-k8s-networking/routecontroller/apis/networking/v1alpha1"
+	logrTesting "github.com/go-logr/logr/testing"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+)
+
+var _ = Describe("RouteReconciler", func() {
+	const (
+		workloadsNamespace = "cf-workloads"
+		routeGUID          = "route-guid"
+	)
+
+	var (
+		reconciler  *RouteReconciler
+		client      *fake.ControllerRuntimeClient
+		cfClient    *cffakes.FakeClientInterface
+		request     ctrl.Request
+		ccRoute     model.RouteResponse
+		actualRoute *networkingv1alpha1.Route
+	)
+
+	BeforeEach(func() {
+		client = new(fake.ControllerRuntimeClient)
+		cfClient = new(cffakes.FakeClientInterface)
+		ccRoute = model.RouteResponse{
+			Route: model.Route{
+				GUID: routeGUID,
+				Host: "new-host",
+				URL:  "new-host.example.com",
+				Relationships: map[string]model.Relationship{
+					"space":  {Data: model.RelationshipData{GUID: "space-guid"}},
+					"domain": {Data: model.RelationshipData{GUID: "domain-guid"}},
+				},
+			},
+			Included: model.RouteListIncluded{
+				Spaces: []model.Space{{
+					GUID: "space-guid",
+					Relationships: map[string]model.Relationship{
+						"organization": {Data: model.RelationshipData{GUID: "org-guid"}},
+					},
+				}},
+				Domains: []model.Domain{{GUID: "domain-guid", Name: "example.com"}},
+			},
+		}
+		cfClient.GetRouteCalls(func(context.Context, string) (model.RouteResponse, error) {
+			return ccRoute, nil
+		})
+
+		actualRoute = &networkingv1alpha1.Route{
+			ObjectMeta: metav1.ObjectMeta{Name: routeGUID, Namespace: workloadsNamespace},
+			Spec: networkingv1alpha1.RouteSpec{
+				Host:   "old-host",
+				Domain: networkingv1alpha1.RouteDomain{Name: "example.com"},
+			},
+		}
+		client.GetCalls(func(_ context.Context, _ types.NamespacedName, object runtime.Object) error {
+			if actualRoute == nil {
+				return apierrors.NewNotFound(schema.GroupResource{}, routeGUID)
+			}
+			ptr := object.(*networkingv1alpha1.Route)
+			*ptr = *actualRoute
+			return nil
+		})
+
+		reconciler = &RouteReconciler{
+			Client:             client,
+			Log:                logrTesting.NullLogger{},
+			CFClient:           cfClient,
+			WorkloadsNamespace: workloadsNamespace,
+		}
+		request = ctrl.Request{
+			NamespacedName: types.NamespacedName{Namespace: workloadsNamespace, Name: routeGUID},
+		}
+	})
+
+	When("the Route has drifted from CC", func() {
+		It("fetches only that route and updates the Route", func() {
+			result, err := reconciler.Reconcile(request)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result).To(Equal(ctrl.Result{}))
+
+			Expect(cfClient.GetRouteCallCount()).To(Equal(1))
+			_, requestedGUID := cfClient.GetRouteArgsForCall(0)
+			Expect(requestedGUID).To(Equal(routeGUID))
+			Expect(cfClient.ListRoutesCallCount()).To(Equal(0))
+
+			Expect(client.UpdateCallCount()).To(Equal(1))
+			_, updatedObject, _ := client.UpdateArgsForCall(0)
+			Expect(updatedObject.(*networkingv1alpha1.Route).Spec.Host).To(Equal("new-host"))
+			Expect(client.CreateCallCount()).To(Equal(0))
+			Expect(client.DeleteCallCount()).To(Equal(0))
+		})
+	})
+
+	When("the controller is switched off", func() {
+		BeforeEach(func() {
+			reconciler.Switch = new(ControllerSwitch)
+			reconciler.Switch.Set(false)
+		})
+
+		It("leaves the Route for when it is switched back on", func() {
+			result, err := reconciler.Reconcile(request)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result).To(Equal(ctrl.Result{}))
+
+			Expect(cfClient.GetRouteCallCount()).To(Equal(0))
+			Expect(client.UpdateCallCount()).To(Equal(0))
+		})
+	})
+
+	// matchingRoute is the Route as translated from ccRoute, with a label set by
+	// someone else
+	matchingRoute := func() *networkingv1alpha1.Route {
+		route := kubernetes.TranslateRoute(&ccRoute.Route, &ccRoute.Included.Spaces[0], nil, &ccRoute.Included.Domains[0], workloadsNamespace)
+		route.Labels["example.com/other"] = "value"
+		return &route
+	}
+
+	When("the Route matches CC", func() {
+		BeforeEach(func() {
+			actualRoute = matchingRoute()
+		})
+
+		It("does nothing", func() {
+			_, err := reconciler.Reconcile(request)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(client.UpdateCallCount()).To(Equal(0))
+			Expect(client.CreateCallCount()).To(Equal(0))
+			Expect(client.DeleteCallCount()).To(Equal(0))
+		})
+	})
+
+	When("the route's labels changed in CF", func() {
+		BeforeEach(func() {
+			actualRoute = matchingRoute()
+			ccRoute.Metadata.Labels = map[string]string{"env": "production"}
+		})
+
+		It("mirrors them onto the Route, keeping the labels set by others", func() {
+			_, err := reconciler.Reconcile(request)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(client.UpdateCallCount()).To(Equal(1))
+			_, updatedObject, _ := client.UpdateArgsForCall(0)
+			labels := updatedObject.(*networkingv1alpha1.Route).Labels
+			Expect(labels).To(HaveKeyWithValue(kubernetes.RouteMetadataDomain+"/env", "production"))
+			Expect(labels).To(HaveKeyWithValue("example.com/other", "value"))
+		})
+	})
+
+	When("the Route was deleted but still exists in CC", func() {
+		BeforeEach(func() {
+			actualRoute = nil
+		})
+
+		It("recreates the Route", func() {
+			_, err := reconciler.Reconcile(request)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(client.CreateCallCount()).To(Equal(1))
+			_, createdObject, _ := client.CreateArgsForCall(0)
+			createdRoute := createdObject.(*networkingv1alpha1.Route)
+			Expect(createdRoute.Name).To(Equal(routeGUID))
+			Expect(createdRoute.Namespace).To(Equal(workloadsNamespace))
+			Expect(createdRoute.Spec.Host).To(Equal("new-host"))
+		})
+	})
+
+	When("the route no longer exists in CC", func() {
+		BeforeEach(func() {
+			cfClient.GetRouteReturns(model.RouteResponse{}, &cf.APIError{StatusCode: http.StatusNotFound})
+		})
+
+		It("deletes the Route", func() {
+			_, err := reconciler.Reconcile(request)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(client.DeleteCallCount()).To(Equal(1))
+			_, deletedObject, _ := client.DeleteArgsForCall(0)
+			Expect(deletedObject.(*networkingv1alpha1.Route).Name).To(Equal(routeGUID))
+			Expect(deletedObject.(*networkingv1alpha1.Route).Namespace).To(Equal(workloadsNamespace))
+		})
+
+		When("the Route is already gone from k8s", func() {
+			BeforeEach(func() {
+				client.DeleteReturns(apierrors.NewNotFound(schema.GroupResource{}, routeGUID))
+			})
+
+			It("succeeds", func() {
+				_, err := reconciler.Reconcile(request)
+				Expect(err).NotTo(HaveOccurred())
+			})
+		})
+	})
+
+	When("the route is a TCP route", func() {
+		BeforeEach(func() {
+			port := 1024
+			ccRoute.Protocol = model.RouteProtocolTCP
+			ccRoute.Port = &port
+		})
+
+		It("leaves the Route as it is", func() {
+			_, err := reconciler.Reconcile(request)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(client.CreateCallCount()).To(Equal(0))
+			Expect(client.UpdateCallCount()).To(Equal(0))
+			Expect(client.DeleteCallCount()).To(Equal(0))
+		})
+	})
+
+	When("fetching the route from CC fails", func() {
+		BeforeEach(func() {
+			cfClient.GetRouteReturns(model.RouteResponse{}, errors.New("cc is down"))
+		})
+
+		It("returns the error to be retried", func() {
+			_, err := reconciler.Reconcile(request)
+			Expect(err).To(MatchError("cc is down"))
+
+			Expect(client.UpdateCallCount()).To(Equal(0))
+			Expect(client.DeleteCallCount()).To(Equal(0))
+		})
+	})
+
+	When("CC does not include the route's domain", func() {
+		BeforeEach(func() {
+			ccRoute.Included.Domains = nil
+			cfClient.GetDomainReturns(model.Domain{GUID: "domain-guid", Name: "example.com"}, nil)
+		})
+
+		It("fetches the domain and updates the Route", func() {
+			_, err := reconciler.Reconcile(request)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(cfClient.GetDomainCallCount()).To(Equal(1))
+			_, requestedGUID := cfClient.GetDomainArgsForCall(0)
+			Expect(requestedGUID).To(Equal("domain-guid"))
+			Expect(cfClient.GetSpaceCallCount()).To(Equal(0))
+
+			Expect(client.UpdateCallCount()).To(Equal(1))
+			_, updatedObject, _ := client.UpdateArgsForCall(0)
+			Expect(updatedObject.(*networkingv1alpha1.Route).Spec.Domain.Name).To(Equal("example.com"))
+		})
+
+		When("fetching the domain fails", func() {
+			BeforeEach(func() {
+				cfClient.GetDomainReturns(model.Domain{}, errors.New("cc is down"))
+			})
+
+			It("errors without changing the Route", func() {
+				_, err := reconciler.Reconcile(request)
+				Expect(err).To(MatchError(`failed to resolve domain "domain-guid": cc is down`))
+
+				Expect(client.UpdateCallCount()).To(Equal(0))
+				Expect(client.DeleteCallCount()).To(Equal(0))
+			})
+		})
+	})
+
+	Describe("the updates it reconciles", func() {
+		var oldRoute, newRoute *networkingv1alpha1.Route
+
+		BeforeEach(func() {
+			route := kubernetes.TranslateRoute(&ccRoute.Route, &ccRoute.Included.Spaces[0], nil, &ccRoute.Included.Domains[0], workloadsNamespace)
+			oldRoute = &route
+			oldRoute.Generation = 1
+			newRoute = oldRoute.DeepCopy()
+		})
+
+		// reconcileUpdate hands the update to the reconciler the way the
+		// controller does, if the event filter lets it through
+		reconcileUpdate := func() {
+			update := event.UpdateEvent{MetaOld: oldRoute, ObjectOld: oldRoute, MetaNew: newRoute, ObjectNew: newRoute}
+			if reconciler.EventFilter().Update(update) {
+				_, err := reconciler.Reconcile(request)
+				Expect(err).NotTo(HaveOccurred())
+			}
+		}
+
+		It("doesn't fetch the route from CC when only the status changed", func() {
+			newRoute.Status.Conditions = []networkingv1alpha1.Condition{{Type: "Ready", Status: true}}
+			reconcileUpdate()
+
+			Expect(cfClient.GetRouteCallCount()).To(Equal(0))
+		})
+
+		It("fetches the route from CC when the spec changed", func() {
+			newRoute.Spec.Host = "drifted-host"
+			newRoute.Generation = 2
+			reconcileUpdate()
+
+			Expect(cfClient.GetRouteCallCount()).To(Equal(1))
+		})
+
+		It("fetches the route from CC when the labels or annotations changed", func() {
+			newRoute.Annotations[kubernetes.CFSpaceNameAnnotation] = "drifted-space"
+			reconcileUpdate()
+
+			Expect(cfClient.GetRouteCallCount()).To(Equal(1))
+		})
+
+		It("ignores Routes not managed by CF", func() {
+			delete(oldRoute.Labels, kubernetes.KubeManagedByLabel)
+			delete(newRoute.Labels, kubernetes.KubeManagedByLabel)
+			newRoute.Spec.Host = "other-host"
+			newRoute.Generation = 2
+			reconcileUpdate()
+
+			Expect(cfClient.GetRouteCallCount()).To(Equal(0))
+		})
+	})
+})
@@ -0,0 +1,270 @@
+package units_test
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	appsv1alpha1 "code.cloudfoundry.org/capi-k8s-release/src/cf-api-controllers/apis/apps.cloudfoundry.org/v1alpha1"
+	"code.cloudfoundry.org/capi-k8s-release/src/cf-api-controllers/cf/model"
+	. "code.cloudfoundry.org/capi-k8s-release/src/cf-api-controllers/controllers"
+	"code.cloudfoundry.org/capi-k8s-release/src/cf-api-controllers/controllers/fake"
+	logrTesting "github.com/go-logr/logr/testing"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+)
+
+var _ = Describe("StackRebaseRolloutPolicy", func() {
+	const (
+		workloadsNamespace = "cf-workloads-fake"
+		appGUID            = "some-app-guid"
+	)
+
+	var (
+		policy      *StackRebaseRolloutPolicy
+		client      *fake.ControllerRuntimeClient
+		cfClient    *fake.CFAppFetcher
+		rollout     *appsv1alpha1.StackRebaseRollout
+		ccApp       model.AppResponse
+		statefulset *appsv1.StatefulSet
+	)
+
+	BeforeEach(func() {
+		client = new(fake.ControllerRuntimeClient)
+		client.StatusReturns(client)
+		cfClient = new(fake.CFAppFetcher)
+
+		rollout = &appsv1alpha1.StackRebaseRollout{
+			ObjectMeta: metav1.ObjectMeta{Name: "some-rollout", Namespace: "cf-system", Generation: 2},
+			Spec:       appsv1alpha1.StackRebaseRolloutSpec{MaxConcurrentRestarts: 2, PauseBetweenBatchesSeconds: 60},
+		}
+		client.GetCalls(func(_ context.Context, _ types.NamespacedName, object runtime.Object) error {
+			if rollout == nil {
+				return apierrors.NewNotFound(schema.GroupResource{}, "some-rollout")
+			}
+			ptr := object.(*appsv1alpha1.StackRebaseRollout)
+			*ptr = *rollout.DeepCopy()
+			return nil
+		})
+
+		ccApp = model.AppResponse{
+			App: model.App{
+				GUID: appGUID,
+				Relationships: map[string]model.Relationship{
+					"space": {Data: model.RelationshipData{GUID: "space-guid"}},
+				},
+			},
+			Included: model.AppIncluded{
+				Spaces: []model.Space{{
+					GUID: "space-guid",
+					Relationships: map[string]model.Relationship{
+						"organization": {Data: model.RelationshipData{GUID: "org-guid"}},
+					},
+				}},
+				Organizations: []model.Organization{{GUID: "org-guid"}},
+			},
+		}
+		cfClient.GetAppCalls(func(context.Context, string) (model.AppResponse, error) {
+			return ccApp, nil
+		})
+
+		// a StatefulSet of an app from the current batch that is still restarting
+		replicas := int32(2)
+		statefulset = &appsv1.StatefulSet{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:       "restarting-app-web",
+				Namespace:  workloadsNamespace,
+				Generation: 3,
+				Labels:     map[string]string{AppGUIDLabel: "restarting-app-guid"},
+			},
+			Spec:   appsv1.StatefulSetSpec{Replicas: &replicas},
+			Status: appsv1.StatefulSetStatus{ObservedGeneration: 3, UpdatedReplicas: 1, ReadyReplicas: 2},
+		}
+	})
+
+	JustBeforeEach(func() {
+		policy = &StackRebaseRolloutPolicy{
+			Client:             client,
+			CFClient:           cfClient,
+			AppsClientSet:      k8sfake.NewSimpleClientset(statefulset).AppsV1(),
+			WorkloadsNamespace: workloadsNamespace,
+			Name:               types.NamespacedName{Namespace: "cf-system", Name: "some-rollout"},
+		}
+	})
+
+	updatedStatus := func() appsv1alpha1.StackRebaseRolloutStatus {
+		Expect(client.UpdateCallCount()).To(Equal(1))
+		_, updatedObject, _ := client.UpdateArgsForCall(0)
+		return updatedObject.(*appsv1alpha1.StackRebaseRollout).Status
+	}
+
+	rollingOutCondition := func(status appsv1alpha1.StackRebaseRolloutStatus) appsv1alpha1.Condition {
+		Expect(status.Conditions).To(HaveLen(1))
+		Expect(status.Conditions[0].Type).To(Equal(appsv1alpha1.RollingOutConditionType))
+		return status.Conditions[0]
+	}
+
+	When("the rollout does not exist", func() {
+		BeforeEach(func() {
+			rollout = nil
+		})
+
+		It("admits the app without looking it up", func() {
+			decision, _, err := policy.Admit(context.Background(), appGUID, logrTesting.NullLogger{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(decision).To(Equal(RolloutAdmitted))
+			Expect(cfClient.GetAppCallCount()).To(Equal(0))
+			Expect(client.UpdateCallCount()).To(Equal(0))
+		})
+	})
+
+	When("the current batch has room", func() {
+		It("admits the app, starting a batch", func() {
+			decision, _, err := policy.Admit(context.Background(), appGUID, logrTesting.NullLogger{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(decision).To(Equal(RolloutAdmitted))
+
+			status := updatedStatus()
+			Expect(status.ObservedGeneration).To(Equal(int64(2)))
+			Expect(status.BatchStartTime).NotTo(BeNil())
+			Expect(status.BatchRestarts).To(Equal(int32(1)))
+			Expect(status.RestartingAppGUIDs).To(Equal([]string{appGUID}))
+			Expect(status.Rebased).To(Equal(int32(1)))
+			Expect(status.LastRebasedAppGUID).To(Equal(appGUID))
+			Expect(rollingOutCondition(status).Reason).To(Equal(appsv1alpha1.ProgressingConditionReason))
+		})
+	})
+
+	When("the app was already admitted to the current batch", func() {
+		BeforeEach(func() {
+			rollout.Status.BatchRestarts = 2
+			rollout.Status.RestartingAppGUIDs = []string{"restarting-app-guid", appGUID}
+		})
+
+		It("admits it again without counting it twice", func() {
+			decision, _, err := policy.Admit(context.Background(), appGUID, logrTesting.NullLogger{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(decision).To(Equal(RolloutAdmitted))
+			Expect(client.UpdateCallCount()).To(Equal(0))
+		})
+	})
+
+	When("the rollout is paused", func() {
+		BeforeEach(func() {
+			rollout.Spec.Paused = true
+		})
+
+		It("defers the app and reports the rollout as paused", func() {
+			decision, wait, err := policy.Admit(context.Background(), appGUID, logrTesting.NullLogger{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(decision).To(Equal(RolloutDeferred))
+			Expect(wait).To(BeNumerically(">", 0))
+
+			condition := rollingOutCondition(updatedStatus())
+			Expect(condition.Status).To(Equal(appsv1alpha1.FalseConditionStatus))
+			Expect(condition.Reason).To(Equal(appsv1alpha1.PausedConditionReason))
+		})
+	})
+
+	When("the app's space opted out", func() {
+		BeforeEach(func() {
+			ccApp.Included.Spaces[0].Metadata.Labels = map[string]string{appsv1alpha1.StackRebaseOptOutLabel: "true"}
+		})
+
+		It("skips the app and counts it as opted out", func() {
+			decision, _, err := policy.Admit(context.Background(), appGUID, logrTesting.NullLogger{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(decision).To(Equal(RolloutOptedOut))
+
+			status := updatedStatus()
+			Expect(status.OptedOut).To(Equal(int32(1)))
+			Expect(status.BatchRestarts).To(Equal(int32(0)))
+		})
+	})
+
+	When("the app's org opted out", func() {
+		BeforeEach(func() {
+			ccApp.Included.Organizations[0].Metadata.Labels = map[string]string{appsv1alpha1.StackRebaseOptOutLabel: "true"}
+		})
+
+		It("skips the app", func() {
+			decision, _, err := policy.Admit(context.Background(), appGUID, logrTesting.NullLogger{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(decision).To(Equal(RolloutOptedOut))
+		})
+	})
+
+	When("the app can't be fetched from CC", func() {
+		BeforeEach(func() {
+			cfClient.GetAppCalls(nil)
+			cfClient.GetAppReturns(model.AppResponse{}, errors.New("cc is down"))
+		})
+
+		It("returns the error to be retried", func() {
+			_, _, err := policy.Admit(context.Background(), appGUID, logrTesting.NullLogger{})
+			Expect(err).To(MatchError("cc is down"))
+			Expect(client.UpdateCallCount()).To(Equal(0))
+		})
+	})
+
+	When("the current batch is full", func() {
+		BeforeEach(func() {
+			rollout.Status.BatchRestarts = 2
+			rollout.Status.RestartingAppGUIDs = []string{"restarting-app-guid", "restarted-app-guid"}
+		})
+
+		It("defers the app until the batch's apps have restarted", func() {
+			decision, wait, err := policy.Admit(context.Background(), appGUID, logrTesting.NullLogger{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(decision).To(Equal(RolloutDeferred))
+			Expect(wait).To(BeNumerically(">", 0))
+
+			status := updatedStatus()
+			Expect(status.RestartingAppGUIDs).To(Equal([]string{"restarting-app-guid"}))
+			Expect(rollingOutCondition(status).Reason).To(Equal(appsv1alpha1.BatchFullConditionReason))
+		})
+
+		When("every app of the batch has restarted", func() {
+			BeforeEach(func() {
+				statefulset.Status.UpdatedReplicas = 2
+			})
+
+			It("finishes the batch and pauses before the next one", func() {
+				decision, wait, err := policy.Admit(context.Background(), appGUID, logrTesting.NullLogger{})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(decision).To(Equal(RolloutDeferred))
+				Expect(wait).To(BeNumerically("~", time.Minute, time.Second))
+
+				status := updatedStatus()
+				Expect(status.RestartingAppGUIDs).To(BeEmpty())
+				Expect(status.BatchRestarts).To(Equal(int32(0)))
+				Expect(status.LastBatchFinishTime).NotTo(BeNil())
+				Expect(rollingOutCondition(status).Reason).To(Equal(appsv1alpha1.BetweenBatchesConditionReason))
+			})
+		})
+	})
+
+	When("the pause after the last batch has passed", func() {
+		BeforeEach(func() {
+			finished := metav1.NewTime(time.Now().Add(-2 * time.Minute))
+			rollout.Status.LastBatchFinishTime = &finished
+		})
+
+		It("admits the app into a new batch", func() {
+			decision, _, err := policy.Admit(context.Background(), appGUID, logrTesting.NullLogger{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(decision).To(Equal(RolloutAdmitted))
+
+			status := updatedStatus()
+			Expect(status.BatchRestarts).To(Equal(int32(1)))
+			Expect(status.BatchStartTime).NotTo(BeNil())
+		})
+	})
+})
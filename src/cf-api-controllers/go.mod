@@ -8,11 +8,14 @@ require (
 	github.com/cloudfoundry-community/go-uaa v0.3.1
 	github.com/go-logr/logr v0.1.0
 	github.com/google/go-containerregistry v0.1.1
+	github.com/google/uuid v1.1.1
 	github.com/matt-royal/biloba v0.2.1
 	github.com/onsi/ginkgo v1.14.0
 	github.com/onsi/gomega v1.10.1
 	github.com/pivotal/kpack v0.1.2
+	github.com/prometheus/client_golang v1.5.0
 	golang.org/x/net v0.0.0-20200813134508-3edf25e44fcc // indirect
+	golang.org/x/oauth2 v0.0.0-20200107190931-bf48bf16ab8d
 	golang.org/x/sys v0.0.0-20200824131525-c12d262b63d8 // indirect
 	google.golang.org/protobuf v1.25.0 // indirect
 	k8s.io/api v0.17.6
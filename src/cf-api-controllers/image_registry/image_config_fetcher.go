@@ -1,27 +1,50 @@
 package image_registry
 
 import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"time"
+
 	"github.com/google/go-containerregistry/pkg/name"
 	v1 "github.com/google/go-containerregistry/pkg/v1"
 	"github.com/google/go-containerregistry/pkg/v1/remote"
 	"github.com/pivotal/kpack/pkg/registry"
 )
 
+// DefaultTimeout bounds fetching an image config when no timeout is configured
+const DefaultTimeout = time.Minute
+
 //go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 . ImageConfigFetcher
 type ImageConfigFetcher interface {
-	FetchImageConfig(imageReference, buildServiceAccount, buildNamespace string) (*v1.Config, error)
+	FetchImageConfig(ctx context.Context, imageReference, buildServiceAccount, buildNamespace string) (*v1.Config, error)
 }
 
 type ociImageConfigFetcher struct {
 	KeychainFactory    registry.KeychainFactory
 	ImageConfigFetcher ImageConfigFetcher
+
+	// timeout is a time.Duration, accessed atomically as it is changed while
+	// fetches are in flight
+	timeout int64
+}
+
+func NewImageConfigFetcher(keychainFactory registry.KeychainFactory, timeout time.Duration) *ociImageConfigFetcher {
+	f := &ociImageConfigFetcher{KeychainFactory: keychainFactory}
+	f.SetTimeout(timeout)
+	return f
 }
 
-func NewImageConfigFetcher(keychainFactory registry.KeychainFactory) ociImageConfigFetcher {
-	return ociImageConfigFetcher{KeychainFactory: keychainFactory}
+// SetTimeout changes how long later fetches may take, falling back to
+// DefaultTimeout when it isn't positive
+func (f *ociImageConfigFetcher) SetTimeout(timeout time.Duration) {
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	atomic.StoreInt64(&f.timeout, int64(timeout))
 }
 
-func (f ociImageConfigFetcher) FetchImageConfig(imageReference, secretServiceAccount, secretNamespace string) (*v1.Config, error) {
+func (f *ociImageConfigFetcher) FetchImageConfig(ctx context.Context, imageReference, secretServiceAccount, secretNamespace string) (*v1.Config, error) {
 	ref, err := name.ParseReference(imageReference)
 	if err != nil {
 		return nil, err
@@ -35,7 +58,12 @@ func (f ociImageConfigFetcher) FetchImageConfig(imageReference, secretServiceAcc
 		return nil, err
 	}
 
-	img, err := remote.Image(ref, remote.WithAuthFromKeychain(keychain))
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(atomic.LoadInt64(&f.timeout)))
+	defer cancel()
+	img, err := remote.Image(ref,
+		remote.WithAuthFromKeychain(keychain),
+		remote.WithTransport(&contextTransport{ctx: ctx, base: http.DefaultTransport}),
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -48,3 +76,14 @@ func (f ociImageConfigFetcher) FetchImageConfig(imageReference, secretServiceAcc
 	// TODO: address potential nil-pointer deref here
 	return &cfgFile.Config, nil
 }
+
+// contextTransport sends every request with ctx, as this version of remote
+// can't be given one
+type contextTransport struct {
+	ctx  context.Context
+	base http.RoundTripper
+}
+
+func (t *contextTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return t.base.RoundTrip(req.WithContext(t.ctx))
+}
@@ -1,6 +1,7 @@
 package image_registry
 
 import (
+	"context"
 	"fmt"
 	"testing"
 
@@ -39,13 +40,13 @@ var _ = Describe("ImageConfigFetcher", func() {
 
 			BeforeEach(func() {
 				keychainFactory.AddKeychainForSecretRef(emptyT, registry.SecretRef{}, &registryfakes.FakeKeychain{})
-				fetcher = NewImageConfigFetcher(keychainFactory)
+				fetcher = NewImageConfigFetcher(keychainFactory, DefaultTimeout)
 			})
 
 			It("returns a valid, expected OCI Image Config", func() {
 				// small, distro-less public Docker image
 				// Verify by running `docker pull [ref]` and `docker inspect [ref]`
-				imageConfig, err = fetcher.FetchImageConfig("busybox@sha256:a2490cec4484ee6c1068ba3a05f89934010c85242f736280b35343483b2264b6", "", "")
+				imageConfig, err = fetcher.FetchImageConfig(context.Background(), "busybox@sha256:a2490cec4484ee6c1068ba3a05f89934010c85242f736280b35343483b2264b6", "", "")
 
 				Expect(err).ToNot(HaveOccurred())
 				Expect(imageConfig).ToNot(BeNil())
@@ -66,7 +67,7 @@ var _ = Describe("ImageConfigFetcher", func() {
 			)
 
 			BeforeEach(func() {
-				fetcher = NewImageConfigFetcher(keychainFactory)
+				fetcher = NewImageConfigFetcher(keychainFactory, DefaultTimeout)
 				fakeRegistryServer = ghttp.NewServer()
 				fakeRegistryServer.AppendHandlers(
 					ghttp.CombineHandlers(
@@ -128,7 +129,7 @@ var _ = Describe("ImageConfigFetcher", func() {
 				appImageKeychain := &registryfakes.FakeKeychain{}
 				keychainFactory.AddKeychainForSecretRef(emptyT, appImageSecretRef, appImageKeychain)
 				registryDomain := strings.TrimPrefix(fakeRegistryServer.URL(), `http://`)
-				imageConfig, err = fetcher.FetchImageConfig(fmt.Sprintf("%s/busybox@sha256:4bc6920026921689d030c4dcb3f960cb5bdd5883dbe4622ae1f2d2accae3c0fd", registryDomain), "build-service-account", "build-namespace")
+				imageConfig, err = fetcher.FetchImageConfig(context.Background(), fmt.Sprintf("%s/busybox@sha256:4bc6920026921689d030c4dcb3f960cb5bdd5883dbe4622ae1f2d2accae3c0fd", registryDomain), "build-service-account", "build-namespace")
 
 				Expect(err).ToNot(HaveOccurred())
 				Expect(imageConfig).ToNot(BeNil())
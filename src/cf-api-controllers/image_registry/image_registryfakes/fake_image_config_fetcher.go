@@ -2,6 +2,7 @@
 package image_registryfakes
 
 import (
+	"context"
 	"sync"
 
 	"code.cloudfoundry.org/capi-k8s-release/src/cf-api-controllers/image_registry"
@@ -9,12 +10,13 @@ import (
 )
 
 type FakeImageConfigFetcher struct {
-	FetchImageConfigStub        func(string, string, string) (*v1.Config, error)
+	FetchImageConfigStub        func(context.Context, string, string, string) (*v1.Config, error)
 	fetchImageConfigMutex       sync.RWMutex
 	fetchImageConfigArgsForCall []struct {
-		arg1 string
+		arg1 context.Context
 		arg2 string
 		arg3 string
+		arg4 string
 	}
 	fetchImageConfigReturns struct {
 		result1 *v1.Config
@@ -28,18 +30,19 @@ type FakeImageConfigFetcher struct {
 	invocationsMutex sync.RWMutex
 }
 
-func (fake *FakeImageConfigFetcher) FetchImageConfig(arg1 string, arg2 string, arg3 string) (*v1.Config, error) {
+func (fake *FakeImageConfigFetcher) FetchImageConfig(arg1 context.Context, arg2 string, arg3 string, arg4 string) (*v1.Config, error) {
 	fake.fetchImageConfigMutex.Lock()
 	ret, specificReturn := fake.fetchImageConfigReturnsOnCall[len(fake.fetchImageConfigArgsForCall)]
 	fake.fetchImageConfigArgsForCall = append(fake.fetchImageConfigArgsForCall, struct {
-		arg1 string
+		arg1 context.Context
 		arg2 string
 		arg3 string
-	}{arg1, arg2, arg3})
-	fake.recordInvocation("FetchImageConfig", []interface{}{arg1, arg2, arg3})
+		arg4 string
+	}{arg1, arg2, arg3, arg4})
+	fake.recordInvocation("FetchImageConfig", []interface{}{arg1, arg2, arg3, arg4})
 	fake.fetchImageConfigMutex.Unlock()
 	if fake.FetchImageConfigStub != nil {
-		return fake.FetchImageConfigStub(arg1, arg2, arg3)
+		return fake.FetchImageConfigStub(arg1, arg2, arg3, arg4)
 	}
 	if specificReturn {
 		return ret.result1, ret.result2
@@ -54,17 +57,17 @@ func (fake *FakeImageConfigFetcher) FetchImageConfigCallCount() int {
 	return len(fake.fetchImageConfigArgsForCall)
 }
 
-func (fake *FakeImageConfigFetcher) FetchImageConfigCalls(stub func(string, string, string) (*v1.Config, error)) {
+func (fake *FakeImageConfigFetcher) FetchImageConfigCalls(stub func(context.Context, string, string, string) (*v1.Config, error)) {
 	fake.fetchImageConfigMutex.Lock()
 	defer fake.fetchImageConfigMutex.Unlock()
 	fake.FetchImageConfigStub = stub
 }
 
-func (fake *FakeImageConfigFetcher) FetchImageConfigArgsForCall(i int) (string, string, string) {
+func (fake *FakeImageConfigFetcher) FetchImageConfigArgsForCall(i int) (context.Context, string, string, string) {
 	fake.fetchImageConfigMutex.RLock()
 	defer fake.fetchImageConfigMutex.RUnlock()
 	argsForCall := fake.fetchImageConfigArgsForCall[i]
-	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4
 }
 
 func (fake *FakeImageConfigFetcher) FetchImageConfigReturns(result1 *v1.Config, result2 error) {
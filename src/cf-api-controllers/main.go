@@ -17,21 +17,24 @@ limitations under the License.
 package main
 
 import (
-	"crypto/tls"
+	"context"
 	"flag"
-	"net/http"
+	"fmt"
 	"os"
+	"sync"
 
 	"code.cloudfoundry.org/capi-k8s-release/src/cf-api-controllers/cf"
 	"code.cloudfoundry.org/capi-k8s-release/src/cf-api-controllers/cf/auth"
 	"code.cloudfoundry.org/capi-k8s-release/src/cf-api-controllers/image_registry"
 	"github.com/pivotal/kpack/pkg/dockercreds/k8sdockercreds"
+	"k8s.io/apimachinery/pkg/types"
 	appsv1 "k8s.io/client-go/kubernetes/typed/apps/v1"
 
 	"k8s.io/client-go/kubernetes"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	_ "k8s.io/client-go/plugin/pkg/client/auth/gcp"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 
 	appsv1alpha1 "code.cloudfoundry.org/capi-k8s-release/src/cf-api-controllers/apis/apps.cloudfoundry.org/v1alpha1"
@@ -90,22 +93,41 @@ func main() {
 		panic(err.Error())
 	}
 
-	uaaClient := auth.NewUAAClient(config.uaaEndpoint, config.uaaClientName, config.uaaClientSecret)
-	httpClient := &http.Client{
-		Transport: &http.Transport{
-			TLSClientConfig: &tls.Config{
-				InsecureSkipVerify: true,
-			},
-		},
+	settings, err := loadSettings(config, mgr.GetAPIReader())
+	if err != nil {
+		setupLog.Error(err, "unable to load settings")
+		os.Exit(1)
+	}
+	cfClient, err := newCFClient(settings)
+	if err != nil {
+		setupLog.Error(err, "unable to create CF API client")
+		os.Exit(1)
+	}
+	reloadableCFClient := cf.NewReloadableClient(cfClient)
+
+	switches := map[string]*controllers.ControllerSwitch{
+		controllers.BuildControllerName:        new(controllers.ControllerSwitch),
+		controllers.ImageControllerName:        new(controllers.ControllerSwitch),
+		controllers.PeriodicSyncControllerName: new(controllers.ControllerSwitch),
+		controllers.RouteControllerName:        new(controllers.ControllerSwitch),
 	}
+	setSwitches(switches, settings)
+	imageConfigFetcher := image_registry.NewImageConfigFetcher(keychainFactory, settings.ImageRegistryTimeout)
+
+	// cancelled once the manager is told to stop, which is after it was
+	// started below
+	shutdownCtx, cancelRequests := context.WithCancel(context.Background())
+	defer cancelRequests()
+
 	if err = (&controllers.BuildReconciler{
-		Client: mgr.GetClient(),
-		Log:    ctrl.Log.WithName("controllers").WithName("Build"),
-		Scheme: mgr.GetScheme(),
-		CFClient: cf.NewClient(config.CFAPIHost(), &cf.RestClient{
-			Client: httpClient,
-		}, uaaClient),
-		ImageConfigFetcher: image_registry.NewImageConfigFetcher(keychainFactory),
+		Client:             mgr.GetClient(),
+		Log:                ctrl.Log.WithName("controllers").WithName("Build"),
+		Scheme:             mgr.GetScheme(),
+		CFClient:           reloadableCFClient,
+		ImageConfigFetcher: imageConfigFetcher,
+		LogFetcher:         controllers.NewPodLogFetcher(client.CoreV1(), controllers.DefaultBuildLogTailLines),
+		Switch:             switches[controllers.BuildControllerName],
+		Context:            shutdownCtx,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "Build")
 		os.Exit(1)
@@ -115,36 +137,176 @@ func main() {
 	if err != nil {
 		panic(err)
 	}
-	if err = (&controllers.ImageReconciler{
-		Client: mgr.GetClient(),
-		Log:    ctrl.Log.WithName("controllers").WithName("Image"),
-		Scheme: mgr.GetScheme(),
-		CFClient: cf.NewClient(config.CFAPIHost(), &cf.RestClient{
-			Client: httpClient,
-		}, uaaClient),
+	var rebaser controllers.Rebaser = &controllers.StatefulSetRebaser{
 		AppsClientSet:      clientset,
-		WorkloadsNamespace: config.WorkloadsNamespace(),
-	}).SetupWithManager(mgr); err != nil {
+		WorkloadsNamespace: settings.WorkloadsNamespace,
+	}
+	if config.RebaseTarget() == controllers.LRPRebaseTarget {
+		rebaser = &controllers.LRPRebaser{
+			Client:             mgr.GetClient(),
+			WorkloadsNamespace: settings.WorkloadsNamespace,
+		}
+	}
+	var rolloutPolicy *controllers.StackRebaseRolloutPolicy
+	if rolloutName, ok := config.StackRebaseRollout(); ok {
+		rolloutPolicy = &controllers.StackRebaseRolloutPolicy{
+			Client:             mgr.GetClient(),
+			CFClient:           reloadableCFClient,
+			AppsClientSet:      clientset,
+			WorkloadsNamespace: settings.WorkloadsNamespace,
+			Name:               rolloutName,
+		}
+	}
+	imageReconciler := &controllers.ImageReconciler{
+		Client:             mgr.GetClient(),
+		Log:                ctrl.Log.WithName("controllers").WithName("Image"),
+		Scheme:             mgr.GetScheme(),
+		CFClient:           reloadableCFClient,
+		AppsClientSet:      clientset,
+		WorkloadsNamespace: settings.WorkloadsNamespace,
+		Rebaser:            rebaser,
+		RolloutPolicy:      rolloutPolicy,
+		ReadinessWindow:    config.RebaseReadinessWindow(),
+		Switch:             switches[controllers.ImageControllerName],
+		Context:            shutdownCtx,
+	}
+	if err = imageReconciler.SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "Image")
 		os.Exit(1)
 	}
 	if err = (&controllers.PeriodicSyncReconciler{
-		Client: mgr.GetClient(),
-		CFClient: cf.NewClient(config.CFAPIHost(), &cf.RestClient{
-			Client: httpClient,
-		}, uaaClient),
-		Log:                ctrl.Log.WithName("controllers").WithName("PeriodicSync"),
-		Scheme:             mgr.GetScheme(),
-		WorkloadsNamespace: config.WorkloadsNamespace(),
+		Client:   mgr.GetClient(),
+		Log:      ctrl.Log.WithName("controllers").WithName("PeriodicSync"),
+		Scheme:   mgr.GetScheme(),
+		Recorder: mgr.GetEventRecorderFor("periodicsync-controller"),
+		Syncers: map[appsv1alpha1.ResourceType]controllers.Syncer{
+			appsv1alpha1.RouteResourceType: &controllers.RouteSyncer{
+				Client:             mgr.GetClient(),
+				CFClient:           reloadableCFClient,
+				WorkloadsNamespace: settings.WorkloadsNamespace,
+			},
+			appsv1alpha1.DropletImageResourceType: &controllers.DropletImageSyncer{
+				Client:       mgr.GetClient(),
+				CFClient:     reloadableCFClient,
+				StackRebases: imageReconciler,
+			},
+		},
+		Switch:  switches[controllers.PeriodicSyncControllerName],
+		Context: shutdownCtx,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "PeriodicSync")
 		os.Exit(1)
 	}
+	if err = (&controllers.RouteReconciler{
+		Client:             mgr.GetClient(),
+		Log:                ctrl.Log.WithName("controllers").WithName("Route"),
+		Scheme:             mgr.GetScheme(),
+		CFClient:           reloadableCFClient,
+		WorkloadsNamespace: settings.WorkloadsNamespace,
+		Switch:             switches[controllers.RouteControllerName],
+		Context:            shutdownCtx,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "Route")
+		os.Exit(1)
+	}
+
+	// the workloads namespace is baked into the controllers, so they are
+	// restarted to pick up a new one
+	stop := ctrl.SetupSignalHandler()
+	managerStop := make(chan struct{})
+	restart := make(chan struct{})
+	var restartOnce sync.Once
+	go func() {
+		select {
+		case <-stop:
+		case <-restart:
+		}
+		cancelRequests()
+		close(managerStop)
+	}()
+
+	if configName, ok := config.ControllersConfig(); ok {
+		if err = (&controllers.ControllersConfigReconciler{
+			Client:    mgr.GetClient(),
+			Log:       ctrl.Log.WithName("controllers").WithName("CFAPIControllersConfig"),
+			APIReader: mgr.GetAPIReader(),
+			Name:      configName,
+			Apply: func(newSettings controllers.ControllersSettings) error {
+				reloadedCFClient, err := newCFClient(newSettings)
+				if err != nil {
+					return err
+				}
+				reloadableCFClient.Reload(reloadedCFClient)
+				imageConfigFetcher.SetTimeout(newSettings.ImageRegistryTimeout)
+				setSwitches(switches, newSettings)
+
+				if newSettings.WorkloadsNamespace != settings.WorkloadsNamespace {
+					setupLog.Info("workloads namespace changed, restarting",
+						"from", settings.WorkloadsNamespace, "to", newSettings.WorkloadsNamespace)
+					restartOnce.Do(func() { close(restart) })
+				}
+				return nil
+			},
+			Context: shutdownCtx,
+		}).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "CFAPIControllersConfig")
+			os.Exit(1)
+		}
+	}
 	// +kubebuilder:scaffold:builder
 
 	setupLog.Info("starting manager")
-	if err := mgr.Start(ctrl.SetupSignalHandler()); err != nil {
+	if err := mgr.Start(managerStop); err != nil {
 		setupLog.Error(err, "problem running manager")
 		os.Exit(1)
 	}
 }
+
+// loadSettings reads the controllers' settings from their
+// CFAPIControllersConfig, if one is set, falling back to the environment
+// while it can't be used
+func loadSettings(config *Config, reader client.Reader) (controllers.ControllersSettings, error) {
+	envSettings, complete := config.Settings()
+	configName, ok := config.ControllersConfig()
+	if !ok {
+		return envSettings, nil
+	}
+
+	var controllersConfig appsv1alpha1.CFAPIControllersConfig
+	ctx := context.Background()
+	err := reader.Get(ctx, types.NamespacedName{Name: configName}, &controllersConfig)
+	if err == nil {
+		var settings controllers.ControllersSettings
+		settings, err = controllers.LoadControllersSettings(ctx, reader, &controllersConfig)
+		if err == nil {
+			return settings, nil
+		}
+	}
+	if !complete {
+		return controllers.ControllersSettings{}, fmt.Errorf("unable to use CFAPIControllersConfig %q: %w", configName, err)
+	}
+	setupLog.Error(err, "unable to use CFAPIControllersConfig, falling back to the environment", "name", configName)
+	return envSettings, nil
+}
+
+// newCFClient creates a CF API client authenticating as the settings' UAA
+// client, with both connecting according to the settings' TLS config
+func newCFClient(settings controllers.ControllersSettings) (*cf.Client, error) {
+	httpClient, err := cf.NewHTTPClient(settings.TLS)
+	if err != nil {
+		return nil, err
+	}
+	httpClient.Timeout = settings.CFAPITimeout
+
+	uaaClient, err := auth.NewUAAClient(settings.UAAEndpoint, settings.UAAClientName, settings.UAAClientSecret, httpClient)
+	if err != nil {
+		return nil, err
+	}
+	return cf.NewClient(cf.NewRestClient(settings.CFAPIHost, httpClient, uaaClient)), nil
+}
+
+func setSwitches(switches map[string]*controllers.ControllerSwitch, settings controllers.ControllersSettings) {
+	for name, controllerSwitch := range switches {
+		controllerSwitch.Set(!settings.DisabledControllers[name])
+	}
+}
@@ -0,0 +1,117 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+const namespace = "cf_api_controllers"
+
+var (
+	// CFAPIRequests counts requests made to the CF API by endpoint, method and
+	// response code. The code is "error" when no response was received.
+	CFAPIRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "cf_api_requests_total",
+		Help:      "Total number of requests made to the CF API.",
+	}, []string{"endpoint", "method", "code"})
+
+	// CFAPIRequestDuration observes the latency of requests to the CF API
+	CFAPIRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "cf_api_request_duration_seconds",
+		Help:      "Latency of requests made to the CF API.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"endpoint", "method"})
+
+	// UAATokenFetchDuration observes the latency of fetching tokens from UAA
+	UAATokenFetchDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "uaa_token_fetch_duration_seconds",
+		Help:      "Latency of fetching access tokens from UAA.",
+		Buckets:   prometheus.DefBuckets,
+	})
+
+	// UAATokenFetchFailures counts failed attempts to fetch tokens from UAA
+	UAATokenFetchFailures = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "uaa_token_fetch_failures_total",
+		Help:      "Total number of failed attempts to fetch access tokens from UAA.",
+	})
+
+	// UAATokenExpiry is when the cached UAA token expires. Falling behind the
+	// current time by more than a few minutes means refreshes keep failing.
+	UAATokenExpiry = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "uaa_token_expiry_timestamp_seconds",
+		Help:      "Unix time at which the cached UAA access token expires.",
+	})
+
+	// BuildsReported counts kpack builds reported to the CF API by the state
+	// they were marked with, STAGED or FAILED
+	BuildsReported = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "builds_reported_total",
+		Help:      "Total number of builds reported to the CF API, by state.",
+	}, []string{"state"})
+
+	// StackRebaseStatefulSetUpdates counts attempts to roll a rebased image out
+	// to an app's StatefulSets, by result
+	StackRebaseStatefulSetUpdates = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "stack_rebase_statefulset_updates_total",
+		Help:      "Total number of StatefulSet updates made for stack rebases, by result.",
+	}, []string{"result"})
+
+	// StackRebaseLRPUpdates counts attempts to roll a rebased image out to an
+	// app's Eirini LRPs, by result
+	StackRebaseLRPUpdates = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "stack_rebase_lrp_updates_total",
+		Help:      "Total number of Eirini LRP updates made for stack rebases, by result.",
+	}, []string{"result"})
+
+	// StackRebaseRollbacks counts attempts to roll an app back to its previous
+	// image after it did not become ready on its rebased one, by result
+	StackRebaseRollbacks = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "stack_rebase_rollbacks_total",
+		Help:      "Total number of stack rebases rolled back because the app did not become ready, by result.",
+	}, []string{"result"})
+
+	// PeriodicSyncChanges counts the resources changed by periodic syncs, by
+	// resource type and action (created, updated, deleted or failed)
+	PeriodicSyncChanges = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "periodic_sync_changes_total",
+		Help:      "Total number of resources changed by periodic syncs, by resource type and action.",
+	}, []string{"resource_type", "action"})
+)
+
+const (
+	ResultSuccess = "success"
+	ResultFailure = "failure"
+
+	SyncActionCreated = "created"
+	SyncActionUpdated = "updated"
+	SyncActionDeleted = "deleted"
+	SyncActionFailed  = "failed"
+
+	SyncActionUnsupported = "unsupported"
+)
+
+func init() {
+	// controller-runtime serves this registry on --metrics-addr
+	metrics.Registry.MustRegister(
+		CFAPIRequests,
+		CFAPIRequestDuration,
+		UAATokenFetchDuration,
+		UAATokenFetchFailures,
+		UAATokenExpiry,
+		BuildsReported,
+		StackRebaseStatefulSetUpdates,
+		StackRebaseLRPUpdates,
+		StackRebaseRollbacks,
+		PeriodicSyncChanges,
+	)
+}